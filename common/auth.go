@@ -0,0 +1,248 @@
+// credentials for fiware components behind PEP proxy
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AuthTokenHeader     = "X-Auth-Token"
+	AuthorizationHeader = "Authorization"
+
+	// TokenExpiryMargin is a time before expiry when a cached token is regarded as expired.
+	TokenExpiryMargin = 30 * time.Second
+)
+
+var (
+	NotRefreshableError = fmt.Errorf("credentials cannot be refreshed")
+)
+
+// Credentials attaches credentials to outgoing requests.
+// Refresh is called when a request is rejected with 401, then the request is replayed once.
+type Credentials interface {
+	Apply(ctx context.Context, req *http.Request) error
+	Refresh(ctx context.Context) error
+}
+
+// setToken sets token to given header. Authorization header has Bearer scheme.
+func setToken(req *http.Request, header, token string) {
+	if len(header) <= 0 {
+		header = AuthTokenHeader
+	}
+	if strings.EqualFold(header, AuthorizationHeader) {
+		token = "Bearer " + token
+	}
+	req.Header.Set(header, token)
+}
+
+// StaticToken attaches fixed token. Header is X-Auth-Token when empty. Authorization header has Bearer scheme.
+type StaticToken struct {
+	Token  string
+	Header string
+}
+
+// NewAuthToken returns credentials that set X-Auth-Token header.
+func NewAuthToken(token string) *StaticToken {
+	return &StaticToken{Token: token, Header: AuthTokenHeader}
+}
+
+// NewBearerToken returns credentials that set Authorization: Bearer header.
+func NewBearerToken(token string) *StaticToken {
+	return &StaticToken{Token: token, Header: AuthorizationHeader}
+}
+
+// Apply sets the token to given request.
+func (st *StaticToken) Apply(ctx context.Context, req *http.Request) error {
+	setToken(req, st.Header, st.Token)
+	return nil
+}
+
+// Refresh returns NotRefreshableError since the token is fixed.
+func (st *StaticToken) Refresh(ctx context.Context) error {
+	return NotRefreshableError
+}
+
+// KeyrockToken is a token response of Keyrock OAuth2.
+type KeyrockToken struct {
+	AccessToken  string   `json:"access_token"`
+	TokenType    string   `json:"token_type"`
+	ExpiresIn    int64    `json:"expires_in"`
+	RefreshToken string   `json:"refresh_token"`
+	Scope        []string `json:"scope"`
+}
+
+// KeyrockOAuth2 gets token from Keyrock with password grant (Username is set) or client credentials grant, and caches it until expiry.
+// Header is X-Auth-Token when empty, which Wilma PEP proxy accepts.
+// Concurrent callers share one token request, which is not canceled by their contexts; set timeout to HttpClient.
+type KeyrockOAuth2 struct {
+	URL          string // base url of Keyrock, e.g. http://keyrock:3005
+	ClientID     string
+	ClientSecret string
+	Username     string
+	Password     string
+	Header       string
+	HttpClient   *http.Client
+
+	mu           sync.Mutex
+	token        string
+	refreshToken string
+	expiry       time.Time
+	call         *tokenCall // token request in flight.
+}
+
+// tokenCall is a token request shared by concurrent callers. err is set before done is closed.
+type tokenCall struct {
+	done chan struct{}
+	err  error
+}
+
+// NewKeyrockPasswordGrant returns credentials that get token with resource owner password grant.
+func NewKeyrockPasswordGrant(keyrockURL, clientID, clientSecret, username, password string) *KeyrockOAuth2 {
+	return &KeyrockOAuth2{URL: keyrockURL, ClientID: clientID, ClientSecret: clientSecret, Username: username, Password: password}
+}
+
+// NewKeyrockClientCredentials returns credentials that get token with client credentials grant.
+func NewKeyrockClientCredentials(keyrockURL, clientID, clientSecret string) *KeyrockOAuth2 {
+	return &KeyrockOAuth2{URL: keyrockURL, ClientID: clientID, ClientSecret: clientSecret}
+}
+
+// Apply sets cached token to given request. Gets new one when it has not been cached or has expired.
+func (k *KeyrockOAuth2) Apply(ctx context.Context, req *http.Request) error {
+	k.mu.Lock()
+	if 0 < len(k.token) && (k.expiry.IsZero() || time.Now().Before(k.expiry)) {
+		token := k.token
+		k.mu.Unlock()
+		setToken(req, k.Header, token)
+		return nil
+	}
+	c := k.start(false)
+	k.mu.Unlock()
+
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+	k.mu.Lock()
+	token := k.token
+	k.mu.Unlock()
+	setToken(req, k.Header, token)
+	return nil
+}
+
+// Refresh gets new token with refresh token if any, otherwise with the original grant.
+func (k *KeyrockOAuth2) Refresh(ctx context.Context) error {
+	k.mu.Lock()
+	k.token = ""
+	c := k.start(true)
+	k.mu.Unlock()
+	return c.wait(ctx)
+}
+
+// start returns the token request in flight, or starts new one. Have to be called with the lock.
+func (k *KeyrockOAuth2) start(refresh bool) *tokenCall {
+	if k.call != nil {
+		return k.call
+	}
+	c := &tokenCall{done: make(chan struct{})}
+	k.call = c
+	refreshToken := ""
+	if refresh {
+		refreshToken = k.refreshToken
+	}
+	go func() {
+		t, err := k.request(refreshToken)
+		k.mu.Lock()
+		if err == nil {
+			k.token = t.AccessToken
+			k.refreshToken = t.RefreshToken
+			k.expiry = expiryOf(t.ExpiresIn)
+		}
+		k.call = nil
+		k.mu.Unlock()
+		c.err = err
+		close(c.done)
+	}()
+	return c
+}
+
+// wait waits for the token request. Returns context error when given context is done first.
+func (c *tokenCall) wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return c.err
+	}
+}
+
+// expiryOf returns a time when a token of given lifetime in seconds is regarded as expired. The margin is capped to a
+// quarter of the lifetime so that short-lived tokens are still cached. Zero means no expiry.
+func expiryOf(expiresIn int64) time.Time {
+	if expiresIn <= 0 {
+		return time.Time{}
+	}
+	lifetime := time.Duration(expiresIn) * time.Second
+	margin := TokenExpiryMargin
+	if lifetime/4 < margin {
+		margin = lifetime / 4
+	}
+	return time.Now().Add(lifetime - margin)
+}
+
+// grant returns form values of the original grant.
+func (k *KeyrockOAuth2) grant() url.Values {
+	if 0 < len(k.Username) {
+		return url.Values{"grant_type": {"password"}, "username": {k.Username}, "password": {k.Password}}
+	}
+	return url.Values{"grant_type": {"client_credentials"}}
+}
+
+// request gets token with given refresh token if any, otherwise or when it is rejected, with the original grant.
+func (k *KeyrockOAuth2) request(refreshToken string) (*KeyrockToken, error) {
+	if 0 < len(refreshToken) {
+		rt := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}}
+		if t, err := k.fetch(rt); err == nil {
+			return t, nil
+		}
+	}
+	return k.fetch(k.grant())
+}
+
+// fetch requests token to Keyrock. It is called without the lock.
+func (k *KeyrockOAuth2) fetch(form url.Values) (*KeyrockToken, error) {
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(k.URL, "/")+"/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(k.ClientID, k.ClientSecret)
+
+	client := k.HttpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("keyrock token request failed: %s", res.Status)
+	}
+
+	var t KeyrockToken
+	if err := json.NewDecoder(res.Body).Decode(&t); err != nil {
+		return nil, err
+	}
+	if len(t.AccessToken) <= 0 {
+		return nil, fmt.Errorf("keyrock returned no access token")
+	}
+	return &t, nil
+}
@@ -0,0 +1,191 @@
+package common_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/gohttp"
+	"github.com/stretchr/testify/assert"
+)
+
+// keyrock is a stand-in of Keyrock token endpoint that issues token-1, token-2, ...
+type keyrock struct {
+	mu        sync.Mutex
+	issued    int
+	grants    []string
+	expiresIn int           // 3599 when zero.
+	delay     time.Duration // wait before responding.
+}
+
+func (k *keyrock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if r.URL.Path != "/oauth2/token" || !ok || id != "client" || secret != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	_ = r.ParseForm()
+	time.Sleep(k.delay)
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.grants = append(k.grants, r.PostForm.Get("grant_type"))
+	if r.PostForm.Get("grant_type") == "password" && r.PostForm.Get("password") != "pass" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	k.issued++
+	expiresIn := k.expiresIn
+	if expiresIn <= 0 {
+		expiresIn = 3599
+	}
+	_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d,"refresh_token":"refresh-%d"}`, k.issued, expiresIn, k.issued)
+}
+
+// newProtected returns server that accepts only given token on X-Auth-Token and counts requests.
+func newProtected(token *string, count *int, bodies *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*count++
+		b, _ := ioutil.ReadAll(r.Body)
+		*bodies = append(*bodies, string(b))
+		if r.Header.Get(common.AuthTokenHeader) != *token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+func TestStaticToken(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/v2/entities", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, common.NewAuthToken("abc").Apply(context.Background(), req))
+	assert.EqualValues(t, "abc", req.Header.Get(common.AuthTokenHeader))
+	assert.NoError(t, common.NewBearerToken("abc").Apply(context.Background(), req))
+	assert.EqualValues(t, "Bearer abc", req.Header.Get(common.AuthorizationHeader))
+	assert.True(t, errors.Is(common.NewAuthToken("abc").Refresh(context.Background()), common.NotRefreshableError))
+}
+
+func TestKeyrockOAuth2(t *testing.T) {
+	k := &keyrock{}
+	ts := httptest.NewServer(k)
+	defer ts.Close()
+
+	c := common.NewKeyrockPasswordGrant(ts.URL, "client", "secret", "alice", "pass")
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest(http.MethodGet, "http://localhost/v2/entities", nil)
+		assert.NoError(t, err)
+		assert.NoError(t, c.Apply(context.Background(), req))
+		assert.EqualValues(t, "token-1", req.Header.Get(common.AuthTokenHeader))
+	}
+	assert.NoError(t, c.Refresh(context.Background()))
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/v2/entities", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, c.Apply(context.Background(), req))
+	assert.EqualValues(t, "token-2", req.Header.Get(common.AuthTokenHeader))
+	assert.EqualValues(t, []string{"password", "refresh_token"}, k.grants)
+
+	c = common.NewKeyrockClientCredentials(ts.URL, "client", "secret")
+	c.Header = common.AuthorizationHeader
+	assert.NoError(t, c.Apply(context.Background(), req))
+	assert.EqualValues(t, "Bearer token-3", req.Header.Get(common.AuthorizationHeader))
+	assert.EqualValues(t, "client_credentials", k.grants[2])
+
+	c = common.NewKeyrockPasswordGrant(ts.URL, "client", "secret", "alice", "wrong")
+	assert.Error(t, c.Apply(context.Background(), req))
+}
+
+func TestKeyrockOAuth2_Concurrent(t *testing.T) {
+	k := &keyrock{delay: 50 * time.Millisecond}
+	ts := httptest.NewServer(k)
+	defer ts.Close()
+	c := common.NewKeyrockPasswordGrant(ts.URL, "client", "secret", "alice", "pass")
+
+	// the caller gives up by its own context while the token request is in flight.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/v2/entities", nil)
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(c.Apply(ctx, req), context.DeadlineExceeded))
+
+	// concurrent callers share one token request.
+	var wg sync.WaitGroup
+	tokens := make([]string, 10)
+	for i := range tokens {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req, err := http.NewRequest(http.MethodGet, "http://localhost/v2/entities", nil)
+			assert.NoError(t, err)
+			assert.NoError(t, c.Apply(context.Background(), req))
+			tokens[i] = req.Header.Get(common.AuthTokenHeader)
+		}(i)
+	}
+	wg.Wait()
+	for _, tk := range tokens {
+		assert.EqualValues(t, "token-1", tk)
+	}
+	assert.EqualValues(t, 1, k.issued)
+}
+
+func TestKeyrockOAuth2_ShortLived(t *testing.T) {
+	k := &keyrock{expiresIn: 20}
+	ts := httptest.NewServer(k)
+	defer ts.Close()
+	c := common.NewKeyrockClientCredentials(ts.URL, "client", "secret")
+
+	// token shorter than the margin is still cached.
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest(http.MethodGet, "http://localhost/v2/entities", nil)
+		assert.NoError(t, err)
+		assert.NoError(t, c.Apply(context.Background(), req))
+		assert.EqualValues(t, "token-1", req.Header.Get(common.AuthTokenHeader))
+	}
+	assert.EqualValues(t, 1, k.issued)
+}
+
+func TestSender_Refresh(t *testing.T) {
+	k := &keyrock{}
+	ks := httptest.NewServer(k)
+	defer ks.Close()
+
+	// the server accepts token-2 only, so the first request is rejected and replayed with refreshed token.
+	token, count, bodies := "token-2", 0, []string{}
+	ts := newProtected(&token, &count, &bodies)
+	defer ts.Close()
+
+	s := common.Sender{Client: new(http.Client), Credentials: common.NewKeyrockPasswordGrant(ks.URL, "client", "secret", "alice", "pass")}
+	req, err := common.GenRequest(nil, gohttp.HttpMethods.POST, ts.URL+"/v2/entities", map[string]string{"id": "a"})
+	assert.NoError(t, err)
+	res, err := s.Do(req)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusNoContent, res.StatusCode)
+	assert.EqualValues(t, 2, count)
+	assert.EqualValues(t, []string{`{"id":"a"}`, `{"id":"a"}`}, bodies)
+
+	// replayed only once.
+	token, count = "token-9", 0
+	req, err = common.GenRequest(nil, gohttp.HttpMethods.GET, ts.URL+"/v2/entities", nil)
+	assert.NoError(t, err)
+	res, err = s.Do(req)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusUnauthorized, res.StatusCode)
+	assert.EqualValues(t, 2, count)
+
+	// static token is not replayed.
+	count = 0
+	s.Credentials = common.NewAuthToken("token-1")
+	req, err = http.NewRequest(http.MethodPost, ts.URL+"/v2/entities", strings.NewReader(`{}`))
+	assert.NoError(t, err)
+	res, err = s.Do(req)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusUnauthorized, res.StatusCode)
+	assert.EqualValues(t, 1, count)
+}
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strings"
 )
 
 const (
 	ServiceHeader     = "fiware-service"
 	ServicePathHeader = "fiware-servicepath"
+
+	MaxServicePathScopes = 10   // max number of comma-separated service paths in a query.
+	HierarchicalWildcard = "/#" // suffix that matches the service path and all its descendants.
 )
 
 var (
@@ -30,8 +34,43 @@ func IsValidServicePath(s string) bool {
 	return ReServicePath.MatchString(s)
 }
 
+// IsServicePathScope returns whether given strings is a query scope (multiple paths or hierarchical) or not.
+func IsServicePathScope(s string) bool {
+	return strings.Contains(s, ",") || strings.HasSuffix(s, HierarchicalWildcard)
+}
+
+// IsValidServicePathScope returns whether given strings suit to fiware-servicepath of a query or not.
+// It accepts comma-separated service paths and the hierarchical wildcard such as `/a/#`.
+func IsValidServicePathScope(s string) bool {
+	paths := strings.Split(s, ",")
+	if MaxServicePathScopes < len(paths) {
+		return false
+	}
+	for _, p := range paths {
+		p = strings.TrimSpace(p)
+		if p == HierarchicalWildcard {
+			continue
+		}
+		if !IsValidServicePath(strings.TrimSuffix(p, HierarchicalWildcard)) {
+			return false
+		}
+	}
+	return true
+}
+
 // AddServiceHeader(req, service, servicePath)
+// servicePath has to be a single path. Use AddServiceScopeHeader for queries.
 func AddServiceHeader(req *http.Request, service, servicePath string) error {
+	return addServiceHeader(req, service, servicePath, IsValidServicePath)
+}
+
+// AddServiceScopeHeader is AddServiceHeader that accepts a query scope as servicePath, such as `/a,/b` or `/a/#`.
+// Use it for entity queries only, since servers reject scopes for other operations.
+func AddServiceScopeHeader(req *http.Request, service, servicePath string) error {
+	return addServiceHeader(req, service, servicePath, IsValidServicePathScope)
+}
+
+func addServiceHeader(req *http.Request, service, servicePath string, isValidPath func(string) bool) error {
 	if 0 < len(service) {
 		if !IsValidService(service) {
 			return InvalidServiceName
@@ -39,7 +78,7 @@ func AddServiceHeader(req *http.Request, service, servicePath string) error {
 		req.Header.Add(ServiceHeader, service)
 	}
 	if 0 < len(servicePath) {
-		if !IsValidServicePath(servicePath) {
+		if !isValidPath(servicePath) {
 			return InvalidServicePath
 		}
 		req.Header.Add(ServicePathHeader, servicePath)
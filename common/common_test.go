@@ -0,0 +1,40 @@
+package common_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidServicePathScope(t *testing.T) {
+	for _, s := range []string{"/", "/a", "/a/b", "/#", "/a/#", "/a,/b", "/a/#,/b/c"} {
+		assert.True(t, common.IsValidServicePathScope(s), s)
+	}
+	for _, s := range []string{"", "a", "/a/", "/a#", "/a/#/#", "/a,", "/1,/2,/3,/4,/5,/6,/7,/8,/9,/10,/11"} {
+		assert.False(t, common.IsValidServicePathScope(s), s)
+	}
+	assert.True(t, common.IsServicePathScope("/a,/b"))
+	assert.True(t, common.IsServicePathScope("/a/#"))
+	assert.False(t, common.IsServicePathScope("/a"))
+}
+
+func TestAddServiceHeader(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/v2/entities", nil)
+	assert.NoError(t, err)
+	assert.EqualError(t, common.AddServiceHeader(req, "openiot", "/a/#"), common.InvalidServicePath.Error())
+	assert.EqualError(t, common.AddServiceHeader(req, "openiot", "/a,/b"), common.InvalidServicePath.Error())
+	assert.NoError(t, common.AddServiceHeader(req, "openiot", "/a"))
+	assert.EqualValues(t, "/a", req.Header.Get(common.ServicePathHeader))
+	assert.EqualError(t, common.AddServiceHeader(req, "Open", "/"), common.InvalidServiceName.Error())
+	assert.EqualError(t, common.AddServiceHeader(req, "", "/a/"), common.InvalidServicePath.Error())
+}
+
+func TestAddServiceScopeHeader(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/v2/entities", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, common.AddServiceScopeHeader(req, "openiot", "/a/#"))
+	assert.EqualValues(t, "/a/#", req.Header.Get(common.ServicePathHeader))
+	assert.EqualError(t, common.AddServiceScopeHeader(req, "", "/a,/b/"), common.InvalidServicePath.Error())
+}
@@ -0,0 +1,39 @@
+package common
+
+import "context"
+
+type contextKey int
+
+const (
+	serviceKey contextKey = iota
+	servicePathKey
+	correlatorKey
+)
+
+// WithFiwareHeaders returns context that holds fiware-service, fiware-servicepath and fiware-correlator of a request
+// received from fiware components.
+func WithFiwareHeaders(ctx context.Context, service, servicePath, correlator string) context.Context {
+	ctx = context.WithValue(ctx, serviceKey, service)
+	ctx = context.WithValue(ctx, servicePathKey, servicePath)
+	return context.WithValue(ctx, correlatorKey, correlator)
+}
+
+func stringValue(ctx context.Context, key contextKey) string {
+	s, _ := ctx.Value(key).(string)
+	return s
+}
+
+// ServiceFromContext returns fiware-service held by given context.
+func ServiceFromContext(ctx context.Context) string {
+	return stringValue(ctx, serviceKey)
+}
+
+// ServicePathFromContext returns fiware-servicepath held by given context.
+func ServicePathFromContext(ctx context.Context) string {
+	return stringValue(ctx, servicePathKey)
+}
+
+// CorrelatorFromContext returns fiware-correlator held by given context.
+func CorrelatorFromContext(ctx context.Context) string {
+	return stringValue(ctx, correlatorKey)
+}
@@ -0,0 +1,78 @@
+// error response of fiware components
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// APIError holds error response returned from fiware components, which have `error` and `description` in the payload.
+type APIError struct {
+	StatusCode  int    `json:"-"`           // HTTP status code. ex) 404
+	Status      string `json:"-"`           // HTTP status. ex) 404 Not Found
+	Code        string `json:"error"`       // error code. ex) NotFound
+	Description string `json:"description"` // error description.
+	Method      string `json:"-"`           // HTTP method of the request.
+	URL         string `json:"-"`           // URL of the request.
+	Correlator  string `json:"-"`           // Fiware-Correlator header of the response.
+	Err         error  `json:"-"`           // sentinel error of the accessor package, e.g. not found. Can be nil.
+}
+
+// Error returns error strings. It begins with HTTP status to keep compatibility.
+func (e *APIError) Error() string {
+	s := e.Status
+	if 0 < len(e.Code) {
+		s = fmt.Sprintf("%s: %s", s, e.Code)
+	}
+	if 0 < len(e.Description) {
+		s = fmt.Sprintf("%s (%s)", s, e.Description)
+	}
+	return fmt.Sprintf("%s [%s %s]", s, e.Method, e.URL)
+}
+
+// Unwrap returns the sentinel error so that errors.Is works.
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// FiwareCorrelator returns Fiware-Correlator header of the response. Implements Correlated.
+func (e *APIError) FiwareCorrelator() string {
+	return e.Correlator
+}
+
+// NewAPIError generates APIError instance from given response. Body of the response is consumed and closed.
+func NewAPIError(res *http.Response) *APIError {
+	e := APIError{
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Correlator: res.Header.Get(CorrelatorHeader),
+	}
+	if res.Request != nil {
+		e.Method = res.Request.Method
+		e.URL = res.Request.URL.String()
+	}
+
+	defer res.Body.Close()
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil || len(b) <= 0 {
+		return &e
+	}
+	if err := json.Unmarshal(b, &e); err != nil {
+		// not a JSON error payload, hold the body as it is.
+		e.Description = strings.TrimSpace(string(b))
+	}
+	return &e
+}
+
+// AsAPIError returns APIError held by given error or nil when it does not hold APIError.
+func AsAPIError(err error) *APIError {
+	var e *APIError
+	if errors.As(err, &e) {
+		return e
+	}
+	return nil
+}
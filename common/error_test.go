@@ -0,0 +1,40 @@
+package common_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAPIError(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://localhost/v2/entities/Room1", nil)
+	assert.NoError(t, err)
+	res := &http.Response{
+		StatusCode: http.StatusNotFound,
+		Status:     "404 Not Found",
+		Header:     http.Header{common.CorrelatorHeader: {"c1"}},
+		Body:       ioutil.NopCloser(strings.NewReader(`{"error":"NotFound","description":"The requested entity has not been found. Check type and id"}`)),
+		Request:    req,
+	}
+	e := common.NewAPIError(res)
+	assert.EqualValues(t, "NotFound", e.Code)
+	assert.EqualValues(t, "404 Not Found: NotFound (The requested entity has not been found. Check type and id) [GET http://localhost/v2/entities/Room1]", e.Error())
+	assert.EqualValues(t, "c1", common.CorrelatorOf(e))
+
+	// sentinel set by the accessor package is unwrapped.
+	notFound := fmt.Errorf("not found")
+	e.Err = notFound
+	wrapped := fmt.Errorf("wrapped: %w", e)
+	assert.True(t, errors.Is(wrapped, notFound))
+	assert.Equal(t, e, common.AsAPIError(wrapped))
+	assert.Nil(t, common.AsAPIError(notFound))
+
+	res.Body = ioutil.NopCloser(strings.NewReader("Bad Gateway\n"))
+	assert.EqualValues(t, "Bad Gateway", common.NewAPIError(res).Description)
+}
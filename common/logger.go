@@ -0,0 +1,181 @@
+// pluggable structured logger
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// LogLevel is a severity of a log record.
+type LogLevel int
+
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+// Redacted replaces secrets in logs.
+const Redacted = "[REDACTED]"
+
+// String returns name of the level.
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelDebug:
+		return "DEBUG"
+	case LogLevelInfo:
+		return "INFO"
+	case LogLevelWarn:
+		return "WARN"
+	case LogLevelError:
+		return "ERROR"
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// Logger writes structured log records. keyvals are alternating keys and values.
+// Accessors pass values through Redact before logging, so API keys and tokens in URLs and headers are not written.
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{})
+}
+
+// NopLogger discards all records. It is the default of accessors.
+type NopLogger struct{}
+
+// Log does nothing.
+func (NopLogger) Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {}
+
+// StdLogger writes records to standard library logger in `LEVEL msg key=value ...` form.
+type StdLogger struct {
+	Logger   *log.Logger
+	MinLevel LogLevel
+}
+
+// NewStdLogger returns StdLogger instance that writes records at given level or higher.
+// Writes to the output of the standard logger when l is nil.
+func NewStdLogger(l *log.Logger, min LogLevel) *StdLogger {
+	if l == nil {
+		l = log.New(log.Writer(), log.Prefix(), log.Flags())
+	}
+	return &StdLogger{Logger: l, MinLevel: min}
+}
+
+// Log writes a record.
+func (s *StdLogger) Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	if level < s.MinLevel {
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString(level.String())
+	sb.WriteString(" ")
+	sb.WriteString(msg)
+	keyvals = Redact(keyvals...)
+	for i := 0; i < len(keyvals); i += 2 {
+		var v interface{} = "(MISSING)"
+		if i+1 < len(keyvals) {
+			v = keyvals[i+1]
+		}
+		_, _ = fmt.Fprintf(&sb, " %v=%q", keyvals[i], fmt.Sprint(v))
+	}
+	_ = s.Logger.Output(2, sb.String())
+}
+
+// sensitiveKeys are lower case names of keys, query parameters and headers whose values are redacted.
+var sensitiveKeys = map[string]bool{
+	"k":             true, // api key of iot agent southbound.
+	"apikey":        true,
+	"api_key":       true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"password":      true,
+	"secret":        true,
+	"client_secret": true,
+	"authorization": true,
+	"x-auth-token":  true,
+	"cookie":        true,
+	"set-cookie":    true,
+}
+
+// IsSensitiveKey returns whether value of given key, query parameter or header has to be redacted.
+func IsSensitiveKey(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// Redact returns copy of given keyvals whose secrets are replaced. Values of sensitive keys are replaced,
+// and URLs, headers and transport errors have their sensitive query parameters and headers replaced.
+func Redact(keyvals ...interface{}) []interface{} {
+	r := make([]interface{}, len(keyvals))
+	copy(r, keyvals)
+	for i := 1; i < len(r); i += 2 {
+		if k, ok := r[i-1].(string); ok && IsSensitiveKey(k) {
+			r[i] = Redacted
+			continue
+		}
+		switch v := r[i].(type) {
+		case *url.URL:
+			r[i] = RedactURL(v)
+		case http.Header:
+			r[i] = RedactHeader(v)
+		case error:
+			r[i] = RedactError(v)
+		}
+	}
+	return r
+}
+
+// RedactURL returns given URL string whose user password and sensitive query parameters are replaced.
+func RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	c := *u
+	if c.User != nil {
+		if _, ok := c.User.Password(); ok {
+			c.User = url.UserPassword(c.User.Username(), Redacted)
+		}
+	}
+	q := c.Query()
+	redacted := false
+	for k := range q {
+		if IsSensitiveKey(k) {
+			q.Set(k, Redacted)
+			redacted = true
+		}
+	}
+	if redacted {
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
+
+// RedactError returns message of given error whose URL is redacted. Transport errors (*url.Error) hold the full
+// request URL, which may have an API key in its query.
+func RedactError(err error) string {
+	var ue *url.Error
+	if !errors.As(err, &ue) || len(ue.URL) <= 0 {
+		return err.Error()
+	}
+	redacted := Redacted
+	if u, perr := url.Parse(ue.URL); perr == nil {
+		redacted = RedactURL(u)
+	}
+	return strings.Replace(err.Error(), ue.URL, redacted, -1)
+}
+
+// RedactHeader returns copy of given header whose sensitive headers are replaced.
+func RedactHeader(h http.Header) http.Header {
+	r := h.Clone()
+	for k := range r {
+		if IsSensitiveKey(k) {
+			r[k] = []string{Redacted}
+		}
+	}
+	return r
+}
@@ -0,0 +1,162 @@
+// hooks for outgoing requests
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Exchange is an outgoing call that middlewares see.
+type Exchange struct {
+	Request      *http.Request  // headers can be modified by Before.
+	Body         []byte         // payload of the request.
+	Response     *http.Response // nil when the call failed.
+	ResponseBody []byte         // payload of the response. The response body can be read again by the caller.
+	Err          error
+	Latency      time.Duration // from the first attempt to the final response including retries.
+}
+
+// Status returns status code of the response, 0 when the call failed.
+func (ex *Exchange) Status() int {
+	if ex.Response == nil {
+		return 0
+	}
+	return ex.Response.StatusCode
+}
+
+// Middleware observes every outgoing call of an accessor.
+// Before is called in order before the call. The call is aborted with the error when Before returns error.
+// After is called in reverse order after the final response of the call (after retries).
+type Middleware interface {
+	Before(ex *Exchange) error
+	After(ex *Exchange)
+}
+
+// MiddlewareFuncs is a Middleware with functions. Nil function is skipped.
+type MiddlewareFuncs struct {
+	BeforeFunc func(ex *Exchange) error
+	AfterFunc  func(ex *Exchange)
+}
+
+// Before calls BeforeFunc.
+func (m MiddlewareFuncs) Before(ex *Exchange) error {
+	if m.BeforeFunc == nil {
+		return nil
+	}
+	return m.BeforeFunc(ex)
+}
+
+// After calls AfterFunc.
+func (m MiddlewareFuncs) After(ex *Exchange) {
+	if m.AfterFunc != nil {
+		m.AfterFunc(ex)
+	}
+}
+
+// HeaderMiddleware returns Middleware that sets given headers to every request.
+func HeaderMiddleware(header http.Header) Middleware {
+	return MiddlewareFuncs{BeforeFunc: func(ex *Exchange) error {
+		for k, v := range header {
+			ex.Request.Header[http.CanonicalHeaderKey(k)] = v
+		}
+		return nil
+	}}
+}
+
+// DumpMiddleware returns Middleware that writes every call to given writer as curl command and response.
+// Tokens and API keys in headers and query are redacted.
+func DumpMiddleware(w io.Writer) Middleware {
+	return MiddlewareFuncs{AfterFunc: func(ex *Exchange) {
+		req := ex.Request.Clone(ex.Request.Context())
+		req.Header = RedactHeader(req.Header)
+		if u, err := url.Parse(RedactURL(req.URL)); err == nil {
+			req.URL = u
+		}
+		if ex.Err != nil {
+			_, _ = fmt.Fprintf(w, "%s\n# error: %s (%s)\n", Curl(req, ex.Body), ex.Err, ex.Latency)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "%s\n# %s (%s)\n%s\n", Curl(req, ex.Body), ex.Response.Status, ex.Latency, ex.ResponseBody)
+	}}
+}
+
+// RequestBody returns payload of given request without consuming it.
+func RequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		// read it and put back.
+		b, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		_ = req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(b)), nil
+		}
+		return b, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return ioutil.ReadAll(body)
+}
+
+// Curl returns curl command that sends the same request.
+func Curl(req *http.Request, body []byte) string {
+	var sb strings.Builder
+	sb.WriteString("curl -X ")
+	sb.WriteString(req.Method)
+	sb.WriteString(" ")
+	sb.WriteString(shellQuote(req.URL.String()))
+
+	keys := make([]string, 0, len(req.Header))
+	for k := range req.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range req.Header[k] {
+			sb.WriteString(" -H ")
+			sb.WriteString(shellQuote(k + ": " + v))
+		}
+	}
+	if 0 < len(body) {
+		sb.WriteString(" --data-raw ")
+		sb.WriteString(shellQuote(string(body)))
+	}
+	return sb.String()
+}
+
+// shellQuote quotes given string with single quotes for POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// before calls Before of middlewares in order.
+func before(ms []Middleware, ex *Exchange) error {
+	for _, m := range ms {
+		if err := m.Before(ex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// after calls After of middlewares in reverse order.
+func after(ms []Middleware, ex *Exchange) {
+	for i := len(ms) - 1; 0 <= i; i-- {
+		ms[i].After(ex)
+	}
+}
@@ -0,0 +1,95 @@
+package common_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/gohttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSender_Middlewares(t *testing.T) {
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		b, _ := ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(b)
+	}))
+	defer ts.Close()
+
+	var order []string
+	var seen *common.Exchange
+	s := common.Sender{Middlewares: []common.Middleware{
+		common.HeaderMiddleware(http.Header{"X-Request-Source": {"ingestion"}}),
+		common.MiddlewareFuncs{
+			BeforeFunc: func(ex *common.Exchange) error {
+				order = append(order, "before")
+				return nil
+			},
+			AfterFunc: func(ex *common.Exchange) {
+				order = append(order, "after")
+				seen = ex
+			},
+		},
+	}}
+
+	req, err := common.GenRequest(nil, gohttp.HttpMethods.POST, ts.URL+"/v2/entities", map[string]string{"id": "a"})
+	assert.NoError(t, err)
+	res, err := s.Do(req)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"before", "after"}, order)
+	assert.EqualValues(t, "ingestion", received.Get("X-Request-Source"))
+	assert.EqualValues(t, http.MethodPost, seen.Request.Method)
+	assert.EqualValues(t, `{"id":"a"}`, string(seen.Body))
+	assert.EqualValues(t, http.StatusCreated, seen.Status())
+	assert.EqualValues(t, `{"id":"a"}`, string(seen.ResponseBody))
+	assert.True(t, 0 < seen.Latency)
+
+	// the caller can still read the response.
+	b, err := ioutil.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.EqualValues(t, `{"id":"a"}`, string(b))
+
+	// abort by before hook.
+	s.Middlewares = append(s.Middlewares, common.MiddlewareFuncs{BeforeFunc: func(ex *common.Exchange) error {
+		return fmt.Errorf("denied")
+	}})
+	req, err = common.GenRequest(nil, gohttp.HttpMethods.GET, ts.URL+"/v2/entities", nil)
+	assert.NoError(t, err)
+	_, err = s.Do(req)
+	assert.EqualError(t, err, "denied")
+}
+
+func TestCurl(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "http://localhost:1026/v2/entities?options=keyValues", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Fiware-Service", "openiot")
+	req.Header.Set("Content-Type", "application/json")
+	assert.EqualValues(t,
+		`curl -X POST 'http://localhost:1026/v2/entities?options=keyValues' -H 'Content-Type: application/json' -H 'Fiware-Service: openiot' --data-raw '{"name":"Joe'\''s"}'`,
+		common.Curl(req, []byte(`{"name":"Joe's"}`)))
+}
+
+func TestDumpMiddleware(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[]`))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	s := common.Sender{Middlewares: []common.Middleware{common.DumpMiddleware(&buf)}}
+	req, err := http.NewRequest(http.MethodPut, ts.URL, strings.NewReader(`{"a":1}`))
+	assert.NoError(t, err)
+	_, err = s.Do(req)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), fmt.Sprintf(`curl -X PUT '%s' --data-raw '{"a":1}'`, ts.URL))
+	assert.Contains(t, buf.String(), "# 200 OK")
+	assert.Contains(t, buf.String(), "\n[]\n")
+}
@@ -0,0 +1,48 @@
+// request helpers shared by the accessors.
+package common
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/marrbor/gohttp"
+)
+
+// GenRequest generates an HTTP request bound to given context. Body is marshaled to JSON when it is not nil.
+func GenRequest(ctx context.Context, method gohttp.HTTPMethod, url string, body interface{}) (*http.Request, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var buf io.Reader = nil
+	if body != nil {
+		bj, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		buf = bytes.NewReader(bj)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method.String(), url, buf)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// ContextError returns the error of given context (context.Canceled or context.DeadlineExceeded) when it has been done,
+// otherwise returns given error as it is.
+func ContextError(ctx context.Context, err error) error {
+	if err == nil || ctx == nil {
+		return err
+	}
+	if ce := ctx.Err(); ce != nil {
+		return ce
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package common_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/gohttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := common.GenRequest(ctx, gohttp.HttpMethods.POST, "http://localhost/v2/entities", map[string]string{"id": "a"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.MethodPost, req.Method)
+	assert.EqualValues(t, "application/json", req.Header.Get("Content-Type"))
+	assert.EqualValues(t, ctx, req.Context())
+	b, err := ioutil.ReadAll(req.Body)
+	assert.NoError(t, err)
+	assert.EqualValues(t, `{"id":"a"}`, string(b))
+
+	req, err = common.GenRequest(nil, gohttp.HttpMethods.GET, "http://localhost/v2/entities", nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "", req.Header.Get("Content-Type"))
+	assert.NotNil(t, req.Context())
+}
+
+func TestContextError(t *testing.T) {
+	e := fmt.Errorf("other")
+	assert.Nil(t, common.ContextError(context.Background(), nil))
+	assert.EqualValues(t, e, common.ContextError(context.Background(), e))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.EqualValues(t, context.Canceled, common.ContextError(ctx, e))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	<-ctx.Done()
+	assert.EqualValues(t, context.DeadlineExceeded, common.ContextError(ctx, e))
+}
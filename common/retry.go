@@ -0,0 +1,206 @@
+// retry and circuit breaker for transient failures
+package common
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts    = 3
+	DefaultInitialBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff     = 5 * time.Second
+	DefaultBackoffFactor  = 2.0
+	DefaultJitter         = 0.2
+
+	DefaultFailureThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+
+	RetryAfterHeader = "Retry-After"
+)
+
+var (
+	CircuitOpenError = fmt.Errorf("circuit breaker is open")
+)
+
+// RetryPolicy is a policy to retry requests that failed transiently,
+// i.e. network errors and 429, 502, 503, 504 responses.
+// Only idempotent methods (GET, HEAD, OPTIONS, PUT, DELETE) are retried unless RetryNonIdempotent is set.
+type RetryPolicy struct {
+	MaxAttempts        int           // number of attempts including the first one.
+	InitialBackoff     time.Duration // wait before the second attempt.
+	MaxBackoff         time.Duration // upper limit of the wait. Retry-After is also capped by this.
+	Factor             float64       // multiplier of the wait for each attempt.
+	Jitter             float64       // randomization ratio of the wait, 0 to 1.
+	RetryNonIdempotent bool          // retry POST and PATCH too.
+}
+
+// NewRetryPolicy returns RetryPolicy instance with default parameters.
+func NewRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    DefaultMaxAttempts,
+		InitialBackoff: DefaultInitialBackoff,
+		MaxBackoff:     DefaultMaxBackoff,
+		Factor:         DefaultBackoffFactor,
+		Jitter:         DefaultJitter,
+	}
+}
+
+// Backoff returns a wait before given attempt (2 for the first retry).
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	if attempt <= 1 {
+		return 0
+	}
+	factor := p.Factor
+	if factor < 1 {
+		factor = 1
+	}
+	d := float64(p.InitialBackoff) * math.Pow(factor, float64(attempt-2))
+	if 0 < p.MaxBackoff && float64(p.MaxBackoff) < d {
+		d = float64(p.MaxBackoff)
+	}
+	if 0 < p.Jitter {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+// Retryable returns whether a request with given method can be retried.
+func (p *RetryPolicy) Retryable(method string) bool {
+	if p.RetryNonIdempotent {
+		return true
+	}
+	return IsIdempotent(method)
+}
+
+// IsIdempotent returns whether given method is idempotent.
+func IsIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// IsTransientStatus returns whether given status code means a transient failure.
+func IsTransientStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// RetryAfter returns a wait specified by Retry-After header of given response, in seconds or HTTP date.
+func RetryAfter(res *http.Response) (time.Duration, bool) {
+	v := res.Header.Get(RetryAfterHeader)
+	if len(v) <= 0 {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil && 0 <= s {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// breaker states
+const (
+	breakerClosed = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// CircuitBreaker fails requests fast while the server is down. It opens after Threshold consecutive transient failures,
+// then lets one trial request through after Cooldown. Share one instance among accessors that access the same server.
+type CircuitBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    int
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker returns CircuitBreaker instance.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+// Allow returns CircuitOpenError when the breaker is open.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown() {
+			return CircuitOpenError
+		}
+		b.state = breakerHalfOpen // let this request through as a trial.
+		return nil
+	case breakerHalfOpen:
+		return CircuitOpenError // trial request is in flight.
+	}
+	return nil
+}
+
+// Success records a request that the server responded and closes the breaker.
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = breakerClosed
+	b.failures = 0
+}
+
+// Failure records a transient failure and opens the breaker when reached to the threshold or the trial failed.
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	threshold := b.Threshold
+	if threshold <= 0 {
+		threshold = DefaultFailureThreshold
+	}
+	if b.state == breakerHalfOpen || threshold <= b.failures {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// Cancel records a request that ended without a response and without a transient failure, e.g. canceled by its
+// context or failed to apply credentials. Such a trial tells nothing about the server, so the breaker goes back to
+// open and lets the next request through as a trial.
+func (b *CircuitBreaker) Cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+	}
+}
+
+// IsOpen returns whether the breaker rejects requests now.
+func (b *CircuitBreaker) IsOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state == breakerOpen && time.Since(b.openedAt) < b.cooldown()
+}
+
+// cooldown returns Cooldown or default one when it is not specified.
+func (b *CircuitBreaker) cooldown() time.Duration {
+	if b.Cooldown <= 0 {
+		return DefaultBreakerCooldown
+	}
+	return b.Cooldown
+}
@@ -0,0 +1,227 @@
+package common_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFlaky returns server that responds given status to the first n requests, then 200.
+func newFlaky(n int, status int, header http.Header, count *int, bodies *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*count++
+		b, _ := ioutil.ReadAll(r.Body)
+		*bodies = append(*bodies, string(b))
+		if *count <= n {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func fastRetry() *common.RetryPolicy {
+	p := common.NewRetryPolicy()
+	p.InitialBackoff = time.Millisecond
+	p.MaxBackoff = 10 * time.Millisecond
+	return p
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := &common.RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Factor: 2}
+	assert.EqualValues(t, 0, p.Backoff(1))
+	assert.EqualValues(t, 100*time.Millisecond, p.Backoff(2))
+	assert.EqualValues(t, 200*time.Millisecond, p.Backoff(3))
+	assert.EqualValues(t, time.Second, p.Backoff(10))
+
+	p.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		d := p.Backoff(2)
+		assert.True(t, 50*time.Millisecond <= d && d <= 150*time.Millisecond, d)
+	}
+	assert.True(t, p.Retryable(http.MethodPut))
+	assert.False(t, p.Retryable(http.MethodPost))
+	p.RetryNonIdempotent = true
+	assert.True(t, p.Retryable(http.MethodPatch))
+}
+
+func TestRetryAfter(t *testing.T) {
+	res := &http.Response{Header: http.Header{}}
+	_, ok := common.RetryAfter(res)
+	assert.False(t, ok)
+	res.Header.Set(common.RetryAfterHeader, "3")
+	d, ok := common.RetryAfter(res)
+	assert.True(t, ok)
+	assert.EqualValues(t, 3*time.Second, d)
+	res.Header.Set(common.RetryAfterHeader, time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	d, ok = common.RetryAfter(res)
+	assert.True(t, ok)
+	assert.EqualValues(t, 0, d)
+}
+
+func TestSender_Retry(t *testing.T) {
+	count, bodies := 0, []string{}
+	ts := newFlaky(2, http.StatusServiceUnavailable, http.Header{common.RetryAfterHeader: {"0"}}, &count, &bodies)
+	defer ts.Close()
+	s := common.Sender{Retry: fastRetry()}
+
+	req, err := http.NewRequest(http.MethodPut, ts.URL, strings.NewReader(`{"a":1}`))
+	assert.NoError(t, err)
+	res, err := s.Do(req)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.EqualValues(t, []string{`{"a":1}`, `{"a":1}`, `{"a":1}`}, bodies)
+
+	// POST is not retried unless opted in.
+	count, bodies = 0, []string{}
+	req, err = http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{}`))
+	assert.NoError(t, err)
+	res, err = s.Do(req)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.EqualValues(t, 1, count)
+
+	count, bodies = 0, []string{}
+	s.Retry.RetryNonIdempotent = true
+	req, err = http.NewRequest(http.MethodPost, ts.URL, strings.NewReader(`{}`))
+	assert.NoError(t, err)
+	res, err = s.Do(req)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.EqualValues(t, 3, count)
+
+	// gives up after max attempts.
+	count, bodies = 0, []string{}
+	s.Retry.MaxAttempts = 2
+	req, err = http.NewRequest(http.MethodGet, ts.URL, nil)
+	assert.NoError(t, err)
+	res, err = s.Do(req)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.EqualValues(t, 2, count)
+
+	// client errors are not retried.
+	count, bodies = 0, []string{}
+	ts4 := newFlaky(2, http.StatusBadRequest, nil, &count, &bodies)
+	defer ts4.Close()
+	req, err = http.NewRequest(http.MethodGet, ts4.URL, nil)
+	assert.NoError(t, err)
+	res, err = s.Do(req)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, res.StatusCode)
+	assert.EqualValues(t, 1, count)
+}
+
+func TestSender_RetryCanceled(t *testing.T) {
+	count, bodies := 0, []string{}
+	ts := newFlaky(10, http.StatusServiceUnavailable, http.Header{common.RetryAfterHeader: {"60"}}, &count, &bodies)
+	defer ts.Close()
+	s := common.Sender{Retry: common.NewRetryPolicy()}
+	s.Retry.MaxBackoff = time.Minute
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	assert.NoError(t, err)
+	_, err = s.Do(req)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.EqualValues(t, 1, count)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	count, bodies := 0, []string{}
+	ts := newFlaky(3, http.StatusServiceUnavailable, nil, &count, &bodies)
+	defer ts.Close()
+	b := common.NewCircuitBreaker(2, 50*time.Millisecond)
+	s1 := common.Sender{Breaker: b}
+	s2 := common.Sender{Breaker: b} // shared by another accessor.
+
+	get := func(s common.Sender) (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodGet, ts.URL, nil)
+		assert.NoError(t, err)
+		return s.Do(req)
+	}
+	_, err := get(s1)
+	assert.NoError(t, err)
+	assert.False(t, b.IsOpen())
+	_, err = get(s2)
+	assert.NoError(t, err)
+	assert.True(t, b.IsOpen())
+
+	// fails fast while open.
+	_, err = get(s1)
+	assert.True(t, errors.Is(err, common.CircuitOpenError))
+	assert.EqualValues(t, 2, count)
+
+	// trial after cooldown fails, then opens again.
+	time.Sleep(60 * time.Millisecond)
+	_, err = get(s2)
+	assert.NoError(t, err)
+	assert.True(t, b.IsOpen())
+	assert.EqualValues(t, 3, count)
+
+	// trial succeeds and closes.
+	time.Sleep(60 * time.Millisecond)
+	res, err := get(s1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.False(t, b.IsOpen())
+}
+
+func TestCircuitBreaker_CancelledTrial(t *testing.T) {
+	count, bodies := 0, []string{}
+	ts := newFlaky(10, http.StatusServiceUnavailable, nil, &count, &bodies)
+	defer ts.Close()
+	b := common.NewCircuitBreaker(2, 20*time.Millisecond)
+	s := common.Sender{Breaker: b}
+
+	get := func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+		assert.NoError(t, err)
+		_, err = s.Do(req)
+		return err
+	}
+	assert.NoError(t, get(context.Background()))
+	assert.NoError(t, get(context.Background()))
+	assert.True(t, b.IsOpen())
+
+	// cancelled trial does not close the breaker.
+	time.Sleep(30 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, get(ctx))
+	assert.EqualValues(t, 2, count)
+
+	// neither does a trial whose credentials could not be applied.
+	s.Credentials = brokenCredentials{}
+	assert.Error(t, get(context.Background()))
+	assert.EqualValues(t, 2, count)
+	s.Credentials = nil
+
+	// next request is the trial again, and its failure opens the breaker at once.
+	assert.NoError(t, get(context.Background()))
+	assert.True(t, b.IsOpen())
+	assert.EqualValues(t, 3, count)
+}
+
+// brokenCredentials fails to apply tokens without accessing the server.
+type brokenCredentials struct{}
+
+func (brokenCredentials) Apply(ctx context.Context, req *http.Request) error {
+	return errors.New("keyrock is unreachable")
+}
+
+func (brokenCredentials) Refresh(ctx context.Context) error {
+	return errors.New("keyrock is unreachable")
+}
@@ -0,0 +1,231 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Sender sends requests to fiware components with the options of an accessor.
+type Sender struct {
+	Client      *http.Client
+	Credentials Credentials
+	Retry       *RetryPolicy
+	Breaker     *CircuitBreaker
+	Middlewares []Middleware
+	Logger      Logger
+}
+
+// Do sends given request. Credentials are applied, and the request is replayed once with refreshed credentials
+// when the server responds 401. Transient failures are retried by the retry policy and recorded to the circuit breaker.
+// Middlewares see the call before the first attempt and after the final response.
+// fiware-correlator, traceparent and tracestate held by the context of the request are propagated.
+// Returns CircuitOpenError while the breaker is open, context error when the request was canceled or expired.
+func (s Sender) Do(req *http.Request) (*http.Response, error) {
+	InjectTraceHeaders(req.Context(), req.Header)
+	if len(s.Middlewares) <= 0 {
+		start := time.Now()
+		res, err := s.retry(req)
+		s.logResult(req, res, err, time.Since(start))
+		recordResponse(req.Context(), res)
+		return res, err
+	}
+
+	body, err := RequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	ex := &Exchange{Request: req, Body: body}
+	if err := before(s.Middlewares, ex); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	res, err := s.retry(req)
+	ex.Latency = time.Since(start)
+	ex.Response, ex.Err = res, err
+	s.logResult(req, res, err, ex.Latency)
+	if res != nil {
+		// buffer the response body so that both middlewares and the caller can read it.
+		b, rerr := ioutil.ReadAll(res.Body)
+		_ = res.Body.Close()
+		res.Body = ioutil.NopCloser(bytes.NewReader(b))
+		ex.ResponseBody = b
+		if rerr != nil {
+			res, err = nil, ContextError(req.Context(), rerr)
+			ex.Response, ex.Err = res, err
+		}
+	}
+	after(s.Middlewares, ex)
+	recordResponse(req.Context(), res)
+	return res, err
+}
+
+// retry sends given request with retries.
+func (s Sender) retry(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	attempts := 1
+	if s.Retry != nil && s.Retry.Retryable(req.Method) && 1 < s.Retry.MaxAttempts {
+		attempts = s.Retry.MaxAttempts
+	}
+
+	r := req
+	for attempt := 1; ; attempt++ {
+		if s.Breaker != nil {
+			if err := s.Breaker.Allow(); err != nil {
+				s.log(ctx, LogLevelWarn, "circuit breaker is open", "method", req.Method, "url", req.URL)
+				return nil, err
+			}
+		}
+		res, err := s.authorized(r)
+		transient := isTransient(ctx, res, err)
+		if s.Breaker != nil {
+			switch {
+			case transient:
+				s.Breaker.Failure()
+			case err != nil:
+				s.Breaker.Cancel() // no response, e.g. canceled or credentials could not be applied.
+			default:
+				s.Breaker.Success()
+			}
+		}
+		if !transient || attempts <= attempt {
+			return res, err
+		}
+
+		// wait and retry.
+		next, rerr := rewind(req)
+		if rerr != nil {
+			return res, err // cannot replay the body.
+		}
+		wait := s.Retry.Backoff(attempt + 1)
+		if res != nil {
+			if ra, ok := RetryAfter(res); ok {
+				wait = ra
+				if 0 < s.Retry.MaxBackoff && s.Retry.MaxBackoff < wait {
+					wait = s.Retry.MaxBackoff
+				}
+			}
+			_ = res.Body.Close()
+		}
+		s.log(ctx, LogLevelWarn, "retrying fiware request", "method", req.Method, "url", req.URL, "attempt", attempt, "wait", wait, "status", statusOf(res), "error", err)
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+		r = next
+	}
+}
+
+// isTransient returns whether given result is a transient failure. Context errors are not.
+func isTransient(ctx context.Context, res *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, CircuitOpenError) && !isCredentialsError(err)
+	}
+	return IsTransientStatus(res.StatusCode)
+}
+
+// authorized sends given request, and replays it once with refreshed credentials when the server responds 401.
+func (s Sender) authorized(req *http.Request) (*http.Response, error) {
+	res, err := s.send(req)
+	if err != nil || res.StatusCode != http.StatusUnauthorized || s.Credentials == nil {
+		return res, err
+	}
+
+	// refresh and replay once.
+	replay, err := rewind(req)
+	if err != nil {
+		return res, nil // cannot replay. return the 401 response as it is.
+	}
+	s.log(req.Context(), LogLevelInfo, "refreshing credentials", "method", req.Method, "url", req.URL)
+	if err := s.Credentials.Refresh(req.Context()); err != nil {
+		s.log(req.Context(), LogLevelWarn, "failed to refresh credentials", "error", err)
+		return res, nil
+	}
+	_ = res.Body.Close()
+	return s.send(replay)
+}
+
+// send applies credentials and sends the request.
+func (s Sender) send(req *http.Request) (*http.Response, error) {
+	if s.Credentials != nil {
+		if err := s.Credentials.Apply(req.Context(), req); err != nil {
+			return nil, &credentialsError{err: err}
+		}
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, ContextError(req.Context(), err)
+	}
+	return res, nil
+}
+
+// rewind returns a copy of given request whose body can be read again.
+func rewind(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return r, nil
+	}
+	if req.GetBody == nil {
+		return nil, http.ErrBodyNotAllowed
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = body
+	return r, nil
+}
+
+// credentialsError is an error that credentials could not be applied. It is not retried.
+type credentialsError struct {
+	err error
+}
+
+func (e *credentialsError) Error() string {
+	return e.err.Error()
+}
+
+func (e *credentialsError) Unwrap() error {
+	return e.err
+}
+
+// isCredentialsError returns whether given error is returned by credentials.
+func isCredentialsError(err error) bool {
+	var ce *credentialsError
+	return errors.As(err, &ce)
+}
+
+// log writes a redacted record when the logger is set.
+func (s Sender) log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	if s.Logger != nil {
+		s.Logger.Log(ctx, level, msg, Redact(keyvals...)...)
+	}
+}
+
+// logResult writes the result of a call. Payloads are not logged.
+func (s Sender) logResult(req *http.Request, res *http.Response, err error, latency time.Duration) {
+	if err != nil {
+		s.log(req.Context(), LogLevelWarn, "fiware request failed", "method", req.Method, "url", req.URL, "latency", latency, "error", err)
+		return
+	}
+	s.log(req.Context(), LogLevelDebug, "fiware request", "method", req.Method, "url", req.URL, "status", res.StatusCode, "latency", latency,
+		"correlator", res.Header.Get(CorrelatorHeader))
+}
+
+// statusOf returns status code of given response, 0 when it is nil.
+func statusOf(res *http.Response) int {
+	if res == nil {
+		return 0
+	}
+	return res.StatusCode
+}
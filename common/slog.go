@@ -0,0 +1,43 @@
+//go:build go1.21
+// +build go1.21
+
+package common
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogLogger writes records to log/slog logger.
+type SlogLogger struct {
+	Logger *slog.Logger
+}
+
+// NewSlogLogger returns SlogLogger instance. Uses slog.Default() when l is nil.
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &SlogLogger{Logger: l}
+}
+
+// Log writes a record.
+func (s *SlogLogger) Log(ctx context.Context, level LogLevel, msg string, keyvals ...interface{}) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.Logger.Log(ctx, slogLevel(level), msg, Redact(keyvals...)...)
+}
+
+// slogLevel converts given level to slog level.
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LogLevelDebug:
+		return slog.LevelDebug
+	case LogLevelInfo:
+		return slog.LevelInfo
+	case LogLevelWarn:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
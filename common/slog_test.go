@@ -0,0 +1,29 @@
+//go:build go1.21
+// +build go1.21
+
+package common_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := common.NewSlogLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	l.Log(context.Background(), common.LogLevelDebug, "hidden")
+	l.Log(context.Background(), common.LogLevelError, "failed", "status", 503, "apikey", "abc")
+
+	var r map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &r))
+	assert.EqualValues(t, "ERROR", r["level"])
+	assert.EqualValues(t, "failed", r["msg"])
+	assert.EqualValues(t, 503, r["status"])
+	assert.EqualValues(t, common.Redacted, r["apikey"])
+}
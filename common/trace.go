@@ -0,0 +1,148 @@
+// correlation and W3C trace context propagation
+// https://www.w3.org/TR/trace-context/
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+)
+
+const (
+	CorrelatorHeader  = "Fiware-Correlator"
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+)
+
+const (
+	traceParentKey contextKey = iota + 100
+	traceStateKey
+	responseInfoKey
+)
+
+var (
+	traceParentRegexp = regexp.MustCompile(`^[0-9a-f]{2}-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2}$`)
+)
+
+// WithCorrelator returns context that holds given fiware-correlator to propagate.
+func WithCorrelator(ctx context.Context, correlator string) context.Context {
+	return context.WithValue(ctx, correlatorKey, correlator)
+}
+
+// WithTraceContext returns context that holds given traceparent and tracestate to propagate.
+func WithTraceContext(ctx context.Context, traceParent, traceState string) context.Context {
+	ctx = context.WithValue(ctx, traceParentKey, traceParent)
+	return context.WithValue(ctx, traceStateKey, traceState)
+}
+
+// TraceParentFromContext returns traceparent held by given context.
+func TraceParentFromContext(ctx context.Context) string {
+	return stringValue(ctx, traceParentKey)
+}
+
+// TraceStateFromContext returns tracestate held by given context.
+func TraceStateFromContext(ctx context.Context) string {
+	return stringValue(ctx, traceStateKey)
+}
+
+// IsValidTraceParent returns whether given traceparent is valid. All zero trace-id and parent-id are invalid.
+func IsValidTraceParent(tp string) bool {
+	if !traceParentRegexp.MatchString(tp) || tp[:2] == "ff" {
+		return false
+	}
+	return tp[3:35] != "00000000000000000000000000000000" && tp[36:52] != "0000000000000000"
+}
+
+// InjectTraceHeaders sets fiware-correlator, traceparent and tracestate held by given context to given header.
+// Headers that have been set already are kept. Invalid traceparent is not propagated.
+func InjectTraceHeaders(ctx context.Context, h http.Header) {
+	if c := CorrelatorFromContext(ctx); 0 < len(c) && len(h.Get(CorrelatorHeader)) <= 0 {
+		h.Set(CorrelatorHeader, c)
+	}
+	if len(h.Get(TraceParentHeader)) <= 0 {
+		if tp := TraceParentFromContext(ctx); IsValidTraceParent(tp) {
+			h.Set(TraceParentHeader, tp)
+			if ts := TraceStateFromContext(ctx); 0 < len(ts) {
+				h.Set(TraceStateHeader, ts)
+			}
+		}
+	}
+}
+
+// ContextFromHeader returns context that holds fiware-service, fiware-servicepath, fiware-correlator, traceparent and
+// tracestate of a request received from fiware components. Invalid traceparent is dropped with its tracestate.
+func ContextFromHeader(ctx context.Context, h http.Header) context.Context {
+	ctx = WithFiwareHeaders(ctx, h.Get(ServiceHeader), h.Get(ServicePathHeader), h.Get(CorrelatorHeader))
+	if tp := h.Get(TraceParentHeader); IsValidTraceParent(tp) {
+		ctx = WithTraceContext(ctx, tp, h.Get(TraceStateHeader))
+	}
+	return ctx
+}
+
+// ResponseInfo holds correlation of the response to a call.
+type ResponseInfo struct {
+	StatusCode int
+	Correlator string // fiware-correlator the server returned.
+}
+
+// WithResponseInfo returns context that makes accessors record the response of the call to given info.
+func WithResponseInfo(ctx context.Context, info *ResponseInfo) context.Context {
+	return context.WithValue(ctx, responseInfoKey, info)
+}
+
+// recordResponse records given response to ResponseInfo held by given context if any.
+func recordResponse(ctx context.Context, res *http.Response) {
+	info, ok := ctx.Value(responseInfoKey).(*ResponseInfo)
+	if !ok || info == nil || res == nil {
+		return
+	}
+	info.StatusCode = res.StatusCode
+	info.Correlator = res.Header.Get(CorrelatorHeader)
+}
+
+// Correlated is implemented by errors that hold fiware-correlator of the response.
+type Correlated interface {
+	FiwareCorrelator() string
+}
+
+// CorrelatorOf returns fiware-correlator held by given error, empty when it does not have.
+func CorrelatorOf(err error) string {
+	var c Correlated
+	if errors.As(err, &c) {
+		return c.FiwareCorrelator()
+	}
+	return ""
+}
+
+// CorrelatedError is an error with fiware-correlator of the response. Error string is the same as the wrapped one.
+type CorrelatedError struct {
+	Correlator string
+	Err        error
+}
+
+// NewCorrelatedError wraps given error with fiware-correlator of given response. Returns the error as it is
+// when the response has no correlator.
+func NewCorrelatedError(res *http.Response, err error) error {
+	if err == nil || res == nil {
+		return err
+	}
+	c := res.Header.Get(CorrelatorHeader)
+	if len(c) <= 0 {
+		return err
+	}
+	return &CorrelatedError{Correlator: c, Err: err}
+}
+
+func (e *CorrelatedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CorrelatedError) Unwrap() error {
+	return e.Err
+}
+
+// FiwareCorrelator returns fiware-correlator of the response.
+func (e *CorrelatedError) FiwareCorrelator() string {
+	return e.Correlator
+}
@@ -0,0 +1,88 @@
+package common_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestIsValidTraceParent(t *testing.T) {
+	assert.True(t, common.IsValidTraceParent(traceParent))
+	for _, tp := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+	} {
+		assert.False(t, common.IsValidTraceParent(tp), tp)
+	}
+}
+
+func TestContextFromHeader(t *testing.T) {
+	h := http.Header{}
+	h.Set(common.ServiceHeader, "openiot")
+	h.Set(common.ServicePathHeader, "/a")
+	h.Set(common.CorrelatorHeader, "c1; cbnotif=1")
+	h.Set(common.TraceParentHeader, traceParent)
+	h.Set(common.TraceStateHeader, "vendor=x")
+	ctx := common.ContextFromHeader(context.Background(), h)
+	assert.EqualValues(t, "openiot", common.ServiceFromContext(ctx))
+	assert.EqualValues(t, "/a", common.ServicePathFromContext(ctx))
+	assert.EqualValues(t, "c1; cbnotif=1", common.CorrelatorFromContext(ctx))
+	assert.EqualValues(t, traceParent, common.TraceParentFromContext(ctx))
+	assert.EqualValues(t, "vendor=x", common.TraceStateFromContext(ctx))
+
+	h.Set(common.TraceParentHeader, "broken")
+	ctx = common.ContextFromHeader(context.Background(), h)
+	assert.EqualValues(t, "", common.TraceParentFromContext(ctx))
+	assert.EqualValues(t, "", common.TraceStateFromContext(ctx))
+}
+
+func TestSender_Trace(t *testing.T) {
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		c := r.Header.Get(common.CorrelatorHeader)
+		if len(c) <= 0 {
+			c = "generated"
+		}
+		w.Header().Set(common.CorrelatorHeader, c)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	var info common.ResponseInfo
+	ctx := common.WithCorrelator(context.Background(), "c1")
+	ctx = common.WithTraceContext(ctx, traceParent, "vendor=x")
+	ctx = common.WithResponseInfo(ctx, &info)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+	assert.NoError(t, err)
+	_, err = common.Sender{}.Do(req)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "c1", received.Get(common.CorrelatorHeader))
+	assert.EqualValues(t, traceParent, received.Get(common.TraceParentHeader))
+	assert.EqualValues(t, "vendor=x", received.Get(common.TraceStateHeader))
+	assert.EqualValues(t, common.ResponseInfo{StatusCode: http.StatusNotFound, Correlator: "c1"}, info)
+
+	// correlator issued by the server.
+	req, err = http.NewRequestWithContext(common.WithResponseInfo(context.Background(), &info), http.MethodGet, ts.URL, nil)
+	assert.NoError(t, err)
+	res, err := common.Sender{}.Do(req)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "", received.Get(common.TraceParentHeader))
+	assert.EqualValues(t, "generated", info.Correlator)
+
+	err = common.NewCorrelatedError(res, fmt.Errorf(res.Status))
+	assert.EqualError(t, err, "404 Not Found")
+	assert.EqualValues(t, "generated", common.CorrelatorOf(fmt.Errorf("wrapped: %w", err)))
+	assert.EqualValues(t, "", common.CorrelatorOf(fmt.Errorf("other")))
+}
@@ -4,8 +4,6 @@ go 1.14
 
 require (
 	github.com/marrbor/gohttp v0.0.6
-	github.com/marrbor/golog v0.0.3
 	github.com/marrbor/goutil v0.0.6
-	github.com/mattn/go-pipeline v0.0.0-20190323144519-32d779b32768
 	github.com/stretchr/testify v1.4.0
 )
@@ -1,10 +1,12 @@
 package iotagent
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strconv"
 
+	"github.com/marrbor/go-fiware-api/common"
 	"github.com/marrbor/gohttp"
 )
 
@@ -43,7 +45,12 @@ func (av *APIAbout) toAbout() (*About, error) {
 
 // ReadAbout gets IoT Agent information. It can be used as a heartbeat operation to check the health of the IoT Agent if required.
 func (a *Accessor) ReadAbout() (*About, error) {
-	req, err := http.NewRequest(http.MethodGet, a.genConfigUrl(AboutUrl), nil)
+	return a.ReadAboutCtx(context.Background())
+}
+
+// ReadAboutCtx gets IoT Agent information with given context.
+func (a *Accessor) ReadAboutCtx(ctx context.Context) (*About, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.genConfigUrl(AboutUrl), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +60,7 @@ func (a *Accessor) ReadAbout() (*About, error) {
 	}
 
 	if !gohttp.IsSuccessful(res) {
-		return nil, fmt.Errorf(res.Status)
+		return nil, common.NewCorrelatedError(res, fmt.Errorf(res.Status))
 	}
 
 	var av APIAbout
@@ -2,8 +2,11 @@
 package iotagent
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+
+	"github.com/marrbor/go-fiware-api/common"
 )
 
 // Accessor holds http client instance, base url of iot agent.
@@ -11,6 +14,12 @@ type Accessor struct {
 	client    *http.Client
 	configUrl string
 	reportUrl string
+
+	Credentials common.Credentials     // attached to every request when specified.
+	Retry       *common.RetryPolicy    // retries transient failures when specified.
+	Breaker     *common.CircuitBreaker // can be shared among accessors.
+	Middlewares []common.Middleware    // see every outgoing call.
+	Logger      common.Logger          // no-op when not specified.
 }
 
 // genConfigUrl returns configUrl + givenUrl.
@@ -23,9 +32,17 @@ func (a *Accessor) genReportUrl(url string) string {
 	return fmt.Sprintf("%s/%s", a.reportUrl, url)
 }
 
-// Crud do api access.
+// Crud do api access. Returns context error when the request was canceled or expired.
+// The request is replayed once with refreshed credentials when it is rejected with 401, and retried on transient failures.
 func (a *Accessor) Crud(req *http.Request) (*http.Response, error) {
-	return a.client.Do(req)
+	return common.Sender{Client: a.client, Credentials: a.Credentials, Retry: a.Retry, Breaker: a.Breaker, Middlewares: a.Middlewares, Logger: a.Logger}.Do(req)
+}
+
+// log writes given message to the logger of the accessor when specified. Values are passed through common.Redact.
+func (a *Accessor) log(ctx context.Context, level common.LogLevel, msg string, keyvals ...interface{}) {
+	if a.Logger != nil {
+		a.Logger.Log(ctx, level, msg, common.Redact(keyvals...)...)
+	}
 }
 
 func NewAccessor(configUrl, reportUrl string) *Accessor {
@@ -4,10 +4,12 @@
 package iotagent
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
 
+	"github.com/marrbor/go-fiware-api/common"
 	"github.com/marrbor/gohttp"
 )
 
@@ -60,12 +62,17 @@ type (
 
 // https://github.com/telefonicaid/iotagent-node-lib/blob/master/doc/api.md#post-iotdevices
 func (a *Accessor) CreateDevice(service, path string, devices PostDevices) error {
+	return a.CreateDeviceCtx(context.Background(), service, path, devices)
+}
+
+// CreateDeviceCtx is CreateDevice with given context.
+func (a *Accessor) CreateDeviceCtx(ctx context.Context, service, path string, devices PostDevices) error {
 	// set service and service path into request.
 	for i := range devices.Devices {
 		devices.Devices[i].Service = &service
 		devices.Devices[i].ServicePath = &path
 	}
-	req, err := gohttp.GenRequest(gohttp.HttpMethods.POST, a.genConfigUrl(DevicesUrl), devices)
+	req, err := common.GenRequest(ctx, gohttp.HttpMethods.POST, a.genConfigUrl(DevicesUrl), devices)
 	if err != nil {
 		return err
 	}
@@ -74,14 +81,19 @@ func (a *Accessor) CreateDevice(service, path string, devices PostDevices) error
 		return err
 	}
 	if !gohttp.IsSuccessful(res) {
-		return gohttp.ResponseToError(res, nil, nil)
+		return common.NewCorrelatedError(res, gohttp.ResponseToError(res, nil, nil))
 	}
 	return nil
 }
 
 // https://github.com/telefonicaid/iotagent-node-lib/blob/master/doc/api.md#get-iotdevices
 func (a *Accessor) ReadDevices(service, path string, limit, offset *int) (*GetDevices, error) {
-	req, err := http.NewRequest(http.MethodGet, a.genConfigUrl(DevicesUrl), nil)
+	return a.ReadDevicesCtx(context.Background(), service, path, limit, offset)
+}
+
+// ReadDevicesCtx is ReadDevices with given context.
+func (a *Accessor) ReadDevicesCtx(ctx context.Context, service, path string, limit, offset *int) (*GetDevices, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.genConfigUrl(DevicesUrl), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -97,7 +109,7 @@ func (a *Accessor) ReadDevices(service, path string, limit, offset *int) (*GetDe
 		return nil, err
 	}
 	if !gohttp.IsSuccessful(res) {
-		return nil, gohttp.ResponseToError(res, nil, nil)
+		return nil, common.NewCorrelatedError(res, gohttp.ResponseToError(res, nil, nil))
 	}
 	var gd GetDevices
 	if err := gohttp.ResponseJSONToParams(res, &gd); err != nil {
@@ -108,7 +120,12 @@ func (a *Accessor) ReadDevices(service, path string, limit, offset *int) (*GetDe
 
 // https://github.com/telefonicaid/iotagent-node-lib/blob/master/doc/api.md#get-iotdevicesdeviceid
 func (a *Accessor) ReadDevice(service, path, id string) (*Device, error) {
-	req, err := http.NewRequest(http.MethodGet, a.genConfigUrl(DevicesUrl+"/"+id), nil)
+	return a.ReadDeviceCtx(context.Background(), service, path, id)
+}
+
+// ReadDeviceCtx is ReadDevice with given context.
+func (a *Accessor) ReadDeviceCtx(ctx context.Context, service, path, id string) (*Device, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.genConfigUrl(DevicesUrl+"/"+id), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +140,7 @@ func (a *Accessor) ReadDevice(service, path, id string) (*Device, error) {
 	}
 
 	if !gohttp.IsSuccessful(res) {
-		return nil, gohttp.ResponseToError(res, nil, nil)
+		return nil, common.NewCorrelatedError(res, gohttp.ResponseToError(res, nil, nil))
 	}
 
 	var dev Device
@@ -135,7 +152,12 @@ func (a *Accessor) ReadDevice(service, path, id string) (*Device, error) {
 
 // https://github.com/telefonicaid/iotagent-node-lib/blob/master/doc/api.md#put-iotdevicesdeviceid
 func (a *Accessor) UpdateDevice(service, path, id, json string) error {
-	req, err := http.NewRequest(http.MethodPut, a.genConfigUrl(DevicesUrl+"/"+id), strings.NewReader(json))
+	return a.UpdateDeviceCtx(context.Background(), service, path, id, json)
+}
+
+// UpdateDeviceCtx is UpdateDevice with given context.
+func (a *Accessor) UpdateDeviceCtx(ctx context.Context, service, path, id, json string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, a.genConfigUrl(DevicesUrl+"/"+id), strings.NewReader(json))
 	if err != nil {
 		return err
 	}
@@ -146,7 +168,7 @@ func (a *Accessor) UpdateDevice(service, path, id, json string) error {
 	}
 
 	if !gohttp.IsSuccessful(res) {
-		return gohttp.ResponseToError(res, nil, nil)
+		return common.NewCorrelatedError(res, gohttp.ResponseToError(res, nil, nil))
 	}
 	return nil
 
@@ -154,7 +176,12 @@ func (a *Accessor) UpdateDevice(service, path, id, json string) error {
 
 // https://github.com/telefonicaid/iotagent-node-lib/blob/master/doc/api.md#delete-iotdevicesdeviceid
 func (a *Accessor) DeleteDevice(service, path, id string) error {
-	req, err := http.NewRequest(http.MethodDelete, a.genConfigUrl(DevicesUrl+"/"+id), nil)
+	return a.DeleteDeviceCtx(context.Background(), service, path, id)
+}
+
+// DeleteDeviceCtx is DeleteDevice with given context.
+func (a *Accessor) DeleteDeviceCtx(ctx context.Context, service, path, id string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, a.genConfigUrl(DevicesUrl+"/"+id), nil)
 	if err != nil {
 		return err
 	}
@@ -164,7 +191,7 @@ func (a *Accessor) DeleteDevice(service, path, id string) error {
 	}
 
 	if !gohttp.IsSuccessful(res) {
-		return gohttp.ResponseToError(res, nil, nil)
+		return common.NewCorrelatedError(res, gohttp.ResponseToError(res, nil, nil))
 	}
 	return nil
 }
@@ -1,10 +1,18 @@
 package iotagent
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/marrbor/go-fiware-api/common"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -89,18 +97,166 @@ func TestDevice(t *testing.T) {
 	t.Log(string(j))
 
 	da := []DeviceAttribute{
-		CpuUsage,
-		DiskUsage,
-		MemoryUsage,
-		LoadAverage1,
-		LoadAverage5,
-		LoadAverage15,
-		Processes,
-		Uptime,
+		{Name: "cpuUsage", Type: TypePercentage},
+		{Name: "diskUsage", Type: TypePercentage},
+		{Name: "memoryUsage", Type: TypePercentage},
+		{Name: "loadAverage1", Type: TypeFloat},
+		{Name: "loadAverage5", Type: TypeFloat},
+		{Name: "loadAverage15", Type: TypeFloat},
+		{Name: "processes", Type: TypeInteger},
+		{Name: "uptime", Type: TypeText},
 	}
 
-	d.Attributes = da
+	d.Attributes = &da
 	j, err = json.MarshalIndent(d, "", " ")
 	assert.NoError(t, err)
 	t.Log(string(j))
 }
+
+func TestAccessor_Cancel(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+	a := NewAccessor(ts.URL, ts.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := a.ReadAboutCtx(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	err = a.SendJsonReportCtx(ctx, "s", "/p", "k", "i", map[string]int{"t": 1})
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestAccessor_Tenant(t *testing.T) {
+	var service, servicePath string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		service, servicePath = r.Header.Get("Fiware-Service"), r.Header.Get("Fiware-ServicePath")
+		_, _ = w.Write([]byte(`{"count":0,"devices":[]}`))
+	}))
+	defer ts.Close()
+	a := NewAccessor(ts.URL, ts.URL)
+
+	_, err := a.Tenant("Open IoT", "/")
+	assert.EqualError(t, err, common.InvalidServiceName.Error())
+	_, err = a.Tenant("openiot", "/a/#")
+	assert.EqualError(t, err, common.InvalidServicePath.Error())
+	_, err = a.Tenant("", "/")
+	assert.EqualError(t, err, common.InvalidServiceName.Error())
+	_, err = a.Tenant("openiot", "")
+	assert.EqualError(t, err, common.InvalidServicePath.Error())
+
+	tn, err := a.Tenant("openiot", "/floor1")
+	assert.NoError(t, err)
+	_, err = tn.ReadDevices(nil, nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "openiot", service)
+	assert.EqualValues(t, "/floor1", servicePath)
+}
+
+func TestAccessor_Credentials(t *testing.T) {
+	var token string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get(common.AuthTokenHeader)
+		_, _ = w.Write([]byte(`{"libVersion":"2.12.0","port":"4041","baseRoot":"/","version":"1.14.0"}`))
+	}))
+	defer ts.Close()
+	a := NewAccessor(ts.URL, ts.URL)
+	a.Credentials = common.NewAuthToken("secret")
+
+	_, err := a.ReadAbout()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "secret", token)
+}
+
+func TestAccessor_Middlewares(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"libVersion":"2.12.0","port":"4041","baseRoot":"/","version":"1.14.0"}`))
+	}))
+	defer ts.Close()
+	var seen []*common.Exchange
+	a := NewAccessor(ts.URL, ts.URL)
+	a.Middlewares = []common.Middleware{common.MiddlewareFuncs{AfterFunc: func(ex *common.Exchange) {
+		seen = append(seen, ex)
+	}}}
+
+	_, err := a.ReadAbout()
+	assert.NoError(t, err)
+	assert.Len(t, seen, 1)
+	assert.EqualValues(t, http.MethodGet, seen[0].Request.Method)
+	assert.EqualValues(t, http.StatusOK, seen[0].Status())
+}
+
+func TestAccessor_Trace(t *testing.T) {
+	var received http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		w.Header().Set(common.CorrelatorHeader, r.Header.Get(common.CorrelatorHeader))
+		if r.URL.Query().Get("k") == "bad" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	a := NewAccessor(ts.URL, ts.URL)
+
+	var info common.ResponseInfo
+	ctx := common.WithCorrelator(context.Background(), "measure-1")
+	ctx = common.WithTraceContext(ctx, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "")
+	err := a.SendJsonReportCtx(common.WithResponseInfo(ctx, &info), "s", "/p", "k", "i", map[string]int{"t": 1})
+	assert.NoError(t, err)
+	assert.EqualValues(t, "measure-1", received.Get(common.CorrelatorHeader))
+	assert.EqualValues(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", received.Get(common.TraceParentHeader))
+	assert.EqualValues(t, "measure-1", info.Correlator)
+
+	err = a.SendJsonReportCtx(ctx, "s", "/p", "bad", "i", map[string]int{"t": 1})
+	assert.Error(t, err)
+	assert.EqualValues(t, "measure-1", common.CorrelatorOf(err))
+}
+
+func TestAccessor_Logger(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	var buf bytes.Buffer
+	a := NewAccessor(ts.URL, ts.URL)
+	a.Logger = common.NewStdLogger(log.New(&buf, "", 0), common.LogLevelDebug)
+
+	assert.NoError(t, a.SendJsonReport("s", "/p", "apikey1", "dev1", map[string]int{"temperature": 25}))
+	assert.Contains(t, buf.String(), "send report")
+	assert.Contains(t, buf.String(), `device="dev1"`)
+	assert.NotContains(t, buf.String(), "apikey1")
+	assert.NotContains(t, buf.String(), "temperature")
+}
+
+// capture is a Logger that keeps values as they are given.
+type capture struct {
+	lines []string
+}
+
+func (c *capture) Log(ctx context.Context, level common.LogLevel, msg string, keyvals ...interface{}) {
+	c.lines = append(c.lines, fmt.Sprint(append([]interface{}{msg}, keyvals...)...))
+}
+
+func TestAccessor_LoggerRedacts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+	var c capture
+	a := NewAccessor(ts.URL, ts.URL)
+	a.Logger = &c
+
+	assert.NoError(t, a.SendJsonReport("s", "/p", "apikey1", "dev1", map[string]int{"temperature": 25}))
+	assert.NoError(t, a.SendJsonTextReport("s", "/p", "apikey2", "dev1", `{"temperature":25}`))
+	assert.NotEmpty(t, c.lines)
+	for _, l := range c.lines {
+		assert.NotContains(t, l, "apikey1")
+		assert.NotContains(t, l, "apikey2")
+	}
+}
@@ -0,0 +1,288 @@
+package iotagenttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/marrbor/go-fiware-api/iotagent"
+)
+
+const (
+	// DefaultEntityType is a type of the entity of devices provisioned without type.
+	DefaultEntityType = "Thing"
+)
+
+type (
+	// groupList is a response of GET /iot/services.
+	groupList struct {
+		Count    int                      `json:"count"`
+		Services []*iotagent.ServiceGroup `json:"services"`
+	}
+
+	// deviceList is a response of GET /iot/devices.
+	deviceList struct {
+		Count   int                `json:"count"`
+		Devices []*iotagent.Device `json:"devices"`
+	}
+)
+
+func str(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+// page returns range of the list specified by limit and offset parameters.
+func page(q url.Values, n int) (int, int, error) {
+	limit, offset := DefaultLimit, 0
+	var err error
+	if s := q.Get("limit"); 0 < len(s) {
+		if limit, err = strconv.Atoi(s); err != nil || limit < 0 {
+			return 0, 0, fmt.Errorf("invalid limit %s", s)
+		}
+	}
+	if s := q.Get("offset"); 0 < len(s) {
+		if offset, err = strconv.Atoi(s); err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("invalid offset %s", s)
+		}
+	}
+	if n < offset {
+		offset = n
+	}
+	end := offset + limit
+	if n < end {
+		end = n
+	}
+	return offset, end, nil
+}
+
+// readJSON decodes the request body into v.
+func readJSON(r *request, v interface{}) error {
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// findGroup returns index of the group that has given resource and apikey. Service is not checked when it is empty.
+func (a *Agent) findGroup(service, servicePath, resource, apikey string) int {
+	for i, g := range a.groups {
+		if g.Resource != resource || g.APIKey != apikey {
+			continue
+		}
+		if 0 < len(service) && (str(g.Service) != service || str(g.SubService) != servicePath) {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// serveGroups serves /iot/services.
+func (a *Agent) serveGroups(w http.ResponseWriter, r *request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPost:
+		if !r.requireHeaders(w) {
+			return
+		}
+		var body iotagent.APIServiceGroup
+		if err := readJSON(r, &body); err != nil {
+			wrongSyntax(w, err)
+			return
+		}
+		for _, g := range body.Services {
+			if len(g.Resource) <= 0 || len(g.APIKey) <= 0 {
+				wrongSyntax(w, fmt.Errorf("resource and apikey are required"))
+				return
+			}
+			if 0 <= a.findGroup("", "", g.Resource, g.APIKey) {
+				writeError(w, http.StatusConflict, ErrorDuplicateGroup, fmt.Sprintf("A device configuration already exists for resource %s and API Key %s", g.Resource, g.APIKey))
+				return
+			}
+		}
+		for i := range body.Services {
+			g := body.Services[i]
+			service, servicePath := r.service, r.servicePath
+			g.Service, g.SubService = &service, &servicePath
+			a.groups = append(a.groups, &g)
+		}
+		w.WriteHeader(http.StatusCreated)
+
+	case http.MethodGet:
+		if !r.requireHeaders(w) {
+			return
+		}
+		var list []*iotagent.ServiceGroup
+		for _, g := range a.groups {
+			if str(g.Service) == r.service && str(g.SubService) == r.servicePath {
+				list = append(list, g)
+			}
+		}
+		from, to, err := page(r.URL.Query(), len(list))
+		if err != nil {
+			wrongSyntax(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, groupList{Count: len(list), Services: append([]*iotagent.ServiceGroup{}, list[from:to]...)})
+
+	case http.MethodPut, http.MethodDelete:
+		q := r.URL.Query()
+		i := a.findGroup(r.service, r.servicePath, q.Get("resource"), q.Get("apikey"))
+		if i < 0 {
+			writeError(w, http.StatusNotFound, ErrorDeviceGroupNotFound, fmt.Sprintf(`Couldn't find device group for fields: ["resource","apikey"] and values: {"resource":%q,"apikey":%q}`, q.Get("resource"), q.Get("apikey")))
+			return
+		}
+		if r.Method == http.MethodDelete {
+			a.groups = append(a.groups[:i], a.groups[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		g := *a.groups[i]
+		if err := readJSON(r, &g); err != nil {
+			wrongSyntax(w, err)
+			return
+		}
+		g.Service, g.SubService = a.groups[i].Service, a.groups[i].SubService
+		a.groups[i] = &g
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete)
+	}
+}
+
+// findDevice returns index of the device that has given id in the service.
+func (a *Agent) findDevice(service, servicePath, id string) int {
+	for i, d := range a.devices {
+		if d.DeviceID == id && str(d.Service) == service && str(d.ServicePath) == servicePath {
+			return i
+		}
+	}
+	return -1
+}
+
+// groupOf returns the service group of given service. nil when not found.
+func (a *Agent) groupOf(service, servicePath string) *iotagent.ServiceGroup {
+	for _, g := range a.groups {
+		if str(g.Service) == service && str(g.SubService) == servicePath {
+			return g
+		}
+	}
+	return nil
+}
+
+// provision completes entity type and name of the device and stores it.
+func (a *Agent) provision(d *iotagent.Device, service, servicePath string) {
+	d.Service, d.ServicePath = &service, &servicePath
+	if len(d.EntityType) <= 0 {
+		d.EntityType = DefaultEntityType
+		if g := a.groupOf(service, servicePath); g != nil && 0 < len(g.EntityType) {
+			d.EntityType = g.EntityType
+		}
+	}
+	if len(d.EntityName) <= 0 {
+		d.EntityName = d.EntityType + ":" + d.DeviceID
+	}
+	if len(d.Transport) <= 0 {
+		d.Transport = iotagent.TransportHttp
+	}
+	a.devices = append(a.devices, d)
+}
+
+// serveDevices serves /iot/devices.
+func (a *Agent) serveDevices(w http.ResponseWriter, r *request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		return
+	}
+	if !r.requireHeaders(w) {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if r.Method == http.MethodGet {
+		var list []*iotagent.Device
+		for _, d := range a.devices {
+			if str(d.Service) == r.service && str(d.ServicePath) == r.servicePath {
+				list = append(list, d)
+			}
+		}
+		from, to, err := page(r.URL.Query(), len(list))
+		if err != nil {
+			wrongSyntax(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, deviceList{Count: len(list), Devices: append([]*iotagent.Device{}, list[from:to]...)})
+		return
+	}
+
+	var body iotagent.PostDevices
+	if err := readJSON(r, &body); err != nil {
+		wrongSyntax(w, err)
+		return
+	}
+	for i, d := range body.Devices {
+		if len(d.DeviceID) <= 0 {
+			wrongSyntax(w, fmt.Errorf("device_id is required"))
+			return
+		}
+		dup := 0 <= a.findDevice(r.service, r.servicePath, d.DeviceID)
+		for _, o := range body.Devices[:i] {
+			dup = dup || o.DeviceID == d.DeviceID
+		}
+		if dup {
+			writeError(w, http.StatusConflict, ErrorDuplicateDevice, fmt.Sprintf("A device with the same pair (Service, DeviceId) was found:%s", d.DeviceID))
+			return
+		}
+	}
+	for i := range body.Devices {
+		d := body.Devices[i]
+		a.provision(&d, r.service, r.servicePath)
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// serveDevice serves /iot/devices/{id}.
+func (a *Agent) serveDevice(w http.ResponseWriter, r *request, id string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+		methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+		return
+	}
+	if !r.requireHeaders(w) {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	i := a.findDevice(r.service, r.servicePath, id)
+	if i < 0 {
+		writeError(w, http.StatusNotFound, ErrorDeviceNotFound, "No device was found with id:"+id)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, a.devices[i])
+	case http.MethodPut:
+		d := *a.devices[i]
+		if err := readJSON(r, &d); err != nil {
+			wrongSyntax(w, err)
+			return
+		}
+		d.DeviceID, d.Service, d.ServicePath = a.devices[i].DeviceID, a.devices[i].Service, a.devices[i].ServicePath
+		a.devices[i] = &d
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		a.devices = append(a.devices[:i], a.devices[i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
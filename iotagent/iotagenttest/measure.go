@@ -0,0 +1,196 @@
+package iotagenttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/marrbor/go-fiware-api/iotagent"
+	"github.com/marrbor/go-fiware-api/ngsi"
+	"github.com/marrbor/go-fiware-api/orion"
+)
+
+const (
+	ErrorMandatoryParams = "MANDATORY_PARAMS_NOT_FOUND"
+
+	// TimeInstant is an attribute that holds the time of the measure when timestamp is enabled.
+	TimeInstant = "TimeInstant"
+)
+
+// lookupDevice returns the device that sends measures with given apikey and id, and its group. The device is
+// provisioned when only the group is found. Have to be called with the lock.
+func (a *Agent) lookupDevice(apikey, id string) (*iotagent.Device, *iotagent.ServiceGroup) {
+	var group *iotagent.ServiceGroup
+	for _, g := range a.groups {
+		if g.APIKey == apikey {
+			group = g
+			break
+		}
+	}
+	for _, d := range a.devices {
+		if d.DeviceID != id {
+			continue
+		}
+		if d.ApiKey != nil && *d.ApiKey == apikey {
+			return d, a.groupOf(str(d.Service), str(d.ServicePath))
+		}
+		if d.ApiKey == nil && group != nil && str(d.Service) == str(group.Service) && str(d.ServicePath) == str(group.SubService) {
+			return d, group
+		}
+	}
+	if group == nil {
+		return nil, nil
+	}
+	d := &iotagent.Device{DeviceID: id, Protocol: "IoTA-JSON"}
+	a.provision(d, str(group.Service), str(group.SubService))
+	return d, group
+}
+
+// mapping returns the attribute definition of given measure key.
+func mapping(key string, lists ...*[]iotagent.DeviceAttribute) (iotagent.DeviceAttribute, bool) {
+	for _, l := range lists {
+		if l == nil {
+			continue
+		}
+		for _, da := range *l {
+			if (da.ObjectID != nil && *da.ObjectID == key) || (da.ObjectID == nil && da.Name == key) {
+				return da, true
+			}
+		}
+	}
+	return iotagent.DeviceAttribute{}, false
+}
+
+// inferType returns NGSIv2 type of given JSON value.
+func inferType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return ngsi.None
+	case float64:
+		return ngsi.Number
+	case string:
+		return ngsi.Text
+	case bool:
+		return ngsi.Boolean
+	case []interface{}:
+		return ngsi.Array
+	}
+	return ngsi.StructuredValue
+}
+
+func attribute(typeName string, v interface{}, unit *iotagent.UnitMetadata) ngsi.Attribute {
+	at := ngsi.Attribute{Type: typeName, Value: v}
+	if unit != nil {
+		at.Metadata = map[string]ngsi.Metadata{"unitCode": {Type: unit.UnitCode.Type, Value: unit.UnitCode.Value}}
+	}
+	return at
+}
+
+// toEntity translates a measure into the entity of the device.
+func toEntity(d *iotagent.Device, g *iotagent.ServiceGroup, measure map[string]interface{}, at string) *ngsi.Entity {
+	var groupAttrs, groupStatic *[]iotagent.DeviceAttribute
+	timestamp := d.TimeStamp != nil && *d.TimeStamp
+	if g != nil {
+		groupAttrs, groupStatic = g.Attributes, g.StaticAttributes
+		timestamp = timestamp || (d.TimeStamp == nil && g.TimeStamp != nil && *g.TimeStamp)
+	}
+
+	keys := make([]string, 0, len(measure))
+	for k := range measure {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	e := ngsi.NewEntity(d.EntityName, d.EntityType)
+	for _, k := range keys {
+		v := measure[k]
+		if da, ok := mapping(k, d.Attributes, groupAttrs); ok {
+			e.Set(da.Name, attribute(da.Type, v, da.UnitMeta))
+			continue
+		}
+		e.Set(k, attribute(inferType(v), v, nil))
+	}
+	for _, l := range []*[]iotagent.DeviceAttribute{groupStatic, d.StaticAttributes} {
+		if l == nil {
+			continue
+		}
+		for _, sa := range *l {
+			e.Set(sa.Name, attribute(sa.Type, str(sa.Value), sa.UnitMeta))
+		}
+	}
+	if timestamp {
+		e.Set(TimeInstant, ngsi.NewAttribute(ngsi.DateTime, at))
+	}
+	return e
+}
+
+// orionAccessor returns the accessor of the Orion. nil when OrionURL is empty. Have to be called with the lock.
+func (a *Agent) orionAccessor() *orion.Accessor {
+	if len(a.OrionURL) <= 0 {
+		return nil
+	}
+	if a.orion == nil || a.orion.BaseUrl != a.OrionURL {
+		a.orion = orion.NewAccessor(a.OrionURL)
+	}
+	return a.orion
+}
+
+// serveMeasure serves POST /iot/json?k={apikey}&i={device id}. The payload is a measure object or an array of them.
+func (a *Agent) serveMeasure(w http.ResponseWriter, r *request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	q := r.URL.Query()
+	apikey, id := q.Get("k"), q.Get("i")
+	if len(apikey) <= 0 || len(id) <= 0 {
+		writeError(w, http.StatusBadRequest, ErrorMandatoryParams, `Some of the mandatory params weren't found in the request: ["i","k"]`)
+		return
+	}
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		wrongSyntax(w, err)
+		return
+	}
+	var measures []map[string]interface{}
+	if err := json.Unmarshal(b, &measures); err != nil {
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			wrongSyntax(w, err)
+			return
+		}
+		measures = append(measures, m)
+	}
+	at := q.Get("t")
+	if len(at) <= 0 {
+		at = time.Now().UTC().Format(ngsi.DateTimeFormat)
+	}
+
+	a.mu.Lock()
+	d, g := a.lookupDevice(apikey, id)
+	if d == nil {
+		a.mu.Unlock()
+		writeError(w, http.StatusNotFound, ErrorDeviceGroupNotFound, fmt.Sprintf(`Couldn't find device group for fields: ["resource","apikey"] and values: {"resource":"/%s","apikey":%q}`, iotagent.JsonResourceUrl, apikey))
+		return
+	}
+	entities := make([]*ngsi.Entity, 0, len(measures))
+	for _, m := range measures {
+		entities = append(entities, toEntity(d, g, m, at))
+	}
+	service, servicePath := str(d.Service), str(d.ServicePath)
+	oa := a.orionAccessor()
+	a.mu.Unlock()
+
+	if oa != nil {
+		for _, e := range entities {
+			if err := oa.BatchUpdateCtx(r.Context(), service, servicePath, orion.ActionTypes.Append, []*ngsi.Entity{e}); err != nil {
+				writeError(w, http.StatusInternalServerError, ErrorEntityGeneric, fmt.Sprintf("Error accesing entity data for device: %s of type: %s: %s", e.ID, e.Type, err))
+				return
+			}
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,193 @@
+// in-memory IoT Agent JSON for tests.
+// It serves /iot/about, /iot/services, /iot/devices and /admin/log of the northbound API and /iot/json measures of the
+// southbound API on the same port. Measures are translated into entity updates (batch append) on the Orion specified
+// by OrionURL, e.g. the one of oriontest. Errors are returned in iotagent.IotError format.
+package iotagenttest
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/go-fiware-api/iotagent"
+	"github.com/marrbor/go-fiware-api/orion"
+)
+
+const (
+	Version    = "1.19.0"
+	LibVersion = "2.15.0"
+
+	DefaultLimit    = 20
+	DefaultLogLevel = "DEBUG"
+
+	// error names that IoT Agent returns.
+	ErrorMissingHeaders      = "MISSING_HEADERS"
+	ErrorDuplicateDevice     = "DUPLICATE_DEVICE_ID"
+	ErrorDeviceNotFound      = "DEVICE_NOT_FOUND"
+	ErrorDuplicateGroup      = "DUPLICATE_GROUP"
+	ErrorDeviceGroupNotFound = "DEVICE_GROUP_NOT_FOUND"
+	ErrorInvalidLogLevel     = "INVALID_LOG_LEVEL"
+	ErrorEntityGeneric       = "ENTITY_GENERIC_ERROR"
+	ErrorNotFound            = "NOT_FOUND"
+	ErrorMethodNotAllowed    = "METHOD_NOT_ALLOWED"
+)
+
+var (
+	// LogLevels holds log levels the agent accepts.
+	LogLevels = []string{"INFO", "ERROR", "FATAL", "DEBUG", "WARNING"}
+)
+
+// Server is an httptest server that serves the in-memory agent.
+type Server struct {
+	*httptest.Server
+	Agent *Agent
+}
+
+// NewServer starts and returns new Server instance that updates entities on given Orion. Measures are dropped when
+// orionURL is empty. Close it when finished.
+func NewServer(orionURL string) *Server {
+	a := NewAgent(orionURL)
+	return &Server{Server: httptest.NewServer(a), Agent: a}
+}
+
+// Agent is an http.Handler that behaves like IoT Agent JSON.
+type Agent struct {
+	OrionURL string // context broker that measures are sent to.
+
+	mu       sync.Mutex
+	devices  []*iotagent.Device
+	groups   []*iotagent.ServiceGroup
+	logLevel string
+	orion    *orion.Accessor
+}
+
+// NewAgent returns new Agent instance that has no devices and service groups.
+func NewAgent(orionURL string) *Agent {
+	return &Agent{OrionURL: orionURL, logLevel: DefaultLogLevel}
+}
+
+// Reset removes all devices and service groups.
+func (a *Agent) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.devices, a.groups = nil, nil
+}
+
+// request holds a request and fiware headers.
+type request struct {
+	*http.Request
+	service     string
+	servicePath string
+}
+
+// ServeHTTP implements http.Handler.
+func (a *Agent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := &request{
+		Request:     r,
+		service:     strings.ToLower(r.Header.Get(common.ServiceHeader)),
+		servicePath: r.Header.Get(common.ServicePathHeader),
+	}
+	if c := r.Header.Get(common.CorrelatorHeader); 0 < len(c) {
+		w.Header().Set(common.CorrelatorHeader, c)
+	}
+
+	// iotagent.LogUrl begins with a slash, so the accessor may send double slashes.
+	switch p := path.Clean("/" + r.URL.Path); p {
+	case "/" + iotagent.AboutUrl:
+		a.serveAbout(w, req)
+	case iotagent.LogUrl:
+		a.serveLog(w, req)
+	case "/" + iotagent.ServiceGroupUrl:
+		a.serveGroups(w, req)
+	case "/" + iotagent.DevicesUrl:
+		a.serveDevices(w, req)
+	case "/" + iotagent.JsonResourceUrl:
+		a.serveMeasure(w, req)
+	default:
+		if strings.HasPrefix(p, "/"+iotagent.DevicesUrl+"/") {
+			a.serveDevice(w, req, strings.TrimPrefix(p, "/"+iotagent.DevicesUrl+"/"))
+			return
+		}
+		writeError(w, http.StatusNotFound, ErrorNotFound, "Route not found: "+p)
+	}
+}
+
+// requireHeaders responds 400 when fiware-service or fiware-servicepath is missing.
+func (r *request) requireHeaders(w http.ResponseWriter) bool {
+	var missing []string
+	if len(r.service) <= 0 {
+		missing = append(missing, `"fiware-service"`)
+	}
+	if len(r.servicePath) <= 0 {
+		missing = append(missing, `"fiware-servicepath"`)
+	}
+	if 0 < len(missing) {
+		writeError(w, http.StatusBadRequest, ErrorMissingHeaders, "Some headers were missing from the request: ["+strings.Join(missing, ",")+"]")
+		return false
+	}
+	return true
+}
+
+// serveAbout serves GET /iot/about.
+func (a *Agent) serveAbout(w http.ResponseWriter, r *request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	_, port, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		port = "4041"
+	}
+	writeJSON(w, http.StatusOK, iotagent.APIAbout{LibVersion: LibVersion, Port: port, BaseRoot: "/", Version: Version})
+}
+
+// serveLog serves GET and PUT /admin/log.
+func (a *Agent) serveLog(w http.ResponseWriter, r *request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, iotagent.Level{Level: a.logLevel})
+	case http.MethodPut:
+		level := strings.ToUpper(r.URL.Query().Get("level"))
+		for _, l := range LogLevels {
+			if l == level {
+				a.logLevel = level
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+		}
+		writeError(w, http.StatusBadRequest, ErrorInvalidLogLevel, "invalid log level: "+r.URL.Query().Get("level"))
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, name, message string) {
+	writeJSON(w, status, iotagent.IotError{Name: name, Message: message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, ErrorMethodNotAllowed, "method not allowed")
+}
+
+func wrongSyntax(w http.ResponseWriter, err error) {
+	writeError(w, http.StatusBadRequest, iotagent.WrongSyntaxError, "Wrong syntax in request: "+err.Error())
+}
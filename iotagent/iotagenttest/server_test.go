@@ -0,0 +1,146 @@
+package iotagenttest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/iotagent"
+	"github.com/marrbor/go-fiware-api/iotagent/iotagenttest"
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
+	"github.com/marrbor/gohttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_About(t *testing.T) {
+	ts := iotagenttest.NewServer("")
+	defer ts.Close()
+	a := iotagent.NewAccessor(ts.URL, ts.URL)
+
+	v, err := a.ReadAbout()
+	assert.NoError(t, err)
+	assert.EqualValues(t, iotagenttest.Version, v.Version)
+
+	level, err := a.ReadLogLevel()
+	assert.NoError(t, err)
+	assert.EqualValues(t, iotagenttest.DefaultLogLevel, level)
+	assert.NoError(t, a.UpdateLogLevel("ERROR"))
+	level, err = a.ReadLogLevel()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "ERROR", level)
+	assert.Error(t, a.UpdateLogLevel("VERBOSE"))
+}
+
+func TestServer_Devices(t *testing.T) {
+	ts := iotagenttest.NewServer("")
+	defer ts.Close()
+	a := iotagent.NewAccessor(ts.URL, ts.URL)
+
+	devices := iotagent.PostDevices{Devices: []iotagent.Device{
+		{DeviceID: "dev1", EntityType: "Sensor", Protocol: "IoTA-JSON"},
+		{DeviceID: "dev2", EntityName: "urn:ngsi-ld:Sensor:002", EntityType: "Sensor", Protocol: "IoTA-JSON"},
+		{DeviceID: "dev3", Protocol: "IoTA-JSON"},
+	}}
+	assert.NoError(t, a.CreateDevice("openiot", "/a", devices))
+	assert.NoError(t, a.CreateDevice("openiot", "/b", iotagent.PostDevices{Devices: devices.Devices[:1]}))
+
+	limit, offset := 2, 1
+	gd, err := a.ReadDevices("openiot", "/a", &limit, &offset)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, gd.Count)
+	assert.EqualValues(t, 2, len(gd.Devices))
+	assert.EqualValues(t, "urn:ngsi-ld:Sensor:002", gd.Devices[0].EntityName)
+	assert.EqualValues(t, "Thing:dev3", gd.Devices[1].EntityName)
+
+	d, err := a.ReadDevice("openiot", "/a", "dev1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "Sensor:dev1", d.EntityName)
+
+	assert.NoError(t, a.UpdateDevice("openiot", "/a", "dev1", `{"entity_name":"Room1"}`))
+	d, err = a.ReadDevice("openiot", "/a", "dev1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "Room1", d.EntityName)
+	assert.EqualValues(t, "Sensor", d.EntityType)
+
+	assert.NoError(t, a.DeleteDevice("openiot", "/a", "dev1"))
+	_, err = a.ReadDevice("openiot", "/a", "dev1")
+	assert.EqualError(t, err, iotagent.DeviceNotFoundError.Error())
+	_, err = a.ReadDevice("openiot", "/b", "dev1")
+	assert.NoError(t, err)
+}
+
+func TestServer_Errors(t *testing.T) {
+	ts := iotagenttest.NewServer("")
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		method, path, service string
+		status                int
+		name                  string
+	}{
+		{http.MethodGet, "/iot/devices", "", http.StatusBadRequest, iotagenttest.ErrorMissingHeaders},
+		{http.MethodGet, "/iot/devices/dev1", "openiot", http.StatusNotFound, iotagenttest.ErrorDeviceNotFound},
+		{http.MethodDelete, "/iot/services?resource=/iot/json&apikey=1234", "openiot", http.StatusNotFound, iotagenttest.ErrorDeviceGroupNotFound},
+		{http.MethodPost, "/iot/json?k=1234&i=dev1", "", http.StatusNotFound, iotagenttest.ErrorDeviceGroupNotFound},
+		{http.MethodPost, "/iot/json?k=1234", "", http.StatusBadRequest, iotagenttest.ErrorMandatoryParams},
+	} {
+		req, err := http.NewRequest(tc.method, ts.URL+tc.path, strings.NewReader(`{}`))
+		assert.NoError(t, err)
+		iotagent.AddIoTHeader(tc.service, "/", req)
+		res, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		var ie iotagent.IotError
+		assert.NoError(t, gohttp.ResponseJSONToParams(res, &ie))
+		assert.EqualValues(t, tc.status, res.StatusCode, tc.path)
+		assert.EqualValues(t, tc.name, ie.Name, tc.path)
+	}
+}
+
+func TestServer_Measure(t *testing.T) {
+	cb := oriontest.NewServer()
+	defer cb.Close()
+	ts := iotagenttest.NewServer(cb.URL)
+	defer ts.Close()
+	a := iotagent.NewAccessor(ts.URL, ts.URL)
+	o := orion.NewAccessor(cb.URL)
+
+	objectID := "t"
+	assert.NoError(t, a.CreateServiceGroup("openiot", "/", &iotagent.APIServiceGroup{Services: []iotagent.ServiceGroup{{
+		Resource:   "/iot/json",
+		APIKey:     iotagent.DefaultIoTAPIKey,
+		EntityType: "Thermometer",
+		Attributes: &[]iotagent.DeviceAttribute{{ObjectID: &objectID, Name: "temperature", Type: "Number"}},
+	}}}))
+	err := a.CreateServiceGroup("other", "/", &iotagent.APIServiceGroup{Services: []iotagent.ServiceGroup{{Resource: "/iot/json", APIKey: iotagent.DefaultIoTAPIKey}}})
+	assert.Error(t, err)
+
+	// the device is provisioned by the first measure.
+	assert.NoError(t, a.SendJsonReport("openiot", "/", iotagent.DefaultIoTAPIKey, "thermo1", map[string]interface{}{"t": 21.5, "status": "ok"}))
+	var e map[string]interface{}
+	assert.NoError(t, o.GetEntity("openiot", "/", "Thermometer:thermo1", orion.NewKeyValuesQuery(), &e))
+	assert.EqualValues(t, 21.5, e["temperature"])
+	assert.EqualValues(t, "ok", e["status"])
+
+	d, err := a.ReadDevice("openiot", "/", "thermo1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "Thermometer", d.EntityType)
+
+	// multi measures are applied in order.
+	assert.NoError(t, a.SendJsonTextReport("openiot", "/", iotagent.DefaultIoTAPIKey, "thermo1", `[{"t":22},{"t":23}]`))
+	var temperature float64
+	assert.NoError(t, o.GetEntityAttributeValue("openiot", "/", "Thermometer:thermo1", "temperature", "", &temperature))
+	assert.EqualValues(t, 23, temperature)
+
+	err = a.SendJsonTextReport("openiot", "/", iotagent.DefaultIoTAPIKey, "thermo1", `{"t":`)
+	assert.Error(t, err)
+
+	gs, err := a.ReadServiceGroup("openiot", "/")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(gs.Services))
+	b, err := json.Marshal(gs.Services[0].Attributes)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"object_id":"t","name":"temperature","type":"Number"}]`, string(b))
+	assert.NoError(t, a.DeleteServiceGroup("/iot/json", iotagent.DefaultIoTAPIKey))
+}
@@ -1,12 +1,12 @@
 package iotagent
 
 import (
-	"fmt"
+	"context"
 	"net/http"
 	"strings"
 
+	"github.com/marrbor/go-fiware-api/common"
 	"github.com/marrbor/gohttp"
-	"github.com/marrbor/golog"
 )
 
 const (
@@ -15,7 +15,12 @@ const (
 
 // https://fiware-iotagent-json.letsfiware.jp/usermanual/index.html#_3
 func (a *Accessor) SendJsonReport(service, path, key, id string, report interface{}) error {
-	req, err := gohttp.GenRequest(gohttp.HttpMethods.POST, a.genReportUrl(JsonResourceUrl), report)
+	return a.SendJsonReportCtx(context.Background(), service, path, key, id, report)
+}
+
+// SendJsonReportCtx is SendJsonReport with given context.
+func (a *Accessor) SendJsonReportCtx(ctx context.Context, service, path, key, id string, report interface{}) error {
+	req, err := common.GenRequest(ctx, gohttp.HttpMethods.POST, a.genReportUrl(JsonResourceUrl), report)
 	if err != nil {
 		return err
 	}
@@ -23,7 +28,7 @@ func (a *Accessor) SendJsonReport(service, path, key, id string, report interfac
 	q.Add("k", key)
 	q.Add("i", id)
 	req.URL.RawQuery = q.Encode()
-	golog.Info(fmt.Sprintf("send report(%+v) to url (%s)", report, req.URL.String()))
+	a.log(ctx, common.LogLevelDebug, "send report", "device", id, "url", req.URL)
 
 	res, err := a.Crud(AddIoTHeader(service, path, req))
 	if err != nil {
@@ -31,14 +36,19 @@ func (a *Accessor) SendJsonReport(service, path, key, id string, report interfac
 	}
 
 	if !gohttp.IsSuccessful(res) {
-		return gohttp.ResponseToError(res, nil, nil)
+		return common.NewCorrelatedError(res, gohttp.ResponseToError(res, nil, nil))
 	}
 	return nil
 }
 
 // https://fiware-iotagent-json.letsfiware.jp/usermanual/index.html#_3
 func (a *Accessor) SendJsonTextReport(service, path, key, id, report string) error {
-	req, err := http.NewRequest(http.MethodPost, a.genReportUrl(JsonResourceUrl), strings.NewReader(report))
+	return a.SendJsonTextReportCtx(context.Background(), service, path, key, id, report)
+}
+
+// SendJsonTextReportCtx is SendJsonTextReport with given context.
+func (a *Accessor) SendJsonTextReportCtx(ctx context.Context, service, path, key, id, report string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.genReportUrl(JsonResourceUrl), strings.NewReader(report))
 	if err != nil {
 		return err
 	}
@@ -46,7 +56,7 @@ func (a *Accessor) SendJsonTextReport(service, path, key, id, report string) err
 	q.Add("k", key)
 	q.Add("i", id)
 	req.URL.RawQuery = q.Encode()
-	golog.Info(fmt.Sprintf("send report(%+v) to url (%s)", report, req.URL.String()))
+	a.log(ctx, common.LogLevelDebug, "send report", "device", id, "url", req.URL)
 
 	res, err := a.Crud(AddIoTHeader(service, path, req))
 	if err != nil {
@@ -54,7 +64,7 @@ func (a *Accessor) SendJsonTextReport(service, path, key, id, report string) err
 	}
 
 	if !gohttp.IsSuccessful(res) {
-		return gohttp.ResponseToError(res, nil, nil)
+		return common.NewCorrelatedError(res, gohttp.ResponseToError(res, nil, nil))
 	}
 	return nil
 }
@@ -1,9 +1,11 @@
 package iotagent
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
+	"github.com/marrbor/go-fiware-api/common"
 	"github.com/marrbor/gohttp"
 )
 
@@ -19,7 +21,12 @@ type (
 
 // https://github.com/telefonicaid/iotagent-node-lib/blob/master/doc/api.md#put-adminlog-1
 func (a *Accessor) ReadLogLevel() (string, error) {
-	req, err := http.NewRequest(http.MethodGet, a.configUrl+LogUrl, nil)
+	return a.ReadLogLevelCtx(context.Background())
+}
+
+// ReadLogLevelCtx is ReadLogLevel with given context.
+func (a *Accessor) ReadLogLevelCtx(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.configUrl+LogUrl, nil)
 	if err != nil {
 		return "", err
 	}
@@ -28,7 +35,7 @@ func (a *Accessor) ReadLogLevel() (string, error) {
 		return "", err
 	}
 	if res.StatusCode != http.StatusOK {
-		return "", fmt.Errorf(res.Status)
+		return "", common.NewCorrelatedError(res, fmt.Errorf(res.Status))
 	}
 	var level Level
 	if err := gohttp.ResponseJSONToParams(res, &level); err != nil {
@@ -40,7 +47,12 @@ func (a *Accessor) ReadLogLevel() (string, error) {
 // UpdateLogLevel updates log level. If the new level is a valid level for Logops (i.e.: one of the items in the array ['INFO', 'ERROR', 'FATAL', 'DEBUG', 'WARNING']), it will be automatically changed for future logs.
 // https://github.com/telefonicaid/iotagent-node-lib/blob/master/doc/api.md#put-adminlogp
 func (a *Accessor) UpdateLogLevel(level string) error {
-	req, err := http.NewRequest(http.MethodPut, a.genConfigUrl(LogUrl), nil)
+	return a.UpdateLogLevelCtx(context.Background(), level)
+}
+
+// UpdateLogLevelCtx updates log level with given context.
+func (a *Accessor) UpdateLogLevelCtx(ctx context.Context, level string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, a.genConfigUrl(LogUrl), nil)
 	if err != nil {
 		return err
 	}
@@ -51,7 +63,7 @@ func (a *Accessor) UpdateLogLevel(level string) error {
 	}
 
 	if !gohttp.IsSuccessful(res) {
-		return fmt.Errorf(res.Status)
+		return common.NewCorrelatedError(res, fmt.Errorf(res.Status))
 	}
 	return nil
 }
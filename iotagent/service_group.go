@@ -3,9 +3,11 @@
 package iotagent
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
+	"github.com/marrbor/go-fiware-api/common"
 	"github.com/marrbor/gohttp"
 )
 
@@ -40,12 +42,17 @@ type (
 
 // CreateServiceGroup registers given service group into iot agent.
 func (a *Accessor) CreateServiceGroup(service, path string, body *APIServiceGroup) error {
+	return a.CreateServiceGroupCtx(context.Background(), service, path, body)
+}
+
+// CreateServiceGroupCtx registers given service group into iot agent with given context.
+func (a *Accessor) CreateServiceGroupCtx(ctx context.Context, service, path string, body *APIServiceGroup) error {
 	// set service and service path into request.
 	for i := range body.Services {
 		body.Services[i].Service = &service
 		body.Services[i].SubService = &path
 	}
-	req, err := gohttp.GenRequest(gohttp.HttpMethods.POST, a.genConfigUrl(ServiceGroupUrl), body)
+	req, err := common.GenRequest(ctx, gohttp.HttpMethods.POST, a.genConfigUrl(ServiceGroupUrl), body)
 	if err != nil {
 		return err
 	}
@@ -56,14 +63,19 @@ func (a *Accessor) CreateServiceGroup(service, path string, body *APIServiceGrou
 	}
 
 	if !gohttp.IsSuccessful(res) {
-		return gohttp.ResponseToError(res, nil, nil)
+		return common.NewCorrelatedError(res, gohttp.ResponseToError(res, nil, nil))
 	}
 	return nil
 }
 
 // ReadServiceGroup retrieves service groups from the iot agent.
 func (a *Accessor) ReadServiceGroup(service, path string) (*APIServiceGroup, error) {
-	req, err := gohttp.GenRequest(gohttp.HttpMethods.GET, a.genConfigUrl(ServiceGroupUrl), nil)
+	return a.ReadServiceGroupCtx(context.Background(), service, path)
+}
+
+// ReadServiceGroupCtx retrieves service groups from the iot agent with given context.
+func (a *Accessor) ReadServiceGroupCtx(ctx context.Context, service, path string) (*APIServiceGroup, error) {
+	req, err := common.GenRequest(ctx, gohttp.HttpMethods.GET, a.genConfigUrl(ServiceGroupUrl), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -74,7 +86,7 @@ func (a *Accessor) ReadServiceGroup(service, path string) (*APIServiceGroup, err
 	}
 
 	if !gohttp.IsSuccessful(res) {
-		return nil, gohttp.ResponseToError(res, nil, nil)
+		return nil, common.NewCorrelatedError(res, gohttp.ResponseToError(res, nil, nil))
 	}
 
 	var asg APIServiceGroup
@@ -86,7 +98,12 @@ func (a *Accessor) ReadServiceGroup(service, path string) (*APIServiceGroup, err
 
 // UpdateServiceGroup modifies the information for a service group configuration, identified by the resource and apikey query parameters.
 func (a *Accessor) UpdateServiceGroup(resource, apikey, json string) error {
-	req, err := http.NewRequest(http.MethodPut, a.genConfigUrl(ServiceGroupUrl), strings.NewReader(json))
+	return a.UpdateServiceGroupCtx(context.Background(), resource, apikey, json)
+}
+
+// UpdateServiceGroupCtx modifies the information for a service group configuration, identified by the resource and apikey query parameters with given context.
+func (a *Accessor) UpdateServiceGroupCtx(ctx context.Context, resource, apikey, json string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, a.genConfigUrl(ServiceGroupUrl), strings.NewReader(json))
 	if err != nil {
 		return err
 	}
@@ -97,14 +114,19 @@ func (a *Accessor) UpdateServiceGroup(resource, apikey, json string) error {
 	}
 
 	if !gohttp.IsSuccessful(res) {
-		return gohttp.ResponseToError(res, nil, nil)
+		return common.NewCorrelatedError(res, gohttp.ResponseToError(res, nil, nil))
 	}
 	return nil
 }
 
 // DeleteServiceGroup deletes specified group.
 func (a *Accessor) DeleteServiceGroup(resource, apikey string) error {
-	req, err := http.NewRequest(http.MethodDelete, a.genConfigUrl(ServiceGroupUrl), nil)
+	return a.DeleteServiceGroupCtx(context.Background(), resource, apikey)
+}
+
+// DeleteServiceGroupCtx deletes specified group with given context.
+func (a *Accessor) DeleteServiceGroupCtx(ctx context.Context, resource, apikey string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, a.genConfigUrl(ServiceGroupUrl), nil)
 	if err != nil {
 		return err
 	}
@@ -114,7 +136,7 @@ func (a *Accessor) DeleteServiceGroup(resource, apikey string) error {
 	}
 
 	if !gohttp.IsSuccessful(res) {
-		return gohttp.ResponseToError(res, nil, nil)
+		return common.NewCorrelatedError(res, gohttp.ResponseToError(res, nil, nil))
 	}
 	return nil
 }
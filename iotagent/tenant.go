@@ -0,0 +1,128 @@
+// tenant scoped accessor
+package iotagent
+
+import (
+	"context"
+
+	"github.com/marrbor/go-fiware-api/common"
+)
+
+// Tenant is an accessor bound to a fiware-service and fiware-servicepath.
+// IoT Agent does not support query scopes, so the service path has to be a single path.
+type Tenant struct {
+	a           *Accessor
+	service     string
+	servicePath string
+}
+
+// Tenant returns new Tenant instance that accesses given service and service path. Both of them are validated here
+// and required as the same as orion.Tenant.
+func (a *Accessor) Tenant(service, servicePath string) (*Tenant, error) {
+	if !common.IsValidService(service) {
+		return nil, common.InvalidServiceName
+	}
+	if !common.IsValidServicePath(servicePath) {
+		return nil, common.InvalidServicePath
+	}
+	return &Tenant{a: a, service: service, servicePath: servicePath}, nil
+}
+
+// Service returns fiware-service of this tenant.
+func (t *Tenant) Service() string {
+	return t.service
+}
+
+// ServicePath returns fiware-servicepath of this tenant.
+func (t *Tenant) ServicePath() string {
+	return t.servicePath
+}
+
+// CreateDevice is Accessor.CreateDevice for this tenant.
+func (t *Tenant) CreateDevice(devices PostDevices) error {
+	return t.CreateDeviceCtx(context.Background(), devices)
+}
+
+// CreateDeviceCtx is CreateDevice with given context.
+func (t *Tenant) CreateDeviceCtx(ctx context.Context, devices PostDevices) error {
+	return t.a.CreateDeviceCtx(ctx, t.service, t.servicePath, devices)
+}
+
+// ReadDevices is Accessor.ReadDevices for this tenant.
+func (t *Tenant) ReadDevices(limit, offset *int) (*GetDevices, error) {
+	return t.ReadDevicesCtx(context.Background(), limit, offset)
+}
+
+// ReadDevicesCtx is ReadDevices with given context.
+func (t *Tenant) ReadDevicesCtx(ctx context.Context, limit, offset *int) (*GetDevices, error) {
+	return t.a.ReadDevicesCtx(ctx, t.service, t.servicePath, limit, offset)
+}
+
+// ReadDevice is Accessor.ReadDevice for this tenant.
+func (t *Tenant) ReadDevice(id string) (*Device, error) {
+	return t.ReadDeviceCtx(context.Background(), id)
+}
+
+// ReadDeviceCtx is ReadDevice with given context.
+func (t *Tenant) ReadDeviceCtx(ctx context.Context, id string) (*Device, error) {
+	return t.a.ReadDeviceCtx(ctx, t.service, t.servicePath, id)
+}
+
+// UpdateDevice is Accessor.UpdateDevice for this tenant.
+func (t *Tenant) UpdateDevice(id, json string) error {
+	return t.UpdateDeviceCtx(context.Background(), id, json)
+}
+
+// UpdateDeviceCtx is UpdateDevice with given context.
+func (t *Tenant) UpdateDeviceCtx(ctx context.Context, id, json string) error {
+	return t.a.UpdateDeviceCtx(ctx, t.service, t.servicePath, id, json)
+}
+
+// DeleteDevice is Accessor.DeleteDevice for this tenant.
+func (t *Tenant) DeleteDevice(id string) error {
+	return t.DeleteDeviceCtx(context.Background(), id)
+}
+
+// DeleteDeviceCtx is DeleteDevice with given context.
+func (t *Tenant) DeleteDeviceCtx(ctx context.Context, id string) error {
+	return t.a.DeleteDeviceCtx(ctx, t.service, t.servicePath, id)
+}
+
+// SendJsonReport is Accessor.SendJsonReport for this tenant.
+func (t *Tenant) SendJsonReport(key, id string, report interface{}) error {
+	return t.SendJsonReportCtx(context.Background(), key, id, report)
+}
+
+// SendJsonReportCtx is SendJsonReport with given context.
+func (t *Tenant) SendJsonReportCtx(ctx context.Context, key, id string, report interface{}) error {
+	return t.a.SendJsonReportCtx(ctx, t.service, t.servicePath, key, id, report)
+}
+
+// SendJsonTextReport is Accessor.SendJsonTextReport for this tenant.
+func (t *Tenant) SendJsonTextReport(key, id, report string) error {
+	return t.SendJsonTextReportCtx(context.Background(), key, id, report)
+}
+
+// SendJsonTextReportCtx is SendJsonTextReport with given context.
+func (t *Tenant) SendJsonTextReportCtx(ctx context.Context, key, id, report string) error {
+	return t.a.SendJsonTextReportCtx(ctx, t.service, t.servicePath, key, id, report)
+}
+
+// CreateServiceGroup is Accessor.CreateServiceGroup for this tenant.
+func (t *Tenant) CreateServiceGroup(body *APIServiceGroup) error {
+	return t.CreateServiceGroupCtx(context.Background(), body)
+}
+
+// CreateServiceGroupCtx is CreateServiceGroup with given context.
+func (t *Tenant) CreateServiceGroupCtx(ctx context.Context, body *APIServiceGroup) error {
+	return t.a.CreateServiceGroupCtx(ctx, t.service, t.servicePath, body)
+}
+
+// ReadServiceGroup is Accessor.ReadServiceGroup for this tenant.
+func (t *Tenant) ReadServiceGroup() (*APIServiceGroup, error) {
+	return t.ReadServiceGroupCtx(context.Background())
+}
+
+// ReadServiceGroupCtx is ReadServiceGroup with given context.
+func (t *Tenant) ReadServiceGroupCtx(ctx context.Context) (*APIServiceGroup, error) {
+	return t.a.ReadServiceGroupCtx(ctx, t.service, t.servicePath)
+}
@@ -0,0 +1,188 @@
+// NGSIv2 attribute and metadata
+// https://fiware.github.io/specifications/ngsiv2/stable/ #Attributes, #Attribute Metadata
+package ngsi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// None is a type Orion sets for the attribute that has null value.
+	None = "None"
+
+	// DateTimeFormat is a format of DateTime value. Orion accepts ISO8601 format.
+	DateTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+var (
+	MismatchValueTypeError = fmt.Errorf("value type mismatch")
+)
+
+type (
+	// Metadata holds type and value of an attribute metadata.
+	Metadata struct {
+		Type  string      `json:"type,omitempty"`
+		Value interface{} `json:"value"`
+	}
+
+	// Attribute holds type, value and metadata of an entity attribute.
+	Attribute struct {
+		Type     string              `json:"type,omitempty"`
+		Value    interface{}         `json:"value"`
+		Metadata map[string]Metadata `json:"metadata,omitempty"`
+	}
+)
+
+// NewAttribute returns new Attribute instance that has given type and value.
+func NewAttribute(typeName string, value interface{}) Attribute {
+	return Attribute{Type: typeName, Value: value}
+}
+
+// NewNumber returns new Number attribute.
+func NewNumber(v float64) Attribute {
+	return NewAttribute(Number, v)
+}
+
+// NewInteger returns new Integer attribute.
+func NewInteger(v int64) Attribute {
+	return NewAttribute(Integer, v)
+}
+
+// NewText returns new Text attribute.
+func NewText(v string) Attribute {
+	return NewAttribute(Text, v)
+}
+
+// NewBoolean returns new Boolean attribute.
+func NewBoolean(v bool) Attribute {
+	return NewAttribute(Boolean, v)
+}
+
+// NewDateTime returns new DateTime attribute. Value is formatted in ISO8601 UTC.
+func NewDateTime(t time.Time) Attribute {
+	return NewAttribute(DateTime, t.UTC().Format(DateTimeFormat))
+}
+
+// NewGeoPoint returns new geo:point attribute.
+func NewGeoPoint(latitude, longitude float64) Attribute {
+	lat := strconv.FormatFloat(latitude, 'f', -1, 64)
+	lng := strconv.FormatFloat(longitude, 'f', -1, 64)
+	return NewAttribute(GeoPoint, fmt.Sprintf("%s, %s", lat, lng))
+}
+
+// NewGeoJson returns new geo:json attribute. Given geometry is such as datamodel.Point and datamodel.Polygon.
+func NewGeoJson(geometry interface{}) Attribute {
+	return NewAttribute(GeoJson, geometry)
+}
+
+// NewStructuredValue returns new StructuredValue attribute.
+func NewStructuredValue(v interface{}) Attribute {
+	return NewAttribute(StructuredValue, v)
+}
+
+// NewArray returns new Array attribute.
+func NewArray(v []interface{}) Attribute {
+	return NewAttribute(Array, v)
+}
+
+// WithMetadata returns a copy of this attribute that has given metadata.
+func (a Attribute) WithMetadata(name string, md Metadata) Attribute {
+	m := make(map[string]Metadata, len(a.Metadata)+1)
+	for k, v := range a.Metadata {
+		m[k] = v
+	}
+	m[name] = md
+	a.Metadata = m
+	return a
+}
+
+// AsFloat returns value as float64. Returns MismatchValueTypeError when the value is not a number.
+func (a Attribute) AsFloat() (float64, error) {
+	switch v := a.Value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	}
+	return 0, MismatchValueTypeError
+}
+
+// AsString returns value as string. Returns MismatchValueTypeError when the value is not a string.
+func (a Attribute) AsString() (string, error) {
+	if s, ok := a.Value.(string); ok {
+		return s, nil
+	}
+	return "", MismatchValueTypeError
+}
+
+// AsBool returns value as bool. Returns MismatchValueTypeError when the value is not a boolean.
+func (a Attribute) AsBool() (bool, error) {
+	if b, ok := a.Value.(bool); ok {
+		return b, nil
+	}
+	return false, MismatchValueTypeError
+}
+
+// AsTime returns DateTime value as time.Time.
+func (a Attribute) AsTime() (time.Time, error) {
+	s, err := a.AsString()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// DecodeValue decodes value into given instance through JSON. It is useful for StructuredValue and geo:json.
+func (a Attribute) DecodeValue(v interface{}) error {
+	b, err := json.Marshal(a.Value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// inferType returns attribute type for given value decoded from keyValues representation.
+func inferType(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return None
+	case float64, json.Number:
+		return Number
+	case string:
+		return Text
+	case bool:
+		return Boolean
+	case []interface{}:
+		return Array
+	}
+	return StructuredValue
+}
+
+// isNormalizedAttribute returns whether given attribute JSON is normalized representation or not.
+// It is an object that has `value` (or `type`) and has no keys other than `type`, `value` and `metadata`.
+func isNormalizedAttribute(raw json.RawMessage) bool {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return false
+	}
+	_, hasValue := m["value"]
+	_, hasType := m["type"]
+	if !hasValue && !hasType {
+		return false
+	}
+	for k := range m {
+		if k != "type" && k != "value" && k != "metadata" {
+			return false
+		}
+	}
+	return true
+}
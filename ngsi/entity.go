@@ -0,0 +1,213 @@
+// NGSIv2 entity
+// https://fiware.github.io/specifications/ngsiv2/stable/ #Entity Representation, #Simplified Entity Representation
+package ngsi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+var (
+	NotObjectError = fmt.Errorf("entity have to be a JSON object")
+)
+
+// Entity holds id, type and attributes of an NGSIv2 entity. Attributes keep the order they have been set.
+// It is marshaled into normalized representation by json.Marshal, and MarshalKeyValues returns keyValues representation.
+type Entity struct {
+	ID    string
+	Type  string
+	attrs map[string]Attribute
+	names []string
+}
+
+// NewEntity returns new Entity instance that has no attributes.
+func NewEntity(id, typeName string) *Entity {
+	return &Entity{ID: id, Type: typeName}
+}
+
+// Set sets given attribute. When the attribute has been already existing, replace it and keep its order.
+func (e *Entity) Set(name string, attr Attribute) *Entity {
+	if e.attrs == nil {
+		e.attrs = make(map[string]Attribute)
+	}
+	if _, ok := e.attrs[name]; !ok {
+		e.names = append(e.names, name)
+	}
+	e.attrs[name] = attr
+	return e
+}
+
+// Get returns specified attribute and whether it exists or not.
+func (e *Entity) Get(name string) (Attribute, bool) {
+	a, ok := e.attrs[name]
+	return a, ok
+}
+
+// Delete removes specified attribute.
+func (e *Entity) Delete(name string) *Entity {
+	if _, ok := e.attrs[name]; !ok {
+		return e
+	}
+	delete(e.attrs, name)
+	for i, n := range e.names {
+		if n == name {
+			e.names = append(e.names[:i], e.names[i+1:]...)
+			break
+		}
+	}
+	return e
+}
+
+// Names returns attribute names in order.
+func (e *Entity) Names() []string {
+	return append([]string{}, e.names...)
+}
+
+// Len returns the number of attributes.
+func (e *Entity) Len() int {
+	return len(e.names)
+}
+
+// marshal writes this entity as JSON object. Each attribute is converted by given function.
+func (e Entity) marshal(attr func(Attribute) interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	write := func(k string, v interface{}) error {
+		if 1 < buf.Len() {
+			buf.WriteByte(',')
+		}
+		kb, err := json.Marshal(k)
+		if err != nil {
+			return err
+		}
+		vb, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		buf.Write(vb)
+		return nil
+	}
+
+	if 0 < len(e.ID) {
+		if err := write("id", e.ID); err != nil {
+			return nil, err
+		}
+	}
+	if 0 < len(e.Type) {
+		if err := write("type", e.Type); err != nil {
+			return nil, err
+		}
+	}
+	for _, n := range e.names {
+		if err := write(n, attr(e.attrs[n])); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalJSON returns normalized representation of this entity.
+func (e Entity) MarshalJSON() ([]byte, error) {
+	return e.marshal(func(a Attribute) interface{} { return a })
+}
+
+// MarshalKeyValues returns keyValues representation of this entity. Attribute types and metadata are dropped.
+func (e Entity) MarshalKeyValues() ([]byte, error) {
+	return e.marshal(func(a Attribute) interface{} { return a.Value })
+}
+
+// KeyValues returns keyValues representation of this entity which can be passed as a request body.
+func (e Entity) KeyValues() json.Marshaler {
+	return keyValuesEntity(e)
+}
+
+type keyValuesEntity Entity
+
+// MarshalJSON returns keyValues representation of the entity.
+func (kv keyValuesEntity) MarshalJSON() ([]byte, error) {
+	return Entity(kv).MarshalKeyValues()
+}
+
+// unmarshal reads JSON object in order. Each attribute is converted by given function.
+func (e *Entity) unmarshal(b []byte, attr func(json.RawMessage) (Attribute, error)) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := t.(json.Delim); !ok || d != '{' {
+		return NotObjectError
+	}
+
+	*e = Entity{}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		k := t.(string) // keys of JSON object are always string.
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		switch k {
+		case "id":
+			if err := json.Unmarshal(raw, &e.ID); err != nil {
+				return err
+			}
+		case "type":
+			if err := json.Unmarshal(raw, &e.Type); err != nil {
+				return err
+			}
+		default:
+			a, err := attr(raw)
+			if err != nil {
+				return err
+			}
+			e.Set(k, a)
+		}
+	}
+	_, err = dec.Token() // closing '}'
+	return err
+}
+
+// normalizedAttribute decodes normalized attribute.
+func normalizedAttribute(raw json.RawMessage) (Attribute, error) {
+	var a Attribute
+	err := json.Unmarshal(raw, &a)
+	return a, err
+}
+
+// keyValuesAttribute decodes keyValues attribute. Type is inferred from the value.
+func keyValuesAttribute(raw json.RawMessage) (Attribute, error) {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return Attribute{}, err
+	}
+	return NewAttribute(inferType(v), v), nil
+}
+
+// UnmarshalJSON decodes normalized or keyValues representation. Representation is detected for each attribute.
+// Use UnmarshalNormalized or UnmarshalKeyValues when the representation is known.
+func (e *Entity) UnmarshalJSON(b []byte) error {
+	return e.unmarshal(b, func(raw json.RawMessage) (Attribute, error) {
+		if isNormalizedAttribute(raw) {
+			return normalizedAttribute(raw)
+		}
+		return keyValuesAttribute(raw)
+	})
+}
+
+// UnmarshalNormalized decodes normalized representation.
+func (e *Entity) UnmarshalNormalized(b []byte) error {
+	return e.unmarshal(b, normalizedAttribute)
+}
+
+// UnmarshalKeyValues decodes keyValues representation. Attribute types are inferred from the values.
+func (e *Entity) UnmarshalKeyValues(b []byte) error {
+	return e.unmarshal(b, keyValuesAttribute)
+}
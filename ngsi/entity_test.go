@@ -0,0 +1,153 @@
+package ngsi_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/marrbor/go-fiware-api/datamodel"
+	"github.com/marrbor/go-fiware-api/ngsi"
+	"github.com/stretchr/testify/assert"
+)
+
+func genRoom() *ngsi.Entity {
+	return ngsi.NewEntity("Room1", "Room").
+		Set("temperature", ngsi.NewNumber(23.5).WithMetadata("accuracy", ngsi.Metadata{Type: ngsi.Number, Value: 0.8})).
+		Set("name", ngsi.NewText("living")).
+		Set("occupied", ngsi.NewBoolean(true)).
+		Set("location", ngsi.NewGeoJson(datamodel.Point{Type: datamodel.TypePoint, Coordinates: []float64{-3.7, 40.4}})).
+		Set("dateObserved", ngsi.NewDateTime(time.Date(2020, 4, 1, 9, 30, 0, 0, time.UTC)))
+}
+
+func TestEntity_MarshalJSON(t *testing.T) {
+	b, err := json.Marshal(genRoom())
+	assert.NoError(t, err)
+	assert.EqualValues(t, `{"id":"Room1","type":"Room",`+
+		`"temperature":{"type":"Number","value":23.5,"metadata":{"accuracy":{"type":"Number","value":0.8}}},`+
+		`"name":{"type":"Text","value":"living"},`+
+		`"occupied":{"type":"Boolean","value":true},`+
+		`"location":{"type":"geo:json","value":{"type":"Point","coordinates":[-3.7,40.4],"bbox":null}},`+
+		`"dateObserved":{"type":"DateTime","value":"2020-04-01T09:30:00.000Z"}}`, string(b))
+}
+
+func TestEntity_MarshalKeyValues(t *testing.T) {
+	e := genRoom().Delete("location")
+	b, err := e.MarshalKeyValues()
+	assert.NoError(t, err)
+	assert.EqualValues(t, `{"id":"Room1","type":"Room","temperature":23.5,"name":"living","occupied":true,"dateObserved":"2020-04-01T09:30:00.000Z"}`, string(b))
+
+	b2, err := json.Marshal([]interface{}{e.KeyValues()})
+	assert.NoError(t, err)
+	assert.EqualValues(t, "["+string(b)+"]", string(b2))
+}
+
+func TestEntity_UnmarshalNormalized(t *testing.T) {
+	b, err := json.Marshal(genRoom())
+	assert.NoError(t, err)
+
+	var e ngsi.Entity
+	assert.NoError(t, e.UnmarshalNormalized(b))
+	assert.EqualValues(t, "Room1", e.ID)
+	assert.EqualValues(t, "Room", e.Type)
+	assert.EqualValues(t, []string{"temperature", "name", "occupied", "location", "dateObserved"}, e.Names())
+
+	temp, ok := e.Get("temperature")
+	assert.True(t, ok)
+	f, err := temp.AsFloat()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 23.5, f)
+	assert.EqualValues(t, 0.8, temp.Metadata["accuracy"].Value)
+
+	loc, _ := e.Get("location")
+	var p datamodel.Point
+	assert.NoError(t, loc.DecodeValue(&p))
+	assert.EqualValues(t, []float64{-3.7, 40.4}, p.Coordinates)
+
+	do, _ := e.Get("dateObserved")
+	tm, err := do.AsTime()
+	assert.NoError(t, err)
+	assert.True(t, tm.Equal(time.Date(2020, 4, 1, 9, 30, 0, 0, time.UTC)))
+
+	// round trip keeps attribute order.
+	b2, err := json.Marshal(e)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(b), string(b2))
+	var e2 ngsi.Entity
+	assert.NoError(t, e2.UnmarshalNormalized(b2))
+	assert.EqualValues(t, e.Names(), e2.Names())
+}
+
+func TestEntity_UnmarshalKeyValues(t *testing.T) {
+	var e ngsi.Entity
+	err := e.UnmarshalKeyValues([]byte(`{"id":"Car1","type":"Car","speed":98,"brand":"Mercedes","electric":false,"tags":["a"],"engine":{"value":1}}`))
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"speed", "brand", "electric", "tags", "engine"}, e.Names())
+
+	for name, typ := range map[string]string{
+		"speed":    ngsi.Number,
+		"brand":    ngsi.Text,
+		"electric": ngsi.Boolean,
+		"tags":     ngsi.Array,
+		"engine":   ngsi.StructuredValue,
+	} {
+		a, ok := e.Get(name)
+		assert.True(t, ok)
+		assert.EqualValues(t, typ, a.Type, name)
+	}
+	brand, _ := e.Get("brand")
+	s, err := brand.AsString()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "Mercedes", s)
+	_, err = brand.AsBool()
+	assert.EqualError(t, err, ngsi.MismatchValueTypeError.Error())
+}
+
+func TestEntity_UnmarshalJSON(t *testing.T) {
+	// representation is detected for each attribute.
+	var es []ngsi.Entity
+	err := json.Unmarshal([]byte(`[
+		{"id":"Car1","type":"Car","speed":{"type":"Number","value":98,"metadata":{}}},
+		{"id":"Car2","type":"Car","speed":50,"owner":{"name":"x"}}
+	]`), &es)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(es))
+
+	s1, _ := es[0].Get("speed")
+	assert.EqualValues(t, ngsi.Number, s1.Type)
+	assert.EqualValues(t, 98, s1.Value)
+	s2, _ := es[1].Get("speed")
+	assert.EqualValues(t, ngsi.Number, s2.Type)
+	assert.EqualValues(t, 50, s2.Value)
+	o, _ := es[1].Get("owner")
+	assert.EqualValues(t, ngsi.StructuredValue, o.Type)
+
+	var e ngsi.Entity
+	assert.EqualError(t, json.Unmarshal([]byte(`[1]`), &e), ngsi.NotObjectError.Error())
+	assert.EqualError(t, e.UnmarshalNormalized([]byte(`[1]`)), ngsi.NotObjectError.Error())
+}
+
+func TestEntity_SetReplace(t *testing.T) {
+	e := ngsi.NewEntity("a", "T").Set("x", ngsi.NewInteger(1)).Set("y", ngsi.NewInteger(2)).Set("x", ngsi.NewInteger(3))
+	assert.EqualValues(t, []string{"x", "y"}, e.Names())
+	assert.EqualValues(t, 2, e.Len())
+	x, _ := e.Get("x")
+	f, err := x.AsFloat()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, f)
+
+	e.Delete("x").Delete("nothing")
+	assert.EqualValues(t, []string{"y"}, e.Names())
+	_, ok := e.Get("x")
+	assert.False(t, ok)
+}
+
+func TestNewGeoPoint(t *testing.T) {
+	a := ngsi.NewGeoPoint(40.4, -3.7)
+	assert.EqualValues(t, ngsi.GeoPoint, a.Type)
+	assert.EqualValues(t, "40.4, -3.7", a.Value)
+
+	a = ngsi.NewStructuredValue(map[string]int{"a": 1})
+	assert.EqualValues(t, ngsi.StructuredValue, a.Type)
+	a = ngsi.NewArray([]interface{}{1, "a"})
+	assert.EqualValues(t, ngsi.Array, a.Type)
+}
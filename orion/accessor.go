@@ -1,14 +1,17 @@
 package orion
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"path"
+	"strings"
 
 	"github.com/marrbor/go-fiware-api/common"
 	"github.com/marrbor/gohttp"
-	"github.com/marrbor/golog"
 )
 
 type (
@@ -17,16 +20,26 @@ type (
 		HttpClient  *http.Client
 		BaseUrl     string
 		EntryPoints *EntryPoints
+		BatchSize   int                    // max number of entities sent by one batch request.
+		Credentials common.Credentials     // attached to every request when specified.
+		Retry       *common.RetryPolicy    // retries transient failures when specified.
+		Breaker     *common.CircuitBreaker // can be shared among accessors.
+		Middlewares []common.Middleware    // see every outgoing call.
+		Logger      common.Logger          // no-op when not specified.
 	}
 
 	// Access Parameter holds parameter for Orion server access.
 	AccessParameter struct {
+		Ctx            context.Context
 		EpID           EntryPointID
 		Method         gohttp.HTTPMethod
 		Service        string
 		ServicePath    string
+		Scoped         bool // ServicePath can be a query scope. Set by entity queries only.
 		Path           string
 		Query          *Query
+		ContentType    string // overrides Content-Type of the request when specified.
+		Accept         string // Accept header of the request when specified.
 		BodyToSend     interface{}
 		ReceivedBody   interface{}
 		ReceivedHeader http.Header
@@ -43,6 +56,7 @@ func NewAccessor(baseUrl string) *Accessor {
 		HttpClient:  new(http.Client),
 		BaseUrl:     baseUrl,
 		EntryPoints: nil,
+		BatchSize:   DefaultBatchSize,
 	}
 
 	// try to get EntryPoints. Ignore error since take it later when failed here.
@@ -51,14 +65,14 @@ func NewAccessor(baseUrl string) *Accessor {
 }
 
 // genBaseURL returns strings url instance included entry point.
-func (a *Accessor) genBaseURL(epID EntryPointID) (*url.URL, error) {
+func (a *Accessor) genBaseURL(ctx context.Context, epID EntryPointID) (*url.URL, error) {
 	u, err := url.Parse(a.BaseUrl)
 	if err != nil {
 		return nil, err
 	}
 
 	if a.EntryPoints == nil {
-		if err := a.ReloadEntryPoint(); err != nil {
+		if err := a.ReloadEntryPointCtx(ctx); err != nil {
 			return nil, err
 		}
 	}
@@ -72,6 +86,8 @@ func (a *Accessor) genBaseURL(epID EntryPointID) (*url.URL, error) {
 		u.Path = path.Join(u.Path, a.EntryPoints.SubscriptionsURL)
 	case Registrations:
 		u.Path = path.Join(u.Path, a.EntryPoints.RegistrationsURL)
+	case Operations:
+		u.Path = path.Join(u.Path, OperationsURL)
 	default:
 		return nil, IllegalEndPointIDError
 	}
@@ -79,8 +95,8 @@ func (a *Accessor) genBaseURL(epID EntryPointID) (*url.URL, error) {
 }
 
 // genURLString generates url string to make requests.
-func (a *Accessor) genURLString(epID EntryPointID, pathTo string, q *Query) (string, error) {
-	u, err := a.genBaseURL(epID)
+func (a *Accessor) genURLString(ctx context.Context, epID EntryPointID, pathTo string, q *Query) (string, error) {
+	u, err := a.genBaseURL(ctx, epID)
 	if err != nil {
 		return "", err
 	}
@@ -92,29 +108,53 @@ func (a *Accessor) genURLString(epID EntryPointID, pathTo string, q *Query) (str
 	return u.String(), nil
 }
 
+// do sends given request and returns its response. Returns context error when the request was canceled or expired.
+// The request is replayed once with refreshed credentials when it is rejected with 401, and retried on transient failures.
+func (a *Accessor) do(req *http.Request) (*http.Response, error) {
+	return common.Sender{Client: a.HttpClient, Credentials: a.Credentials, Retry: a.Retry, Breaker: a.Breaker, Middlewares: a.Middlewares, Logger: a.Logger}.Do(req)
+}
+
 func (a *Accessor) access(ap *AccessParameter) error {
+	ctx := ap.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	// Generate request
-	uri, err := a.genURLString(ap.EpID, ap.Path, ap.Query)
+	if ap.Query != nil {
+		if err := ap.Query.checkGeoQuery(); err != nil {
+			return err
+		}
+	}
+	uri, err := a.genURLString(ctx, ap.EpID, ap.Path, ap.Query)
 	if err != nil {
 		return err
 	}
-	req, err := gohttp.GenRequest(ap.Method, uri, ap.BodyToSend)
+	req, err := common.GenRequest(ctx, ap.Method, uri, ap.BodyToSend)
 	if err != nil {
 		return err
 	}
-	if err := common.AddServiceHeader(req, ap.Service, ap.ServicePath); err != nil {
+	addHeader := common.AddServiceHeader
+	if ap.Scoped {
+		addHeader = common.AddServiceScopeHeader
+	}
+	if err := addHeader(req, ap.Service, ap.ServicePath); err != nil {
 		return err
 	}
-
-	golog.Trace(fmt.Sprintf("url: %s\nbody:%+v", req.URL, req.Body))
+	if 0 < len(ap.ContentType) && ap.BodyToSend != nil {
+		req.Header.Set("Content-Type", ap.ContentType)
+	}
+	if 0 < len(ap.Accept) {
+		req.Header.Set("Accept", ap.Accept)
+	}
 
 	// Send request
-	res, err := a.HttpClient.Do(req)
+	res, err := a.do(req)
 	if err != nil {
 		return err
 	}
-	if http.StatusBadRequest <= res.StatusCode {
-		return fmt.Errorf(res.Status)
+	if err := checkResponse(res); err != nil {
+		return err
 	}
 
 	// set response header to return.
@@ -126,5 +166,26 @@ func (a *Accessor) access(ap *AccessParameter) error {
 	}
 
 	// Parse the response
+	if strings.HasPrefix(res.Header.Get("Content-Type"), ContentTypeText) {
+		return textResponseToParams(res, ap.ReceivedBody)
+	}
 	return gohttp.ResponseJSONToParams(res, ap.ReceivedBody)
 }
+
+// textResponseToParams decodes text/plain response (e.g. attribute value) into given instance.
+// Numbers, booleans and quoted strings are decoded as JSON, and bare text is set as it is when given instance is *string.
+func textResponseToParams(res *http.Response, params interface{}) error {
+	defer res.Body.Close()
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, params); err != nil {
+		s, ok := params.(*string)
+		if !ok {
+			return err
+		}
+		*s = string(b)
+	}
+	return nil
+}
@@ -1,9 +1,16 @@
 package orion_test
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/marrbor/go-fiware-api/common"
 	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,3 +23,57 @@ func TestNewAccessor(t *testing.T) {
 	assert.NotNil(t, a)
 	assert.EqualValues(t, a.BaseUrl, "localhost:8888")
 }
+
+func TestAccessor_Credentials(t *testing.T) {
+	var tokens []string
+	stub := oriontest.StubHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `[{"id":"Room1","type":"Room"}]`)
+	}))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get(common.AuthTokenHeader))
+		if r.Header.Get(common.AuthTokenHeader) != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		stub.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	a := orion.NewAccessor(ts.URL)
+	var es []map[string]interface{}
+	assert.Error(t, a.GetEntityList("", "", nil, &es))
+
+	a.Credentials = common.NewAuthToken("secret")
+	assert.NoError(t, a.GetEntityList("", "", nil, &es))
+	assert.Len(t, es, 1)
+	assert.EqualValues(t, []string{"", "", "secret", "secret"}, tokens)
+}
+
+func TestAccessor_Retry(t *testing.T) {
+	count := 0
+	ts := oriontest.NewStubServer(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		if count < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = fmt.Fprint(w, `[]`)
+	})
+	defer ts.Close()
+
+	a := orion.NewAccessor(ts.URL)
+	a.Retry = common.NewRetryPolicy()
+	a.Retry.InitialBackoff = time.Millisecond
+	var es []map[string]interface{}
+	assert.NoError(t, a.GetEntityList("", "", nil, &es))
+	assert.EqualValues(t, 3, count)
+
+	// breaker opens after consecutive failures and fails fast.
+	count = 0
+	a.Retry = nil
+	a.Breaker = common.NewCircuitBreaker(2, time.Minute)
+	assert.Error(t, a.GetEntityList("", "", nil, &es))
+	assert.Error(t, a.GetEntityList("", "", nil, &es))
+	assert.True(t, errors.Is(a.GetEntityList("", "", nil, &es), common.CircuitOpenError))
+	assert.EqualValues(t, 2, count)
+}
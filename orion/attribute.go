@@ -0,0 +1,165 @@
+// fiware orion entity attribute api
+// https://fiware.github.io/specifications/ngsiv2/stable/ #Attributes, #Attribute Value
+package orion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/marrbor/gohttp"
+)
+
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeText = "text/plain"
+
+	// Accept header for attribute value. Orion returns scalar value as text/plain, object and array as application/json.
+	AcceptAttributeValue = "application/json, text/plain"
+)
+
+// typeQuery returns query holds `type` when given type name is not empty, otherwise nil.
+func typeQuery(typeName string) *Query {
+	if len(typeName) <= 0 {
+		return nil
+	}
+	return NewQuery().SetQuery("type", typeName)
+}
+
+// isScalarValue returns whether given value is sent as text/plain (string, number, boolean or null) or not.
+func isScalarValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	if _, ok := value.(json.Number); ok {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// GetEntityAttributeValue gets value of specified attribute. Scalar value is received as text/plain and decoded into given value.
+func (a *Accessor) GetEntityAttributeValue(service, servicePath, id, attrName, typeName string, value interface{}) error {
+	return a.GetEntityAttributeValueCtx(context.Background(), service, servicePath, id, attrName, typeName, value)
+}
+
+// GetEntityAttributeValueCtx gets value of specified attribute with given context.
+func (a *Accessor) GetEntityAttributeValueCtx(ctx context.Context, service, servicePath, id, attrName, typeName string, value interface{}) error {
+	return a.access(&AccessParameter{
+		Ctx:          ctx,
+		EpID:         EntryPointIDs.Entities,
+		Method:       gohttp.HttpMethods.GET,
+		Scoped:       true,
+		Service:      service,
+		ServicePath:  servicePath,
+		Path:         fmt.Sprintf("/%s/attrs/%s/value", id, attrName),
+		Query:        typeQuery(typeName),
+		Accept:       AcceptAttributeValue,
+		BodyToSend:   nil,
+		ReceivedBody: value,
+	})
+}
+
+// ReplaceEntityAttributes replaces all attributes of specified entity with given attributes.
+func (a *Accessor) ReplaceEntityAttributes(service, servicePath, id, typeName string, attrs interface{}) error {
+	return a.ReplaceEntityAttributesCtx(context.Background(), service, servicePath, id, typeName, attrs)
+}
+
+// ReplaceEntityAttributesCtx replaces all attributes of specified entity with given context.
+func (a *Accessor) ReplaceEntityAttributesCtx(ctx context.Context, service, servicePath, id, typeName string, attrs interface{}) error {
+	return a.access(&AccessParameter{
+		Ctx:          ctx,
+		EpID:         EntryPointIDs.Entities,
+		Method:       gohttp.HttpMethods.PUT,
+		Service:      service,
+		ServicePath:  servicePath,
+		Path:         fmt.Sprintf("/%s/attrs", id),
+		Query:        typeQuery(typeName),
+		BodyToSend:   attrs,
+		ReceivedBody: nil,
+	})
+}
+
+// UpdateEntityAttributeData replaces type, value and metadata of specified attribute with given attribute.
+func (a *Accessor) UpdateEntityAttributeData(service, servicePath, id, attrName, typeName string, attr interface{}) error {
+	return a.UpdateEntityAttributeDataCtx(context.Background(), service, servicePath, id, attrName, typeName, attr)
+}
+
+// UpdateEntityAttributeDataCtx replaces specified attribute with given context.
+func (a *Accessor) UpdateEntityAttributeDataCtx(ctx context.Context, service, servicePath, id, attrName, typeName string, attr interface{}) error {
+	return a.access(&AccessParameter{
+		Ctx:          ctx,
+		EpID:         EntryPointIDs.Entities,
+		Method:       gohttp.HttpMethods.PUT,
+		Service:      service,
+		ServicePath:  servicePath,
+		Path:         fmt.Sprintf("/%s/attrs/%s", id, attrName),
+		Query:        typeQuery(typeName),
+		BodyToSend:   attr,
+		ReceivedBody: nil,
+	})
+}
+
+// UpdateEntityAttributeValue replaces value of specified attribute.
+// String, number, boolean and null are sent as text/plain, others (object, array) as application/json.
+func (a *Accessor) UpdateEntityAttributeValue(service, servicePath, id, attrName, typeName string, value interface{}) error {
+	return a.UpdateEntityAttributeValueCtx(context.Background(), service, servicePath, id, attrName, typeName, value)
+}
+
+// UpdateEntityAttributeValueCtx replaces value of specified attribute with given context.
+func (a *Accessor) UpdateEntityAttributeValueCtx(ctx context.Context, service, servicePath, id, attrName, typeName string, value interface{}) error {
+	ct := ContentTypeJSON
+	if isScalarValue(value) {
+		ct = ContentTypeText
+	}
+	body := value
+	if body == nil {
+		body = json.RawMessage("null") // send null as it is.
+	}
+	return a.access(&AccessParameter{
+		Ctx:          ctx,
+		EpID:         EntryPointIDs.Entities,
+		Method:       gohttp.HttpMethods.PUT,
+		Service:      service,
+		ServicePath:  servicePath,
+		Path:         fmt.Sprintf("/%s/attrs/%s/value", id, attrName),
+		Query:        typeQuery(typeName),
+		ContentType:  ct,
+		BodyToSend:   body,
+		ReceivedBody: nil,
+	})
+}
+
+// DeleteEntityAttribute removes specified attribute from the entity.
+func (a *Accessor) DeleteEntityAttribute(service, servicePath, id, attrName, typeName string) error {
+	return a.DeleteEntityAttributeCtx(context.Background(), service, servicePath, id, attrName, typeName)
+}
+
+// DeleteEntityAttributeCtx removes specified attribute from the entity with given context.
+func (a *Accessor) DeleteEntityAttributeCtx(ctx context.Context, service, servicePath, id, attrName, typeName string) error {
+	return a.access(&AccessParameter{
+		Ctx:          ctx,
+		EpID:         EntryPointIDs.Entities,
+		Method:       gohttp.HttpMethods.DELETE,
+		Service:      service,
+		ServicePath:  servicePath,
+		Path:         fmt.Sprintf("/%s/attrs/%s", id, attrName),
+		Query:        typeQuery(typeName),
+		BodyToSend:   nil,
+		ReceivedBody: nil,
+	})
+}
@@ -0,0 +1,161 @@
+package orion_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedRequest struct {
+	Method      string
+	Path        string
+	Query       string
+	ContentType string
+	Accept      string
+	Body        string
+}
+
+// newRecordServer returns test server that records requests and responds with given content type and body.
+func newRecordServer(rec *recordedRequest, contentType, body string) *httptest.Server {
+	return oriontest.NewStubServer(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		*rec = recordedRequest{
+			Method:      r.Method,
+			Path:        r.URL.Path,
+			Query:       r.URL.RawQuery,
+			ContentType: r.Header.Get("Content-Type"),
+			Accept:      r.Header.Get("Accept"),
+			Body:        string(b),
+		}
+		if len(body) <= 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		_, _ = fmt.Fprint(w, body)
+	})
+}
+
+func TestAccessor_ReplaceEntityAttributes(t *testing.T) {
+	var rec recordedRequest
+	ts := newRecordServer(&rec, "", "")
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	err := a.ReplaceEntityAttributes("", "", "Room1", "Room", map[string]interface{}{"temperature": map[string]interface{}{"value": 21}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.MethodPut, rec.Method)
+	assert.EqualValues(t, "/v2/entities/Room1/attrs", rec.Path)
+	assert.EqualValues(t, "type=Room", rec.Query)
+	assert.EqualValues(t, orion.ContentTypeJSON, rec.ContentType)
+	assert.JSONEq(t, `{"temperature":{"value":21}}`, rec.Body)
+}
+
+func TestAccessor_UpdateEntityAttributeData(t *testing.T) {
+	var rec recordedRequest
+	ts := newRecordServer(&rec, "", "")
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	err := a.UpdateEntityAttributeData("", "", "Room1", "temperature", "", map[string]interface{}{"type": "Number", "value": 25})
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.MethodPut, rec.Method)
+	assert.EqualValues(t, "/v2/entities/Room1/attrs/temperature", rec.Path)
+	assert.EqualValues(t, "", rec.Query)
+	assert.JSONEq(t, `{"type":"Number","value":25}`, rec.Body)
+}
+
+func TestAccessor_DeleteEntityAttribute(t *testing.T) {
+	var rec recordedRequest
+	ts := newRecordServer(&rec, "", "")
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	err := a.DeleteEntityAttribute("", "", "Room1", "temperature", "Room")
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.MethodDelete, rec.Method)
+	assert.EqualValues(t, "/v2/entities/Room1/attrs/temperature", rec.Path)
+	assert.EqualValues(t, "type=Room", rec.Query)
+}
+
+func TestAccessor_UpdateEntityAttributeValue(t *testing.T) {
+	var rec recordedRequest
+	ts := newRecordServer(&rec, "", "")
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	str := "on"
+	for _, tc := range []struct {
+		value       interface{}
+		contentType string
+		body        string
+	}{
+		{"on", orion.ContentTypeText, `"on"`},
+		{&str, orion.ContentTypeText, `"on"`},
+		{23.5, orion.ContentTypeText, `23.5`},
+		{42, orion.ContentTypeText, `42`},
+		{true, orion.ContentTypeText, `true`},
+		{nil, orion.ContentTypeText, `null`},
+		{map[string]int{"x": 1}, orion.ContentTypeJSON, `{"x":1}`},
+		{[]int{1, 2}, orion.ContentTypeJSON, `[1,2]`},
+	} {
+		err := a.UpdateEntityAttributeValue("", "", "Lamp1", "state", "", tc.value)
+		assert.NoError(t, err)
+		assert.EqualValues(t, http.MethodPut, rec.Method)
+		assert.EqualValues(t, "/v2/entities/Lamp1/attrs/state/value", rec.Path)
+		assert.EqualValues(t, tc.contentType, rec.ContentType)
+		assert.EqualValues(t, tc.body, rec.Body)
+	}
+}
+
+func TestAccessor_GetEntityAttributeValue(t *testing.T) {
+	var rec recordedRequest
+
+	ts := newRecordServer(&rec, orion.ContentTypeText, `23.5`)
+	a := orion.NewAccessor(ts.URL)
+	var f float64
+	err := a.GetEntityAttributeValue("", "", "Room1", "temperature", "Room", &f)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 23.5, f)
+	assert.EqualValues(t, http.MethodGet, rec.Method)
+	assert.EqualValues(t, "/v2/entities/Room1/attrs/temperature/value", rec.Path)
+	assert.EqualValues(t, orion.AcceptAttributeValue, rec.Accept)
+	ts.Close()
+
+	ts = newRecordServer(&rec, orion.ContentTypeText, `"on"`)
+	a = orion.NewAccessor(ts.URL)
+	var s string
+	err = a.GetEntityAttributeValue("", "", "Lamp1", "state", "", &s)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "on", s)
+	ts.Close()
+
+	ts = newRecordServer(&rec, orion.ContentTypeText, `off`)
+	a = orion.NewAccessor(ts.URL)
+	err = a.GetEntityAttributeValue("", "", "Lamp1", "state", "", &s)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "off", s)
+	ts.Close()
+
+	ts = newRecordServer(&rec, orion.ContentTypeText, `true`)
+	a = orion.NewAccessor(ts.URL)
+	var b bool
+	err = a.GetEntityAttributeValue("", "", "Lamp1", "enabled", "", &b)
+	assert.NoError(t, err)
+	assert.True(t, b)
+	ts.Close()
+
+	ts = newRecordServer(&rec, orion.ContentTypeJSON, `{"x":1,"y":2}`)
+	a = orion.NewAccessor(ts.URL)
+	var m map[string]int
+	err = a.GetEntityAttributeValue("", "", "Robot1", "position", "", &m)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, m["y"])
+	ts.Close()
+}
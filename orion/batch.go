@@ -0,0 +1,271 @@
+// fiware orion batch operation api
+// https://fiware.github.io/specifications/ngsiv2/stable/ #Batch Operations
+package orion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/marrbor/gohttp"
+)
+
+const (
+	BatchUpdatePath = "/update"
+
+	DefaultBatchSize       = 1000
+	MaxBatchPayloadSize    = 1024 * 1024 // Orion rejects a request payload larger than 1MB.
+	batchPayloadReserveLen = 64          // room for `{"actionType":"...","entities":[]}`
+)
+
+var (
+	NotSliceError          = fmt.Errorf("entities have to be a slice")
+	TooLargeEntityError    = fmt.Errorf("entity too large to send")
+	InvalidBatchSizeError  = fmt.Errorf("invalid batch size")
+	InvalidActionTypeError = fmt.Errorf("invalid action type")
+)
+
+// Action Type
+type (
+	ActionType struct{ value string }
+)
+
+// String returns action type strings.
+func (at ActionType) String() string {
+	return at.value
+}
+
+var (
+	// ActionTypes holds possible actionType value of batch update.
+	ActionTypes = struct {
+		Append       ActionType
+		AppendStrict ActionType
+		Update       ActionType
+		Delete       ActionType
+		Replace      ActionType
+	}{
+		Append:       ActionType{"append"},
+		AppendStrict: ActionType{"appendStrict"},
+		Update:       ActionType{"update"},
+		Delete:       ActionType{"delete"},
+		Replace:      ActionType{"replace"},
+	}
+)
+
+// ParseActionType returns ActionType that has given name.
+func ParseActionType(name string) (ActionType, error) {
+	for _, at := range []ActionType{ActionTypes.Append, ActionTypes.AppendStrict, ActionTypes.Update, ActionTypes.Delete, ActionTypes.Replace} {
+		if at.value == name {
+			return at, nil
+		}
+	}
+	return ActionType{}, InvalidActionTypeError
+}
+
+// BatchUpdateBody is a payload of POST /v2/op/update.
+type BatchUpdateBody struct {
+	ActionType string            `json:"actionType"`
+	Entities   []json.RawMessage `json:"entities"`
+}
+
+// BatchError is returned when a chunk of the batch update has failed.
+// Chunks before the failed one have been processed, chunks after it have not been sent.
+type BatchError struct {
+	Processed int      // number of entities processed successfully, including the ones applied in the failed chunk.
+	FailedIDs []string // IDs of the entities reported as failed. All entities of the failed chunk when Orion does not tell them.
+	UnsentIDs []string // IDs of the entities that have not been sent.
+	Err       error    // error of the failed chunk.
+}
+
+// Error returns error strings.
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch update failed after %d entities processed (failed: %s): %s", e.Processed, strings.Join(e.FailedIDs, ","), e.Err)
+}
+
+// Unwrap returns the error of the failed chunk.
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// batchEntity holds marshaled entity and its ID.
+type batchEntity struct {
+	ID   string
+	Type string
+	Body json.RawMessage
+}
+
+// toBatchEntities marshals each item of given slice.
+func toBatchEntities(entities interface{}) ([]batchEntity, error) {
+	v := reflect.ValueOf(entities)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, NotSliceError
+	}
+
+	ret := make([]batchEntity, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		b, err := json.Marshal(v.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		var id struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(b, &id); err != nil {
+			return nil, err
+		}
+		ret = append(ret, batchEntity{ID: id.ID, Type: id.Type, Body: b})
+	}
+	return ret, nil
+}
+
+// chunkBatchEntities splits given entities by the number of entities and the payload size.
+func chunkBatchEntities(entities []batchEntity, size int) ([][]batchEntity, error) {
+	chunks := make([][]batchEntity, 0)
+	chunk := make([]batchEntity, 0)
+	payload := batchPayloadReserveLen
+	for _, e := range entities {
+		l := len(e.Body) + 1 // with separator
+		if MaxBatchPayloadSize < batchPayloadReserveLen+l {
+			return nil, TooLargeEntityError
+		}
+		if size <= len(chunk) || MaxBatchPayloadSize < payload+l {
+			chunks = append(chunks, chunk)
+			chunk = make([]batchEntity, 0)
+			payload = batchPayloadReserveLen
+		}
+		chunk = append(chunk, e)
+		payload += l
+	}
+	if 0 < len(chunk) {
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
+// batchEntityIDs returns IDs of given entities.
+func batchEntityIDs(entities [][]batchEntity) []string {
+	ids := make([]string, 0)
+	for _, c := range entities {
+		for _, e := range c {
+			ids = append(ids, e.ID)
+		}
+	}
+	return ids
+}
+
+// partialUpdatePrefix precedes the list of failed entities in the description of PartialUpdate error such as
+// `do not exist: Room1 - [ temperature ], Room2/Room - [entity itself]`.
+const partialUpdatePrefix = "do not exist:"
+
+// parsePartialUpdate returns entities told in given PartialUpdate description. Each of them is `id` or `id/type`.
+func parsePartialUpdate(description string) []string {
+	i := strings.Index(description, partialUpdatePrefix)
+	if i < 0 {
+		return nil
+	}
+	s := description[i+len(partialUpdatePrefix):]
+	ret := make([]string, 0)
+	for {
+		s = strings.TrimLeft(s, " ,")
+		j := strings.Index(s, " - [")
+		if j < 0 {
+			return ret
+		}
+		ret = append(ret, strings.TrimSpace(s[:j]))
+		k := strings.Index(s[j:], "]")
+		if k < 0 {
+			return ret
+		}
+		s = s[j+k+1:]
+	}
+}
+
+// failedBatchEntityIDs returns IDs of the entities in the chunk that are told in the error description, and whether
+// they are told or not. Returns all IDs of the chunk when they are not told.
+func failedBatchEntityIDs(chunk []batchEntity, err error) ([]string, bool) {
+	all := batchEntityIDs([][]batchEntity{chunk})
+	ae := AsAPIError(err)
+	if ae == nil {
+		return all, false
+	}
+	told := make(map[string]bool)
+	for _, t := range parsePartialUpdate(ae.Description) {
+		told[t] = true
+	}
+	ids := make([]string, 0)
+	for _, e := range chunk {
+		if 0 < len(e.ID) && (told[e.ID] || told[e.ID+"/"+e.Type]) {
+			ids = append(ids, e.ID)
+		}
+	}
+	if len(ids) <= 0 {
+		return all, false
+	}
+	return ids, true
+}
+
+// BatchUpdate creates, updates, replaces or deletes given entities by POST /v2/op/update.
+// Entities are split into chunks by BatchSize of the accessor and the payload size limit of Orion.
+func (a *Accessor) BatchUpdate(service, servicePath string, actionType ActionType, entities interface{}) error {
+	return a.BatchUpdateCtx(context.Background(), service, servicePath, actionType, entities)
+}
+
+// BatchUpdateCtx is BatchUpdate with given context.
+func (a *Accessor) BatchUpdateCtx(ctx context.Context, service, servicePath string, actionType ActionType, entities interface{}) error {
+	size := a.BatchSize
+	if size == 0 {
+		size = DefaultBatchSize
+	}
+	if size < 0 {
+		return InvalidBatchSizeError
+	}
+
+	es, err := toBatchEntities(entities)
+	if err != nil {
+		return err
+	}
+	chunks, err := chunkBatchEntities(es, size)
+	if err != nil {
+		return err
+	}
+
+	processed := 0
+	for i, chunk := range chunks {
+		body := BatchUpdateBody{ActionType: actionType.value, Entities: make([]json.RawMessage, 0, len(chunk))}
+		for _, e := range chunk {
+			body.Entities = append(body.Entities, e.Body)
+		}
+		err := a.access(&AccessParameter{
+			Ctx:          ctx,
+			EpID:         EntryPointIDs.Operations,
+			Method:       gohttp.HttpMethods.POST,
+			Service:      service,
+			ServicePath:  servicePath,
+			Path:         BatchUpdatePath,
+			Query:        nil,
+			BodyToSend:   &body,
+			ReceivedBody: nil,
+		})
+		if err != nil {
+			failed, told := failedBatchEntityIDs(chunk, err)
+			if told {
+				// Orion applies the rest of the chunk on partial update.
+				processed += len(chunk) - len(failed)
+			}
+			return &BatchError{
+				Processed: processed,
+				FailedIDs: failed,
+				UnsentIDs: batchEntityIDs(chunks[i+1:]),
+				Err:       err,
+			}
+		}
+		processed += len(chunk)
+	}
+	return nil
+}
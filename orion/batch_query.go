@@ -0,0 +1,121 @@
+// fiware orion batch query api
+// https://fiware.github.io/specifications/ngsiv2/stable/ #Query operation
+package orion
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/marrbor/gohttp"
+)
+
+const (
+	BatchQueryPath = "/query"
+)
+
+type (
+	// BatchQueryEntity specifies entities to be retrieved. id is incompatible with idPattern, type is incompatible with typePattern.
+	BatchQueryEntity struct {
+		ID          string `json:"id,omitempty"`
+		IDPattern   string `json:"idPattern,omitempty"`
+		Type        string `json:"type,omitempty"`
+		TypePattern string `json:"typePattern,omitempty"`
+	}
+
+	// BatchQueryExpression holds filtering expression that have the same meaning as the query parameters of GET /v2/entities.
+	BatchQueryExpression struct {
+		Q        string `json:"q,omitempty"`
+		MQ       string `json:"mq,omitempty"`
+		Georel   string `json:"georel,omitempty"`
+		Geometry string `json:"geometry,omitempty"`
+		Coords   string `json:"coords,omitempty"`
+	}
+
+	// BatchQuery is a payload of POST /v2/op/query.
+	BatchQuery struct {
+		Entities   []BatchQueryEntity    `json:"entities,omitempty"`
+		Attrs      []string              `json:"attrs,omitempty"`
+		Expression *BatchQueryExpression `json:"expression,omitempty"`
+		Metadata   []string              `json:"metadata,omitempty"`
+	}
+)
+
+// Validate checks whether this query is acceptable or not.
+func (bq *BatchQuery) Validate() error {
+	for _, e := range bq.Entities {
+		if 0 < len(e.ID) && 0 < len(e.IDPattern) {
+			return IncompatibleQueryError
+		}
+		if 0 < len(e.Type) && 0 < len(e.TypePattern) {
+			return IncompatibleQueryError
+		}
+		for _, re := range []string{e.IDPattern, e.TypePattern} {
+			if _, err := regexp.Compile(re); err != nil {
+				return err
+			}
+		}
+	}
+	if ex := bq.Expression; ex != nil {
+		if err := checkGeoParams(ex.Georel, ex.Geometry, ex.Coords); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewBatchQuery returns new (empty) BatchQuery instance.
+func NewBatchQuery() *BatchQuery {
+	return &BatchQuery{}
+}
+
+// AddEntity adds given entity specification into this query.
+func (bq *BatchQuery) AddEntity(e BatchQueryEntity) *BatchQuery {
+	bq.Entities = append(bq.Entities, e)
+	return bq
+}
+
+// SetAttrs sets list of attributes to be included in the response.
+func (bq *BatchQuery) SetAttrs(attrs []string) *BatchQuery {
+	bq.Attrs = attrs
+	return bq
+}
+
+// SetMetadata sets list of metadata names to be included in the response.
+func (bq *BatchQuery) SetMetadata(md []string) *BatchQuery {
+	bq.Metadata = md
+	return bq
+}
+
+// SetExpression sets filtering expression.
+func (bq *BatchQuery) SetExpression(ex *BatchQueryExpression) *BatchQuery {
+	bq.Expression = ex
+	return bq
+}
+
+// BatchQuery retrieves entities matched with given query by POST /v2/op/query.
+// limit, offset, orderBy and options (count, keyValues, values, unique) of q are applied to the request.
+func (a *Accessor) BatchQuery(service, servicePath string, body *BatchQuery, q *Query, entities interface{}) error {
+	return a.BatchQueryCtx(context.Background(), service, servicePath, body, q, entities)
+}
+
+// BatchQueryCtx is BatchQuery with given context.
+func (a *Accessor) BatchQueryCtx(ctx context.Context, service, servicePath string, body *BatchQuery, q *Query, entities interface{}) error {
+	if body == nil {
+		body = NewBatchQuery()
+	}
+	if err := body.Validate(); err != nil {
+		return err
+	}
+	return a.access(&AccessParameter{
+		Ctx:          ctx,
+		EpID:         EntryPointIDs.Operations,
+		Method:       gohttp.HttpMethods.POST,
+		Scoped:       true,
+		Service:      service,
+		ServicePath:  servicePath,
+		Path:         BatchQueryPath,
+		Query:        q,
+		BodyToSend:   body,
+		ReceivedBody: entities,
+	})
+}
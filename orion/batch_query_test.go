@@ -0,0 +1,75 @@
+package orion_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
+	"github.com/marrbor/gohttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessor_BatchQuery(t *testing.T) {
+	ts := oriontest.NewStubServer(func(w http.ResponseWriter, r *http.Request) {
+		assert.EqualValues(t, "/v2/op/query", r.URL.Path)
+		assert.EqualValues(t, http.MethodPost, r.Method)
+		q := r.URL.Query()
+		assert.EqualValues(t, "10", q.Get("limit"))
+		assert.EqualValues(t, "20", q.Get("offset"))
+		assert.EqualValues(t, "!temperature", q.Get("orderBy"))
+		assert.EqualValues(t, "keyValues", q.Get("options"))
+
+		var body map[string]interface{}
+		assert.NoError(t, gohttp.RequestJSONToParams(r, &body))
+		b, _ := json.Marshal(body)
+		assert.JSONEq(t, `{
+			"entities":[{"id":"Room1","type":"Room"},{"idPattern":"^Room[2-5]","type":"Room"}],
+			"attrs":["temperature"],
+			"expression":{"q":"temperature>40","georel":"near;maxDistance:1000","geometry":"point","coords":"40.4,-3.5"},
+			"metadata":["accuracy"]
+		}`, string(b))
+		_, _ = fmt.Fprint(w, `[{"id":"Room1","type":"Room","temperature":45},{"id":"Room3","type":"Room","temperature":41}]`)
+	})
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	bq := orion.NewBatchQuery().
+		AddEntity(orion.BatchQueryEntity{ID: "Room1", Type: "Room"}).
+		AddEntity(orion.BatchQueryEntity{IDPattern: "^Room[2-5]", Type: "Room"}).
+		SetAttrs([]string{"temperature"}).
+		SetMetadata([]string{"accuracy"}).
+		SetExpression(&orion.BatchQueryExpression{Q: "temperature>40", Georel: "near;maxDistance:1000", Geometry: "point", Coords: "40.4,-3.5"})
+	q := orion.NewKeyValuesQuery().SetLimit(10).SetOffset(20).SetOrderBy([]string{"!temperature"})
+
+	var rooms []struct {
+		ID          string  `json:"id"`
+		Temperature float64 `json:"temperature"`
+	}
+	err := a.BatchQuery("", "", bq, q, &rooms)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(rooms))
+	assert.EqualValues(t, "Room3", rooms[1].ID)
+	assert.EqualValues(t, 41, rooms[1].Temperature)
+}
+
+func TestBatchQuery_Validate(t *testing.T) {
+	bq := orion.NewBatchQuery().AddEntity(orion.BatchQueryEntity{ID: "Room1", IDPattern: "^Room"})
+	assert.EqualError(t, bq.Validate(), orion.IncompatibleQueryError.Error())
+
+	bq = orion.NewBatchQuery().AddEntity(orion.BatchQueryEntity{Type: "Room", TypePattern: "^Ro"})
+	assert.EqualError(t, bq.Validate(), orion.IncompatibleQueryError.Error())
+
+	bq = orion.NewBatchQuery().AddEntity(orion.BatchQueryEntity{IDPattern: "^Room[", Type: "Room"})
+	assert.Error(t, bq.Validate())
+
+	// invalid query is never sent.
+	a := orion.NewAccessor("http://localhost:0")
+	err := a.BatchQuery("", "", bq, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, orion.AsAPIError(err))
+
+	assert.NoError(t, orion.NewBatchQuery().Validate())
+}
@@ -0,0 +1,147 @@
+package orion_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/ngsi"
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
+	"github.com/marrbor/gohttp"
+	"github.com/stretchr/testify/assert"
+)
+
+type batchRequest struct {
+	ActionType string        `json:"actionType"`
+	Entities   []pagedEntity `json:"entities"`
+}
+
+// newBatchServer returns test server that records batch requests. It fails the request holding given ID.
+func newBatchServer(t *testing.T, requests *[]batchRequest, failID string) *httptest.Server {
+	return oriontest.NewStubServer(func(w http.ResponseWriter, r *http.Request) {
+		assert.EqualValues(t, "/v2/op/update", r.URL.Path)
+		assert.EqualValues(t, http.MethodPost, r.Method)
+		assert.EqualValues(t, "tenant", r.Header.Get("Fiware-Service"))
+		var br batchRequest
+		assert.NoError(t, gohttp.RequestJSONToParams(r, &br))
+		*requests = append(*requests, br)
+		for _, e := range br.Entities {
+			if e.ID == failID {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = fmt.Fprintf(w, `{"error":"PartialUpdate","description":"do not exist: %s - [ temperature ]"}`, failID)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func genPagedEntities(n int) []pagedEntity {
+	es := make([]pagedEntity, 0)
+	for i := 0; i < n; i++ {
+		es = append(es, pagedEntity{ID: fmt.Sprintf("e%03d", i), Type: "T"})
+	}
+	return es
+}
+
+func TestAccessor_BatchUpdate(t *testing.T) {
+	var requests []batchRequest
+	ts := newBatchServer(t, &requests, "")
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+	a.BatchSize = 10
+
+	err := a.BatchUpdate("tenant", "/", orion.ActionTypes.Append, genPagedEntities(25))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, len(requests))
+	assert.EqualValues(t, "append", requests[0].ActionType)
+	assert.EqualValues(t, 10, len(requests[0].Entities))
+	assert.EqualValues(t, 10, len(requests[1].Entities))
+	assert.EqualValues(t, 5, len(requests[2].Entities))
+	assert.EqualValues(t, "e024", requests[2].Entities[4].ID)
+
+	// pointer to slice is also acceptable.
+	requests = requests[:0]
+	es := genPagedEntities(3)
+	err = a.BatchUpdate("tenant", "/", orion.ActionTypes.Delete, &es)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(requests))
+	assert.EqualValues(t, "delete", requests[0].ActionType)
+
+	err = a.BatchUpdate("tenant", "/", orion.ActionTypes.Update, pagedEntity{ID: "a"})
+	assert.EqualError(t, err, orion.NotSliceError.Error())
+}
+
+func TestAccessor_BatchUpdatePartialFailure(t *testing.T) {
+	var requests []batchRequest
+	ts := newBatchServer(t, &requests, "e013")
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+	a.BatchSize = 10
+
+	err := a.BatchUpdate("tenant", "/", orion.ActionTypes.Update, genPagedEntities(25))
+	assert.Error(t, err)
+	assert.EqualValues(t, 2, len(requests))
+	var be *orion.BatchError
+	assert.True(t, errors.As(err, &be))
+	assert.EqualValues(t, 19, be.Processed) // the others in the failed chunk are applied.
+	assert.EqualValues(t, []string{"e013"}, be.FailedIDs)
+	assert.EqualValues(t, 5, len(be.UnsentIDs))
+	assert.EqualValues(t, "e020", be.UnsentIDs[0])
+	assert.True(t, orion.IsNotFound(err))
+	assert.EqualValues(t, orion.ErrorCodePartialUpdate, orion.AsAPIError(err).Code)
+}
+
+func TestAccessor_BatchUpdateFailedIDs(t *testing.T) {
+	ts := oriontest.NewServer()
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+	assert.NoError(t, a.CreateEntity("", "", nil, ngsi.NewEntity("E1", "T").Set("A", ngsi.NewAttribute(ngsi.Number, 1))))
+
+	// the description tells E10 only, which contains E1.
+	es := []*ngsi.Entity{
+		ngsi.NewEntity("E1", "T").Set("A", ngsi.NewAttribute(ngsi.Number, 2)),
+		ngsi.NewEntity("E10", "T").Set("A", ngsi.NewAttribute(ngsi.Number, 2)),
+	}
+	err := a.BatchUpdate("", "", orion.ActionTypes.Update, es)
+	var be *orion.BatchError
+	assert.True(t, errors.As(err, &be))
+	assert.EqualValues(t, []string{"E10"}, be.FailedIDs)
+	assert.EqualValues(t, 1, be.Processed)
+	var v float64
+	assert.NoError(t, a.GetEntityAttributeValue("", "", "E1", "A", "", &v))
+	assert.EqualValues(t, 2, v)
+}
+
+func TestAccessor_BatchUpdatePayloadSize(t *testing.T) {
+	var requests []batchRequest
+	ts := newBatchServer(t, &requests, "")
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	// 3 entities around 400KB each can not be sent at once.
+	big := strings.Repeat("x", 400*1024)
+	es := make([]map[string]interface{}, 0)
+	for i := 0; i < 3; i++ {
+		es = append(es, map[string]interface{}{"id": fmt.Sprintf("e%d", i), "type": "T", "data": map[string]string{"value": big}})
+	}
+	err := a.BatchUpdate("tenant", "/", orion.ActionTypes.AppendStrict, es)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(requests))
+
+	huge := []map[string]interface{}{{"id": "e", "type": "T", "data": map[string]string{"value": strings.Repeat("x", orion.MaxBatchPayloadSize)}}}
+	err = a.BatchUpdate("tenant", "/", orion.ActionTypes.Replace, huge)
+	assert.EqualError(t, err, orion.TooLargeEntityError.Error())
+}
+
+func TestActionTypes(t *testing.T) {
+	assert.EqualValues(t, "append", orion.ActionTypes.Append.String())
+	assert.EqualValues(t, "appendStrict", orion.ActionTypes.AppendStrict.String())
+	assert.EqualValues(t, "update", orion.ActionTypes.Update.String())
+	assert.EqualValues(t, "delete", orion.ActionTypes.Delete.String())
+	assert.EqualValues(t, "replace", orion.ActionTypes.Replace.String())
+}
@@ -0,0 +1,68 @@
+package orion_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
+	"github.com/stretchr/testify/assert"
+)
+
+// newSlowServer returns test server that waits given duration before responding entity requests.
+func newSlowServer(wait time.Duration) *httptest.Server {
+	return oriontest.NewStubServer(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(wait):
+		case <-r.Context().Done():
+		}
+		_, _ = fmt.Fprint(w, `[]`)
+	})
+}
+
+func TestAccessor_Deadline(t *testing.T) {
+	ts := newSlowServer(time.Second)
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var es []map[string]interface{}
+	err := a.GetEntityListCtx(ctx, "", "", nil, &es)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestAccessor_Cancel(t *testing.T) {
+	ts := newSlowServer(time.Second)
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	var e map[string]interface{}
+	err := a.GetEntityCtx(ctx, "", "", "a", nil, &e)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	// already canceled context never reaches the server.
+	_, err = a.GetVersionCtx(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestAccessor_NoDeadline(t *testing.T) {
+	ts := newSlowServer(0)
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	var es []map[string]interface{}
+	err := a.GetEntityListCtx(context.Background(), "", "", nil, &es)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, len(es))
+}
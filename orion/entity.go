@@ -2,6 +2,7 @@
 package orion
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/marrbor/gohttp"
@@ -11,7 +12,13 @@ import (
 
 // CreateEntry create entity
 func (a *Accessor) CreateEntity(service, servicePath string, q *Query, entity interface{}) error {
+	return a.CreateEntityCtx(context.Background(), service, servicePath, q, entity)
+}
+
+// CreateEntityCtx is CreateEntity with given context.
+func (a *Accessor) CreateEntityCtx(ctx context.Context, service, servicePath string, q *Query, entity interface{}) error {
 	return a.access(&AccessParameter{
+		Ctx:          ctx,
 		EpID:         EntryPointIDs.Entities,
 		Method:       gohttp.HttpMethods.POST,
 		Service:      service,
@@ -27,9 +34,16 @@ func (a *Accessor) CreateEntity(service, servicePath string, q *Query, entity in
 
 // GetEntityList gets entity list.
 func (a *Accessor) GetEntityList(service, servicePath string, q *Query, entities interface{}) error {
+	return a.GetEntityListCtx(context.Background(), service, servicePath, q, entities)
+}
+
+// GetEntityListCtx gets entity list with given context.
+func (a *Accessor) GetEntityListCtx(ctx context.Context, service, servicePath string, q *Query, entities interface{}) error {
 	return a.access(&AccessParameter{
+		Ctx:          ctx,
 		EpID:         EntryPointIDs.Entities,
 		Method:       gohttp.HttpMethods.GET,
+		Scoped:       true,
 		Service:      service,
 		ServicePath:  servicePath,
 		Path:         "",
@@ -41,9 +55,16 @@ func (a *Accessor) GetEntityList(service, servicePath string, q *Query, entities
 
 // GetEntity gets entity that has specified ID.
 func (a *Accessor) GetEntity(service, servicePath, id string, q *Query, entity interface{}) error {
+	return a.GetEntityCtx(context.Background(), service, servicePath, id, q, entity)
+}
+
+// GetEntityCtx gets entity that has specified ID with given context.
+func (a *Accessor) GetEntityCtx(ctx context.Context, service, servicePath, id string, q *Query, entity interface{}) error {
 	return a.access(&AccessParameter{
+		Ctx:          ctx,
 		EpID:         EntryPointIDs.Entities,
 		Method:       gohttp.HttpMethods.GET,
+		Scoped:       true,
 		Service:      service,
 		ServicePath:  servicePath,
 		Path:         fmt.Sprintf("/%s", id),
@@ -55,9 +76,16 @@ func (a *Accessor) GetEntity(service, servicePath, id string, q *Query, entity i
 
 // GetEntityAttribute gets specified attribute of specified entity.
 func (a *Accessor) GetEntityAttribute(service, servicePath, id, attrName string, q *Query, attr interface{}) error {
+	return a.GetEntityAttributeCtx(context.Background(), service, servicePath, id, attrName, q, attr)
+}
+
+// GetEntityAttributeCtx gets specified attribute of specified entity with given context.
+func (a *Accessor) GetEntityAttributeCtx(ctx context.Context, service, servicePath, id, attrName string, q *Query, attr interface{}) error {
 	return a.access(&AccessParameter{
+		Ctx:          ctx,
 		EpID:         EntryPointIDs.Entities,
 		Method:       gohttp.HttpMethods.GET,
+		Scoped:       true,
 		Service:      service,
 		ServicePath:  servicePath,
 		Path:         fmt.Sprintf("/%s/attrs/%s", id, attrName),
@@ -71,7 +99,13 @@ func (a *Accessor) GetEntityAttribute(service, servicePath, id, attrName string,
 
 // UpdateEntity updates entity
 func (a *Accessor) UpdateEntity(service, servicePath, id, typeName string, param interface{}) error {
+	return a.UpdateEntityCtx(context.Background(), service, servicePath, id, typeName, param)
+}
+
+// UpdateEntityCtx updates entity with given context.
+func (a *Accessor) UpdateEntityCtx(ctx context.Context, service, servicePath, id, typeName string, param interface{}) error {
 	return a.access(&AccessParameter{
+		Ctx:          ctx,
 		EpID:         EntryPointIDs.Entities,
 		Method:       gohttp.HttpMethods.PATCH,
 		Service:      service,
@@ -85,7 +119,13 @@ func (a *Accessor) UpdateEntity(service, servicePath, id, typeName string, param
 
 // UpdateEntityAttribute updates or append entity attribute.
 func (a *Accessor) UpdateEntityAttribute(service, servicePath, id, attrName string, q *Query, attr interface{}) error {
+	return a.UpdateEntityAttributeCtx(context.Background(), service, servicePath, id, attrName, q, attr)
+}
+
+// UpdateEntityAttributeCtx updates or append entity attribute with given context.
+func (a *Accessor) UpdateEntityAttributeCtx(ctx context.Context, service, servicePath, id, attrName string, q *Query, attr interface{}) error {
 	return a.access(&AccessParameter{
+		Ctx:          ctx,
 		EpID:         EntryPointIDs.Entities,
 		Method:       gohttp.HttpMethods.POST,
 		Service:      service,
@@ -99,7 +139,13 @@ func (a *Accessor) UpdateEntityAttribute(service, servicePath, id, attrName stri
 
 /// Delete
 func (a *Accessor) DeleteEntity(service, servicePath, id, typeName string) error {
+	return a.DeleteEntityCtx(context.Background(), service, servicePath, id, typeName)
+}
+
+// DeleteEntityCtx is DeleteEntity with given context.
+func (a *Accessor) DeleteEntityCtx(ctx context.Context, service, servicePath, id, typeName string) error {
 	return a.access(&AccessParameter{
+		Ctx:          ctx,
 		EpID:         EntryPointIDs.Entities,
 		Method:       gohttp.HttpMethods.DELETE,
 		Service:      service,
@@ -0,0 +1,61 @@
+// entity api returns ngsi.Entity
+package orion
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/marrbor/go-fiware-api/ngsi"
+)
+
+// decodeNgsiEntity decodes given entity in the representation specified by the query.
+func decodeNgsiEntity(q *Query, raw json.RawMessage, e *ngsi.Entity) error {
+	if q != nil && q.HasOption(QueryOptions.KeyValues) {
+		return e.UnmarshalKeyValues(raw)
+	}
+	return e.UnmarshalNormalized(raw)
+}
+
+// GetNgsiEntityList gets entity list as ngsi.Entity. keyValues option of q is honoured.
+func (a *Accessor) GetNgsiEntityList(service, servicePath string, q *Query) ([]ngsi.Entity, error) {
+	return a.GetNgsiEntityListCtx(context.Background(), service, servicePath, q)
+}
+
+// GetNgsiEntityListCtx gets entity list as ngsi.Entity with given context.
+func (a *Accessor) GetNgsiEntityListCtx(ctx context.Context, service, servicePath string, q *Query) ([]ngsi.Entity, error) {
+	if q != nil && q.HasOption(QueryOptions.Values) {
+		return nil, IncompatibleQueryError
+	}
+	var raws []json.RawMessage
+	if err := a.GetEntityListCtx(ctx, service, servicePath, q, &raws); err != nil {
+		return nil, err
+	}
+	es := make([]ngsi.Entity, len(raws))
+	for i, raw := range raws {
+		if err := decodeNgsiEntity(q, raw, &es[i]); err != nil {
+			return nil, err
+		}
+	}
+	return es, nil
+}
+
+// GetNgsiEntity gets entity that has specified ID as ngsi.Entity. keyValues option of q is honoured.
+func (a *Accessor) GetNgsiEntity(service, servicePath, id string, q *Query) (*ngsi.Entity, error) {
+	return a.GetNgsiEntityCtx(context.Background(), service, servicePath, id, q)
+}
+
+// GetNgsiEntityCtx gets entity that has specified ID as ngsi.Entity with given context.
+func (a *Accessor) GetNgsiEntityCtx(ctx context.Context, service, servicePath, id string, q *Query) (*ngsi.Entity, error) {
+	if q != nil && q.HasOption(QueryOptions.Values) {
+		return nil, IncompatibleQueryError
+	}
+	var raw json.RawMessage
+	if err := a.GetEntityCtx(ctx, service, servicePath, id, q, &raw); err != nil {
+		return nil, err
+	}
+	var e ngsi.Entity
+	if err := decodeNgsiEntity(q, raw, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
@@ -0,0 +1,47 @@
+package orion_test
+
+import (
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/ngsi"
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessor_GetNgsiEntity(t *testing.T) {
+	var rec recordedRequest
+	ts := newRecordServer(&rec, orion.ContentTypeJSON, `{"id":"Room1","type":"Room","temperature":{"type":"Number","value":23.5,"metadata":{}},"name":{"type":"Text","value":"living","metadata":{}}}`)
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	e, err := a.GetNgsiEntity("", "", "Room1", nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "/v2/entities/Room1", rec.Path)
+	assert.EqualValues(t, "Room1", e.ID)
+	assert.EqualValues(t, []string{"temperature", "name"}, e.Names())
+	temp, ok := e.Get("temperature")
+	assert.True(t, ok)
+	assert.EqualValues(t, ngsi.Number, temp.Type)
+	assert.EqualValues(t, 23.5, temp.Value)
+
+	q := orion.NewQuery().SetOptions([]orion.Option{orion.QueryOptions.Values})
+	_, err = a.GetNgsiEntity("", "", "Room1", q)
+	assert.EqualError(t, err, orion.IncompatibleQueryError.Error())
+}
+
+func TestAccessor_GetNgsiEntityList(t *testing.T) {
+	var rec recordedRequest
+	ts := newRecordServer(&rec, orion.ContentTypeJSON, `[{"id":"Room1","type":"Room","temperature":23.5},{"id":"Room2","type":"Room","temperature":20,"tags":["a"]}]`)
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	es, err := a.GetNgsiEntityList("", "", orion.NewKeyValuesQuery())
+	assert.NoError(t, err)
+	assert.EqualValues(t, "/v2/entities", rec.Path)
+	assert.EqualValues(t, "options=keyValues", rec.Query)
+	assert.EqualValues(t, 2, len(es))
+	assert.EqualValues(t, "Room2", es[1].ID)
+	assert.EqualValues(t, []string{"temperature", "tags"}, es[1].Names())
+	tags, _ := es[1].Get("tags")
+	assert.EqualValues(t, ngsi.Array, tags.Type)
+}
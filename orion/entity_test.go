@@ -1,11 +1,11 @@
 package orion_test
 
 import (
-	"fmt"
 	"testing"
 
 	"github.com/marrbor/go-fiware-api/datamodel"
 	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
 	"github.com/marrbor/goutil"
 	"github.com/stretchr/testify/assert"
 )
@@ -50,7 +50,9 @@ type (
 )
 
 func TestAccessor_CRUDEntity(t *testing.T) {
-	a := orion.NewAccessor(fmt.Sprintf("http://%s:%d", "localhost", 1066))
+	ts := oriontest.NewServer()
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
 
 	// normalized request
 	n := CookBookNormalized{
@@ -0,0 +1,125 @@
+// fiware orion types api
+// https://fiware.github.io/specifications/ngsiv2/stable/ #Types
+package orion
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/marrbor/gohttp"
+)
+
+type (
+	// EntityTypeAttribute holds attribute types used by the entities of the type.
+	// Types is empty when noAttrDetail option is specified.
+	EntityTypeAttribute struct {
+		Types []string `json:"types"`
+	}
+
+	// EntityType holds attribute names, attribute types and the number of entities that belong to the type.
+	EntityType struct {
+		Type  string                         `json:"type,omitempty"` // not present in GET /v2/types/{type}
+		Attrs map[string]EntityTypeAttribute `json:"attrs"`
+		Count int                            `json:"count"`
+	}
+)
+
+// AttributeNames returns attribute names of this type in alphabetical order.
+func (et *EntityType) AttributeNames() []string {
+	names := make([]string, 0, len(et.Attrs))
+	for n := range et.Attrs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetEntityTypes gets entity types in the tenant. limit, offset and options (count, noAttrDetail) of q are applied to the request.
+func (a *Accessor) GetEntityTypes(service, servicePath string, q *Query) ([]EntityType, error) {
+	return a.GetEntityTypesCtx(context.Background(), service, servicePath, q)
+}
+
+// GetEntityTypesCtx gets entity types in the tenant with given context.
+func (a *Accessor) GetEntityTypesCtx(ctx context.Context, service, servicePath string, q *Query) ([]EntityType, error) {
+	if q != nil && q.HasOption(QueryOptions.Values) {
+		return nil, IncompatibleQueryError // use GetEntityTypeNames.
+	}
+	types := make([]EntityType, 0)
+	err := a.access(&AccessParameter{
+		Ctx:          ctx,
+		EpID:         EntryPointIDs.Types,
+		Method:       gohttp.HttpMethods.GET,
+		Scoped:       true,
+		Service:      service,
+		ServicePath:  servicePath,
+		Path:         "",
+		Query:        q,
+		BodyToSend:   nil,
+		ReceivedBody: &types,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+// GetEntityTypeNames gets entity type names in the tenant with `options=values`. limit and offset of q are applied to the request.
+func (a *Accessor) GetEntityTypeNames(service, servicePath string, q *Query) ([]string, error) {
+	return a.GetEntityTypeNamesCtx(context.Background(), service, servicePath, q)
+}
+
+// GetEntityTypeNamesCtx gets entity type names in the tenant with given context.
+func (a *Accessor) GetEntityTypeNamesCtx(ctx context.Context, service, servicePath string, q *Query) ([]string, error) {
+	if q == nil {
+		q = NewQuery()
+	} else {
+		q = q.Clone()
+	}
+	q.AddOptions([]Option{QueryOptions.Values})
+
+	names := make([]string, 0)
+	err := a.access(&AccessParameter{
+		Ctx:          ctx,
+		EpID:         EntryPointIDs.Types,
+		Method:       gohttp.HttpMethods.GET,
+		Scoped:       true,
+		Service:      service,
+		ServicePath:  servicePath,
+		Path:         "",
+		Query:        q,
+		BodyToSend:   nil,
+		ReceivedBody: &names,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// GetEntityType gets attribute names, attribute types and entity count of specified type.
+func (a *Accessor) GetEntityType(service, servicePath, typeName string) (*EntityType, error) {
+	return a.GetEntityTypeCtx(context.Background(), service, servicePath, typeName)
+}
+
+// GetEntityTypeCtx gets specified type with given context.
+func (a *Accessor) GetEntityTypeCtx(ctx context.Context, service, servicePath, typeName string) (*EntityType, error) {
+	var et EntityType
+	err := a.access(&AccessParameter{
+		Ctx:          ctx,
+		EpID:         EntryPointIDs.Types,
+		Method:       gohttp.HttpMethods.GET,
+		Scoped:       true,
+		Service:      service,
+		ServicePath:  servicePath,
+		Path:         fmt.Sprintf("/%s", typeName),
+		Query:        nil,
+		BodyToSend:   nil,
+		ReceivedBody: &et,
+	})
+	if err != nil {
+		return nil, err
+	}
+	et.Type = typeName
+	return &et, nil
+}
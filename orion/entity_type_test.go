@@ -0,0 +1,80 @@
+package orion_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTypesServer(t *testing.T) *httptest.Server {
+	return oriontest.NewStubServer(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/types":
+			switch r.URL.Query().Get("options") {
+			case "values":
+				_, _ = fmt.Fprint(w, `["Car","Room"]`)
+			case "noAttrDetail":
+				_, _ = fmt.Fprint(w, `[{"type":"Car","attrs":{"speed":{"types":[]}},"count":12}]`)
+			default:
+				assert.EqualValues(t, "1", r.URL.Query().Get("limit"))
+				_, _ = fmt.Fprint(w, `[{"type":"Car","attrs":{"speed":{"types":["Number"]},"fuel":{"types":["gasoline","diesel"]}},"count":12}]`)
+			}
+		case "/v2/types/Room":
+			_, _ = fmt.Fprint(w, `{"attrs":{"pressure":{"types":["Number"]},"temperature":{"types":["urn:phenomenum:temperature","Number"]}},"count":7}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"error":"NotFound","description":"Entity type not found"}`)
+		}
+	})
+}
+
+func TestAccessor_GetEntityTypes(t *testing.T) {
+	ts := newTypesServer(t)
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	types, err := a.GetEntityTypes("", "", orion.NewQuery().SetLimit(1))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(types))
+	assert.EqualValues(t, "Car", types[0].Type)
+	assert.EqualValues(t, 12, types[0].Count)
+	assert.EqualValues(t, []string{"fuel", "speed"}, types[0].AttributeNames())
+	assert.EqualValues(t, []string{"gasoline", "diesel"}, types[0].Attrs["fuel"].Types)
+
+	types, err = a.GetEntityTypes("", "", orion.NewQuery().SetOptions([]orion.Option{orion.QueryOptions.NoAttrDetail}))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, len(types[0].Attrs["speed"].Types))
+
+	_, err = a.GetEntityTypes("", "", orion.NewQuery().SetOptions([]orion.Option{orion.QueryOptions.Values}))
+	assert.EqualError(t, err, orion.IncompatibleQueryError.Error())
+}
+
+func TestAccessor_GetEntityTypeNames(t *testing.T) {
+	ts := newTypesServer(t)
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	names, err := a.GetEntityTypeNames("", "", nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"Car", "Room"}, names)
+}
+
+func TestAccessor_GetEntityType(t *testing.T) {
+	ts := newTypesServer(t)
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	et, err := a.GetEntityType("", "", "Room")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "Room", et.Type)
+	assert.EqualValues(t, 7, et.Count)
+	assert.EqualValues(t, []string{"pressure", "temperature"}, et.AttributeNames())
+
+	_, err = a.GetEntityType("", "", "Nothing")
+	assert.True(t, orion.IsNotFound(err))
+}
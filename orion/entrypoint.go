@@ -2,9 +2,10 @@
 package orion
 
 import (
+	"context"
 	"fmt"
-	"net/http"
 
+	"github.com/marrbor/go-fiware-api/common"
 	"github.com/marrbor/gohttp"
 )
 
@@ -14,8 +15,12 @@ const (
 	Types
 	Subscriptions
 	Registrations
+	Operations
 )
 
+// OperationsURL is an entry point for batch operations. It is not listed in the server response.
+const OperationsURL = "/v2/op"
+
 type EntryPointID struct{ value int }
 
 func (e EntryPointID) Value() int {
@@ -27,11 +32,13 @@ var EntryPointIDs = struct {
 	Types         EntryPointID
 	Subscriptions EntryPointID
 	Registrations EntryPointID
+	Operations    EntryPointID
 }{
 	Entities:      EntryPointID{Entities},
 	Types:         EntryPointID{Types},
 	Subscriptions: EntryPointID{Subscriptions},
 	Registrations: EntryPointID{Registrations},
+	Operations:    EntryPointID{Operations},
 }
 
 type (
@@ -45,16 +52,21 @@ type (
 
 // ReloadEntryPoint load entry point list from server.
 func (a *Accessor) ReloadEntryPoint() error {
-	req, err := gohttp.GenRequest(gohttp.HttpMethods.GET, fmt.Sprintf("%s/v2", a.BaseUrl), nil)
+	return a.ReloadEntryPointCtx(context.Background())
+}
+
+// ReloadEntryPointCtx load entry point list from server with given context.
+func (a *Accessor) ReloadEntryPointCtx(ctx context.Context) error {
+	req, err := common.GenRequest(ctx, gohttp.HttpMethods.GET, fmt.Sprintf("%s/v2", a.BaseUrl), nil)
 	if err != nil {
 		return err
 	}
-	res, err := a.HttpClient.Do(req)
+	res, err := a.do(req)
 	if err != nil {
 		return err
 	}
-	if http.StatusBadRequest <= res.StatusCode {
-		return fmt.Errorf(res.Status)
+	if err := checkResponse(res); err != nil {
+		return err
 	}
 	var ep EntryPoints
 	if err := gohttp.ResponseJSONToParams(res, &ep); err != nil {
@@ -66,7 +78,12 @@ func (a *Accessor) ReloadEntryPoint() error {
 
 // GetEntryPoints gets api resources from Orion.
 func (a *Accessor) GetEntryPoints() (*EntryPoints, error) {
-	if err := a.ReloadEntryPoint(); err != nil {
+	return a.GetEntryPointsCtx(context.Background())
+}
+
+// GetEntryPointsCtx gets api resources from Orion with given context.
+func (a *Accessor) GetEntryPointsCtx(ctx context.Context) (*EntryPoints, error) {
+	if err := a.ReloadEntryPointCtx(ctx); err != nil {
 		return nil, err
 	}
 	return a.EntryPoints, nil
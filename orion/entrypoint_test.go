@@ -1,31 +1,36 @@
 package orion_test
 
 import (
-	"fmt"
 	"testing"
 
 	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestAccessor_GetEntryPoints(t *testing.T) {
-	url := fmt.Sprintf("http://%s:%d", "localhost", 1026)
-	a := orion.NewAccessor(url)
-	assert.EqualValues(t, url, a.BaseUrl)
+	ts := oriontest.NewServer()
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+	assert.EqualValues(t, ts.URL, a.BaseUrl)
 	t.Logf("entrypoints: %+v", a.EntryPoints)
 
 	ep, err := a.GetEntryPoints()
 	assert.NoError(t, err)
+	assert.EqualValues(t, "/v2/entities", ep.EntitiesURL)
 	t.Logf("got entry points:%+v", ep)
 }
 
 // test for generate request error.
 func TestAccessor_GetEntryPoints2(t *testing.T) {
-	url := fmt.Sprintf("https://%s:%d", "localhost", 1026)
+	ts := oriontest.NewServer()
+	url := ts.URL
+	ts.Close()
 	a := orion.NewAccessor(url)
 	assert.EqualValues(t, a.BaseUrl, url)
 
 	// server not running.
 	_, err := a.GetEntryPoints()
-	assert.EqualError(t, err, "Get https://localhost:1026/v2: dial tcp [::1]:1026: connect: connection refused")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "connection refused")
 }
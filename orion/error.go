@@ -0,0 +1,79 @@
+// NGSIv2 error response
+// https://fiware.github.io/specifications/ngsiv2/stable/ #Error Responses
+package orion
+
+import (
+	"net/http"
+
+	"github.com/marrbor/go-fiware-api/common"
+)
+
+const (
+	CorrelatorHeader = common.CorrelatorHeader
+
+	// Error codes Orion sets into `error` field of the error payload.
+	ErrorCodeBadRequest            = "BadRequest"
+	ErrorCodeNotFound              = "NotFound"
+	ErrorCodeTooManyResults        = "TooManyResults"
+	ErrorCodeUnprocessable         = "Unprocessable"
+	ErrorCodePartialUpdate         = "PartialUpdate"
+	ErrorCodeMethodNotAllowed      = "MethodNotAllowed"
+	ErrorCodeRequestEntityTooLarge = "RequestEntityTooLarge"
+	ErrorCodeUnsupportedMediaType  = "UnsupportedMediaType"
+	ErrorCodeNotAcceptable         = "NotAcceptable"
+	ErrorCodeInternalServerError   = "InternalServerError"
+
+	// description Orion returns when creating an entity which has been already existing.
+	AlreadyExistsDescription = "Already Exists"
+)
+
+// APIError holds error response returned from Orion.
+type APIError = common.APIError
+
+// newAPIError generates APIError instance from given response. Body of the response is consumed and closed.
+func newAPIError(res *http.Response) *APIError {
+	return common.NewAPIError(res)
+}
+
+// checkResponse returns APIError when given response is an error response, otherwise nil.
+func checkResponse(res *http.Response) error {
+	if http.StatusBadRequest <= res.StatusCode {
+		return newAPIError(res)
+	}
+	return nil
+}
+
+// AsAPIError returns APIError held by given error or nil when it does not hold APIError.
+func AsAPIError(err error) *APIError {
+	return common.AsAPIError(err)
+}
+
+// IsNotFound returns whether given error means the resource has not been found or not.
+func IsNotFound(err error) bool {
+	e := AsAPIError(err)
+	return e != nil && e.StatusCode == http.StatusNotFound
+}
+
+// IsAlreadyExists returns whether given error means the entity has been already existing or not.
+func IsAlreadyExists(err error) bool {
+	e := AsAPIError(err)
+	return e != nil && e.StatusCode == http.StatusUnprocessableEntity && e.Description == AlreadyExistsDescription
+}
+
+// IsTooManyResults returns whether given error means there are too many results for the request (e.g. ambiguous entity id) or not.
+func IsTooManyResults(err error) bool {
+	e := AsAPIError(err)
+	return e != nil && (e.StatusCode == http.StatusConflict || e.Code == ErrorCodeTooManyResults)
+}
+
+// IsBadRequest returns whether given error means the request was malformed or not.
+func IsBadRequest(err error) bool {
+	e := AsAPIError(err)
+	return e != nil && e.StatusCode == http.StatusBadRequest
+}
+
+// IsUnprocessable returns whether given error means the request could not be processed (e.g. entity already exists) or not.
+func IsUnprocessable(err error) bool {
+	e := AsAPIError(err)
+	return e != nil && e.StatusCode == http.StatusUnprocessableEntity
+}
@@ -0,0 +1,89 @@
+package orion_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
+	"github.com/stretchr/testify/assert"
+)
+
+// newErrorServer returns test server that returns given status and body for any entity request.
+func newErrorServer(status int, body string) *httptest.Server {
+	return oriontest.NewStubServer(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(orion.CorrelatorHeader, "c0ffee")
+		w.WriteHeader(status)
+		_, _ = fmt.Fprint(w, body)
+	})
+}
+
+func TestAPIError_NotFound(t *testing.T) {
+	ts := newErrorServer(http.StatusNotFound, `{"error":"NotFound","description":"The requested entity has not been found. Check type and id"}`)
+	defer ts.Close()
+
+	a := orion.NewAccessor(ts.URL)
+	var e map[string]interface{}
+	err := a.GetEntity("", "", "nothing", nil, &e)
+	assert.Error(t, err)
+	assert.True(t, orion.IsNotFound(err))
+	assert.False(t, orion.IsBadRequest(err))
+
+	ae := orion.AsAPIError(err)
+	assert.NotNil(t, ae)
+	assert.EqualValues(t, http.StatusNotFound, ae.StatusCode)
+	assert.EqualValues(t, orion.ErrorCodeNotFound, ae.Code)
+	assert.EqualValues(t, "The requested entity has not been found. Check type and id", ae.Description)
+	assert.EqualValues(t, http.MethodGet, ae.Method)
+	assert.EqualValues(t, ts.URL+"/v2/entities/nothing", ae.URL)
+	assert.EqualValues(t, "c0ffee", ae.Correlator)
+	assert.EqualValues(t, "c0ffee", common.CorrelatorOf(err))
+	assert.Contains(t, err.Error(), "404 Not Found")
+}
+
+func TestAPIError_AlreadyExists(t *testing.T) {
+	ts := newErrorServer(http.StatusUnprocessableEntity, `{"error":"Unprocessable","description":"Already Exists"}`)
+	defer ts.Close()
+
+	a := orion.NewAccessor(ts.URL)
+	err := a.CreateEntity("", "", nil, map[string]string{"id": "a", "type": "T"})
+	assert.True(t, orion.IsAlreadyExists(err))
+	assert.True(t, orion.IsUnprocessable(err))
+	assert.False(t, orion.IsNotFound(err))
+}
+
+func TestAPIError_TooManyResults(t *testing.T) {
+	ts := newErrorServer(http.StatusConflict, `{"error":"TooManyResults","description":"More than one matching entity. Please refine your query"}`)
+	defer ts.Close()
+
+	a := orion.NewAccessor(ts.URL)
+	err := a.DeleteEntity("", "", "a", "")
+	assert.True(t, orion.IsTooManyResults(err))
+}
+
+func TestAPIError_BadRequest(t *testing.T) {
+	ts := newErrorServer(http.StatusBadRequest, `{"error":"BadRequest","description":"invalid character in URI parameter"}`)
+	defer ts.Close()
+
+	a := orion.NewAccessor(ts.URL)
+	err := a.GetEntityList("", "", nil, &[]map[string]interface{}{})
+	assert.True(t, orion.IsBadRequest(err))
+	assert.EqualValues(t, orion.ErrorCodeBadRequest, orion.AsAPIError(err).Code)
+}
+
+func TestAPIError_NotJSON(t *testing.T) {
+	ts := newErrorServer(http.StatusServiceUnavailable, "maintenance\n")
+	defer ts.Close()
+
+	a := orion.NewAccessor(ts.URL)
+	_, err := a.GetVersion()
+	ae := orion.AsAPIError(err)
+	assert.NotNil(t, ae)
+	assert.EqualValues(t, http.StatusServiceUnavailable, ae.StatusCode)
+	assert.EqualValues(t, "", ae.Code)
+	assert.EqualValues(t, "maintenance", ae.Description)
+	assert.Nil(t, orion.AsAPIError(fmt.Errorf("other")))
+}
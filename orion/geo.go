@@ -0,0 +1,314 @@
+// fiware orion geographical query
+// https://fiware.github.io/specifications/ngsiv2/stable/ #Geographical Queries
+package orion
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/marrbor/go-fiware-api/datamodel"
+	"github.com/marrbor/goutil"
+)
+
+const (
+	maxDistanceModifier = "maxDistance"
+	minDistanceModifier = "minDistance"
+)
+
+var (
+	InvalidGeoQueryError = fmt.Errorf("invalid geo query")
+)
+
+// Georel and Geometry
+type (
+	Georel   struct{ value string }
+	Geometry struct{ value string }
+)
+
+// String returns georel strings.
+func (g Georel) String() string {
+	return g.value
+}
+
+// String returns geometry strings.
+func (g Geometry) String() string {
+	return g.value
+}
+
+var (
+	// Georels holds possible spatial relationships.
+	Georels = struct {
+		Near       Georel
+		CoveredBy  Georel
+		Intersects Georel
+		Equals     Georel
+		Disjoint   Georel
+	}{
+		Near:       Georel{"near"},
+		CoveredBy:  Georel{"coveredBy"},
+		Intersects: Georel{"intersects"},
+		Equals:     Georel{"equals"},
+		Disjoint:   Georel{"disjoint"},
+	}
+
+	// Geometries holds possible reference shapes.
+	Geometries = struct {
+		Point   Geometry
+		Line    Geometry
+		Polygon Geometry
+		Box     Geometry
+	}{
+		Point:   Geometry{"point"},
+		Line:    Geometry{"line"},
+		Polygon: Geometry{"polygon"},
+		Box:     Geometry{"box"},
+	}
+)
+
+// GeoQuery holds georel, geometry and coords that restrict entities to be retrieved.
+type GeoQuery struct {
+	Georel      Georel
+	MaxDistance int // meters, only for near. 0 means not specified.
+	MinDistance int // meters, only for near. 0 means not specified.
+	Geometry    Geometry
+	Coords      []datamodel.LatLng
+}
+
+func invalidGeoQuery(format string, a ...interface{}) error {
+	return fmt.Errorf("%w: %s", InvalidGeoQueryError, fmt.Sprintf(format, a...))
+}
+
+// NewGeoQuery returns new GeoQuery instance.
+func NewGeoQuery(georel Georel, geometry Geometry, coords ...datamodel.LatLng) *GeoQuery {
+	return &GeoQuery{Georel: georel, Geometry: geometry, Coords: coords}
+}
+
+// NewNearQuery returns new GeoQuery instance that matches entities located within maxDistance meters from given point.
+func NewNearQuery(point datamodel.LatLng, maxDistance int) *GeoQuery {
+	return NewGeoQuery(Georels.Near, Geometries.Point, point).SetMaxDistance(maxDistance)
+}
+
+// NewGeoQueryFromPoint returns new GeoQuery instance whose reference shape is given GeoJSON point.
+func NewGeoQueryFromPoint(georel Georel, p *datamodel.Point) (*GeoQuery, error) {
+	if p == nil || (0 < len(p.Type) && p.Type != datamodel.TypePoint) {
+		return nil, datamodel.MismatchTypeError
+	}
+	ll, err := geoJsonToLatLng(p.Coordinates)
+	if err != nil {
+		return nil, err
+	}
+	return NewGeoQuery(georel, Geometries.Point, ll), nil
+}
+
+// NewGeoQueryFromPolygon returns new GeoQuery instance whose reference shape is the exterior ring of given GeoJSON polygon.
+func NewGeoQueryFromPolygon(georel Georel, p *datamodel.Polygon) (*GeoQuery, error) {
+	if p == nil || (0 < len(p.Type) && p.Type != datamodel.TypePolygon) {
+		return nil, datamodel.MismatchTypeError
+	}
+	if len(p.Coordinates) <= 0 {
+		return nil, invalidGeoQuery("polygon has no ring")
+	}
+	if 1 < len(p.Coordinates) {
+		return nil, invalidGeoQuery("polygon with holes is not supported")
+	}
+	var coords []datamodel.LatLng
+	for _, pos := range p.Coordinates[0] {
+		ll, err := geoJsonToLatLng(pos)
+		if err != nil {
+			return nil, err
+		}
+		coords = append(coords, ll)
+	}
+	return NewGeoQuery(georel, Geometries.Polygon, coords...), nil
+}
+
+// geoJsonToLatLng converts GeoJSON position ([longitude, latitude]) into LatLng.
+func geoJsonToLatLng(pos []float64) (datamodel.LatLng, error) {
+	if len(pos) < 2 {
+		return datamodel.LatLng{}, invalidGeoQuery("position has to hold longitude and latitude")
+	}
+	return datamodel.LatLng{Latitude: pos[1], Longitude: pos[0]}, nil
+}
+
+// SetMaxDistance sets maxDistance modifier of near.
+func (g *GeoQuery) SetMaxDistance(meters int) *GeoQuery {
+	g.MaxDistance = meters
+	return g
+}
+
+// SetMinDistance sets minDistance modifier of near.
+func (g *GeoQuery) SetMinDistance(meters int) *GeoQuery {
+	g.MinDistance = meters
+	return g
+}
+
+// Validate checks georel, geometry and the number of coordinates.
+func (g *GeoQuery) Validate() error {
+	if err := g.validateGeorel(); err != nil {
+		return err
+	}
+
+	n := len(g.Coords)
+	switch g.Geometry {
+	case Geometries.Point:
+		if n != 1 {
+			return invalidGeoQuery("point requires 1 coordinate but %d", n)
+		}
+	case Geometries.Line:
+		if n < 2 {
+			return invalidGeoQuery("line requires at least 2 coordinates but %d", n)
+		}
+	case Geometries.Polygon:
+		if n < 4 {
+			return invalidGeoQuery("polygon requires at least 4 coordinates but %d", n)
+		}
+		if g.Coords[0] != g.Coords[n-1] {
+			return invalidGeoQuery("polygon is not closed")
+		}
+	case Geometries.Box:
+		if n != 2 {
+			return invalidGeoQuery("box requires 2 coordinates but %d", n)
+		}
+	default:
+		return invalidGeoQuery("unknown geometry %q", g.Geometry)
+	}
+
+	if g.Georel == Georels.Near && g.Geometry != Geometries.Point {
+		return invalidGeoQuery("near requires point geometry")
+	}
+	for _, ll := range g.Coords {
+		if !goutil.IsValidLatitude(ll.Latitude) || !goutil.IsValidLongitude(ll.Longitude) {
+			return InvalidLatLngError
+		}
+	}
+	return nil
+}
+
+// validateGeorel checks georel and its modifiers.
+func (g *GeoQuery) validateGeorel() error {
+	switch g.Georel {
+	case Georels.Near:
+		if g.MaxDistance < 0 || g.MinDistance < 0 {
+			return invalidGeoQuery("negative distance")
+		}
+		if g.MaxDistance == 0 && g.MinDistance == 0 {
+			return invalidGeoQuery("near requires maxDistance or minDistance")
+		}
+		if 0 < g.MaxDistance && g.MaxDistance < g.MinDistance {
+			return invalidGeoQuery("minDistance is larger than maxDistance")
+		}
+	case Georels.CoveredBy, Georels.Intersects, Georels.Equals, Georels.Disjoint:
+		if g.MaxDistance != 0 || g.MinDistance != 0 {
+			return invalidGeoQuery("distance is only for near")
+		}
+	default:
+		return invalidGeoQuery("unknown georel %q", g.Georel)
+	}
+	return nil
+}
+
+// GeorelString returns georel parameter strings, e.g. near;maxDistance:1000.
+func (g *GeoQuery) GeorelString() string {
+	list := []string{g.Georel.value}
+	if 0 < g.MaxDistance {
+		list = append(list, fmt.Sprintf("%s:%d", maxDistanceModifier, g.MaxDistance))
+	}
+	if 0 < g.MinDistance {
+		list = append(list, fmt.Sprintf("%s:%d", minDistanceModifier, g.MinDistance))
+	}
+	return strings.Join(list, ";")
+}
+
+// CoordsString returns coords parameter strings, e.g. 41.390205,2.154007;48.8566,2.3522.
+func (g *GeoQuery) CoordsString() string {
+	list := make([]string, len(g.Coords))
+	for i := range g.Coords {
+		list[i] = g.Coords[i].String()
+	}
+	return strings.Join(list, ";")
+}
+
+// parseGeorel parses georel parameter strings.
+func parseGeorel(str string) (*GeoQuery, error) {
+	list := strings.Split(str, ";")
+	g := &GeoQuery{}
+	for _, gr := range []Georel{Georels.Near, Georels.CoveredBy, Georels.Intersects, Georels.Equals, Georels.Disjoint} {
+		if gr.value == list[0] {
+			g.Georel = gr
+		}
+	}
+	for _, m := range list[1:] {
+		kv := strings.SplitN(m, ":", 2)
+		if len(kv) != 2 {
+			return nil, invalidGeoQuery("invalid modifier %q", m)
+		}
+		d, err := strconv.Atoi(kv[1])
+		if err != nil {
+			return nil, invalidGeoQuery("invalid distance %q", m)
+		}
+		switch kv[0] {
+		case maxDistanceModifier:
+			g.MaxDistance = d
+		case minDistanceModifier:
+			g.MinDistance = d
+		default:
+			return nil, invalidGeoQuery("unknown modifier %q", m)
+		}
+	}
+	if err := g.validateGeorel(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// parseGeometry returns geometry that has given name.
+func parseGeometry(str string) (Geometry, error) {
+	for _, gm := range []Geometry{Geometries.Point, Geometries.Line, Geometries.Polygon, Geometries.Box} {
+		if gm.value == str {
+			return gm, nil
+		}
+	}
+	return Geometry{}, invalidGeoQuery("unknown geometry %q", str)
+}
+
+// checkGeoParams checks that georel, geometry and coords are specified all together.
+func checkGeoParams(georel, geometry, coords string) error {
+	if 0 < len(geometry) && len(georel) <= 0 {
+		return invalidGeoQuery("geometry requires georel")
+	}
+	if 0 < len(georel) && (len(geometry) <= 0 || len(coords) <= 0) {
+		return invalidGeoQuery("georel requires geometry and coords")
+	}
+	if 0 < len(coords) && len(geometry) <= 0 {
+		return invalidGeoQuery("coords requires geometry")
+	}
+	return nil
+}
+
+// SetGeoQuery validates given geo query and sets georel, geometry and coords into this instance.
+func (q *Query) SetGeoQuery(g *GeoQuery) error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+	q.SetQuery("georel", g.GeorelString())
+	q.SetQuery("geometry", g.Geometry.value)
+	q.SetQuery("coords", g.CoordsString())
+	return nil
+}
+
+// checkGeoQuery checks geographical query parameters of this instance.
+func (q *Query) checkGeoQuery() error {
+	return checkGeoParams(q.GetQuery("georel"), q.GetQuery("geometry"), q.GetQuery("coords"))
+}
+
+// SetGeoQuery validates given geo query and sets georel, geometry and coords.
+func (ex *BatchQueryExpression) SetGeoQuery(g *GeoQuery) error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+	ex.Georel = g.GeorelString()
+	ex.Geometry = g.Geometry.value
+	ex.Coords = g.CoordsString()
+	return nil
+}
@@ -0,0 +1,105 @@
+package orion_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/datamodel"
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	madrid    = datamodel.LatLng{Latitude: 40.4, Longitude: -3.7}
+	closedBox = []datamodel.LatLng{{Latitude: 40, Longitude: -4}, {Latitude: 41, Longitude: -4}, {Latitude: 41, Longitude: -3}, {Latitude: 40, Longitude: -4}}
+)
+
+func TestQuery_SetGeoQuery(t *testing.T) {
+	q := orion.NewQuery()
+	assert.NoError(t, q.SetGeoQuery(orion.NewNearQuery(madrid, 1000).SetMinDistance(10)))
+	assert.EqualValues(t, "near;maxDistance:1000;minDistance:10", q.GetQuery("georel"))
+	assert.EqualValues(t, "point", q.GetQuery("geometry"))
+	assert.EqualValues(t, "40.4,-3.7", q.GetQuery("coords"))
+
+	assert.NoError(t, q.SetGeoQuery(orion.NewGeoQuery(orion.Georels.CoveredBy, orion.Geometries.Polygon, closedBox...)))
+	assert.EqualValues(t, "coveredBy", q.GetQuery("georel"))
+	assert.EqualValues(t, "40,-4;41,-4;41,-3;40,-4", q.GetQuery("coords"))
+}
+
+func TestGeoQuery_Validate(t *testing.T) {
+	for i, g := range []*orion.GeoQuery{
+		orion.NewGeoQuery(orion.Georels.Near, orion.Geometries.Point, madrid),
+		orion.NewNearQuery(madrid, 10).SetMinDistance(100),
+		orion.NewNearQuery(madrid, -1),
+		orion.NewGeoQuery(orion.Georels.Near, orion.Geometries.Line, madrid, madrid).SetMaxDistance(10),
+		orion.NewGeoQuery(orion.Georels.Intersects, orion.Geometries.Point, madrid).SetMaxDistance(10),
+		orion.NewGeoQuery(orion.Georels.Equals, orion.Geometries.Point, madrid, madrid),
+		orion.NewGeoQuery(orion.Georels.Equals, orion.Geometries.Line, madrid),
+		orion.NewGeoQuery(orion.Georels.Disjoint, orion.Geometries.Polygon, closedBox[:3]...),
+		orion.NewGeoQuery(orion.Georels.Disjoint, orion.Geometries.Polygon, append(closedBox[:3:3], madrid)...),
+		orion.NewGeoQuery(orion.Georels.Disjoint, orion.Geometries.Box, madrid),
+		orion.NewGeoQuery(orion.Georel{}, orion.Geometries.Point, madrid),
+		orion.NewGeoQuery(orion.Georels.Equals, orion.Geometry{}, madrid),
+	} {
+		assert.True(t, errors.Is(g.Validate(), orion.InvalidGeoQueryError), i)
+	}
+
+	g := orion.NewGeoQuery(orion.Georels.Equals, orion.Geometries.Point, datamodel.LatLng{Latitude: 91})
+	assert.EqualError(t, g.Validate(), orion.InvalidLatLngError.Error())
+	g = orion.NewGeoQuery(orion.Georels.Intersects, orion.Geometries.Box, madrid, datamodel.LatLng{Latitude: 41, Longitude: -3})
+	assert.NoError(t, g.Validate())
+}
+
+func TestNewGeoQueryFromGeoJson(t *testing.T) {
+	g, err := orion.NewGeoQueryFromPoint(orion.Georels.Near, &datamodel.Point{Type: datamodel.TypePoint, Coordinates: []float64{-3.7, 40.4}})
+	assert.NoError(t, err)
+	g.SetMaxDistance(500)
+	assert.NoError(t, g.Validate())
+	assert.EqualValues(t, "40.4,-3.7", g.CoordsString())
+
+	_, err = orion.NewGeoQueryFromPoint(orion.Georels.Near, &datamodel.Point{Type: datamodel.TypePolygon})
+	assert.EqualError(t, err, datamodel.MismatchTypeError.Error())
+
+	p := datamodel.Polygon{Type: datamodel.TypePolygon, Coordinates: [][][]float64{{{-4, 40}, {-4, 41}, {-3, 41}, {-4, 40}}}}
+	g, err = orion.NewGeoQueryFromPolygon(orion.Georels.CoveredBy, &p)
+	assert.NoError(t, err)
+	assert.NoError(t, g.Validate())
+	assert.EqualValues(t, "40,-4;41,-4;41,-3;40,-4", g.CoordsString())
+}
+
+func TestQuery_GeoParams(t *testing.T) {
+	q := orion.NewQuery()
+	assert.NoError(t, q.SetGeorelQuery("near;maxDistance:1000"))
+	assert.True(t, errors.Is(q.SetGeorelQuery("near"), orion.InvalidGeoQueryError))
+	assert.True(t, errors.Is(q.SetGeorelQuery("nearby"), orion.InvalidGeoQueryError))
+	assert.True(t, errors.Is(q.SetGeorelQuery("near;maxDistance:x"), orion.InvalidGeoQueryError))
+	assert.NoError(t, q.SetGeometryQuery("point"))
+	assert.True(t, errors.Is(q.SetGeometryQuery("circle"), orion.InvalidGeoQueryError))
+
+	var rec recordedRequest
+	ts := newRecordServer(&rec, orion.ContentTypeJSON, `[]`)
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	// georel without coords fails before the request is sent.
+	var list []interface{}
+	err := a.GetEntityList("", "", q, &list)
+	assert.True(t, errors.Is(err, orion.InvalidGeoQueryError))
+	assert.EqualValues(t, "", rec.Method)
+
+	q2 := orion.NewQuery()
+	assert.NoError(t, q2.SetGeometryQuery("point"))
+	err = a.GetEntityList("", "", q2, &list)
+	assert.True(t, errors.Is(err, orion.InvalidGeoQueryError))
+
+	assert.NoError(t, q.SetCoordsQuery(&[]datamodel.LatLng{madrid}))
+	assert.NoError(t, a.GetEntityList("", "", q, &list))
+	assert.EqualValues(t, http.MethodGet, rec.Method)
+
+	bq := orion.NewBatchQuery().SetExpression(&orion.BatchQueryExpression{Geometry: "point"})
+	assert.True(t, errors.Is(bq.Validate(), orion.InvalidGeoQueryError))
+	var ex orion.BatchQueryExpression
+	assert.NoError(t, ex.SetGeoQuery(orion.NewNearQuery(madrid, 10)))
+	assert.NoError(t, orion.NewBatchQuery().SetExpression(&ex).Validate())
+}
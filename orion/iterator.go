@@ -0,0 +1,182 @@
+// paginated entity iteration
+package orion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/marrbor/gohttp"
+)
+
+const (
+	TotalCountHeader = "Fiware-Total-Count"
+
+	DefaultPageSize = 100
+	MaxPageSize     = 1000 // Orion caps the number of entities in a response.
+)
+
+var (
+	IteratorNotStartedError = fmt.Errorf("iterator has no current entity")
+	InvalidPageSizeError    = fmt.Errorf("invalid page size")
+)
+
+// EntityIterator iterates entities page by page with `options=count`.
+//
+//	it := a.IterateEntities(service, servicePath, q)
+//	for it.Next() {
+//		var e MyEntity
+//		if err := it.Decode(&e); err != nil { ... }
+//	}
+//	if err := it.Err(); err != nil { ... }
+type EntityIterator struct {
+	a           *Accessor
+	ctx         context.Context
+	service     string
+	servicePath string
+	q           *Query
+	pageSize    int
+	offset      int
+	total       int
+	page        []json.RawMessage
+	pos         int
+	fetched     bool
+	done        bool
+	err         error
+}
+
+// IterateEntities returns an iterator that retrieves entities matched with given query page by page.
+// `limit` of given query is replaced by page size and `offset` is used as the beginning of the iteration.
+func (a *Accessor) IterateEntities(service, servicePath string, q *Query) *EntityIterator {
+	return a.IterateEntitiesCtx(context.Background(), service, servicePath, q)
+}
+
+// IterateEntitiesCtx is IterateEntities with given context.
+func (a *Accessor) IterateEntitiesCtx(ctx context.Context, service, servicePath string, q *Query) *EntityIterator {
+	it := EntityIterator{
+		a:           a,
+		ctx:         ctx,
+		service:     service,
+		servicePath: servicePath,
+		pageSize:    DefaultPageSize,
+		total:       -1,
+		pos:         -1,
+	}
+	if q == nil {
+		it.q = NewQuery()
+	} else {
+		it.q = q.Clone()
+	}
+	if v := it.q.GetQuery("offset"); 0 < len(v) {
+		o, err := strconv.Atoi(v)
+		if err != nil || o < 0 {
+			it.err = fmt.Errorf("invalid offset: %s", v)
+		}
+		it.offset = o
+	}
+	it.q.AddOptions([]Option{QueryOptions.Count})
+	return &it
+}
+
+// SetPageSize sets the number of entities retrieved by one request. It have to be called before the first Next.
+func (it *EntityIterator) SetPageSize(size int) *EntityIterator {
+	if size <= 0 || MaxPageSize < size {
+		it.err = InvalidPageSizeError
+		return it
+	}
+	it.pageSize = size
+	return it
+}
+
+// Next advances the iterator to the next entity. It returns false when there is no more entity or an error occurred.
+func (it *EntityIterator) Next() bool {
+	if it.err != nil || it.done {
+		return false
+	}
+	it.pos++
+	if it.pos < len(it.page) {
+		return true
+	}
+
+	// current page has been consumed. is there a next page?
+	if it.fetched && (len(it.page) < it.pageSize || (0 <= it.total && it.total <= it.offset)) {
+		it.done = true
+		return false
+	}
+	if err := it.fetch(); err != nil {
+		it.err = err
+		return false
+	}
+	if len(it.page) <= 0 {
+		it.done = true
+		return false
+	}
+	it.pos = 0
+	return true
+}
+
+// fetch retrieves the next page.
+func (it *EntityIterator) fetch() error {
+	it.q.SetLimit(it.pageSize).SetOffset(it.offset)
+	var page []json.RawMessage
+	ap := AccessParameter{
+		Ctx:          it.ctx,
+		EpID:         EntryPointIDs.Entities,
+		Method:       gohttp.HttpMethods.GET,
+		Scoped:       true,
+		Service:      it.service,
+		ServicePath:  it.servicePath,
+		Path:         "",
+		Query:        it.q,
+		BodyToSend:   nil,
+		ReceivedBody: &page,
+	}
+	if err := it.a.access(&ap); err != nil {
+		return err
+	}
+	if tc := ap.ReceivedHeader.Get(TotalCountHeader); 0 < len(tc) {
+		total, err := strconv.Atoi(tc)
+		if err != nil {
+			return fmt.Errorf("invalid %s header: %s", TotalCountHeader, tc)
+		}
+		it.total = total
+	}
+	it.page = page
+	it.offset += len(page)
+	it.fetched = true
+	return nil
+}
+
+// Raw returns current entity as it is received.
+func (it *EntityIterator) Raw() json.RawMessage {
+	if it.pos < 0 || len(it.page) <= it.pos {
+		return nil
+	}
+	return it.page[it.pos]
+}
+
+// Decode decodes current entity into given instance.
+func (it *EntityIterator) Decode(entity interface{}) error {
+	raw := it.Raw()
+	if raw == nil {
+		return IteratorNotStartedError
+	}
+	return json.Unmarshal(raw, entity)
+}
+
+// Total returns the total number of entities reported by Fiware-Total-Count header. Returns -1 before the first page is retrieved.
+func (it *EntityIterator) Total() int {
+	return it.total
+}
+
+// Stop stops the iteration. Next returns false after Stop has been called.
+func (it *EntityIterator) Stop() {
+	it.done = true
+	it.page = nil
+}
+
+// Err returns the error occurred while the iteration.
+func (it *EntityIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,113 @@
+package orion_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
+	"github.com/stretchr/testify/assert"
+)
+
+type pagedEntity struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// newPagingServer returns test server that holds given number of entities and serves them with limit/offset.
+func newPagingServer(t *testing.T, n int, requests *[]string) *httptest.Server {
+	return oriontest.NewStubServer(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.RawQuery)
+		q := r.URL.Query()
+		assert.EqualValues(t, "keyValues,count", q.Get("options"))
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		es := make([]pagedEntity, 0)
+		for i := offset; i < n && i < offset+limit; i++ {
+			es = append(es, pagedEntity{ID: fmt.Sprintf("e%03d", i), Type: "T"})
+		}
+		w.Header().Set(orion.TotalCountHeader, strconv.Itoa(n))
+		_ = json.NewEncoder(w).Encode(es)
+	})
+}
+
+func TestAccessor_IterateEntities(t *testing.T) {
+	var requests []string
+	ts := newPagingServer(t, 25, &requests)
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	q := orion.NewKeyValuesQuery()
+	it := a.IterateEntities("", "", q).SetPageSize(10)
+	assert.EqualValues(t, -1, it.Total())
+	n := 0
+	for it.Next() {
+		var e pagedEntity
+		assert.NoError(t, it.Decode(&e))
+		assert.EqualValues(t, fmt.Sprintf("e%03d", n), e.ID)
+		n++
+	}
+	assert.NoError(t, it.Err())
+	assert.EqualValues(t, 25, n)
+	assert.EqualValues(t, 25, it.Total())
+	assert.EqualValues(t, 3, len(requests))
+	assert.False(t, it.Next())
+
+	// given query is not modified.
+	assert.False(t, q.IsExists("limit"))
+	assert.EqualValues(t, "keyValues", q.GetQuery("options"))
+}
+
+func TestAccessor_IterateEntitiesExactPage(t *testing.T) {
+	var requests []string
+	ts := newPagingServer(t, 20, &requests)
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	it := a.IterateEntities("", "", orion.NewKeyValuesQuery().SetOffset(5)).SetPageSize(5)
+	n := 0
+	for it.Next() {
+		n++
+	}
+	assert.NoError(t, it.Err())
+	assert.EqualValues(t, 15, n)
+	assert.EqualValues(t, 3, len(requests)) // total count stops the iteration without an extra request.
+}
+
+func TestAccessor_IterateEntitiesStop(t *testing.T) {
+	var requests []string
+	ts := newPagingServer(t, 25, &requests)
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	it := a.IterateEntities("", "", orion.NewKeyValuesQuery()).SetPageSize(10)
+	assert.EqualError(t, it.Decode(&pagedEntity{}), orion.IteratorNotStartedError.Error())
+	n := 0
+	for it.Next() {
+		n++
+		if n == 12 {
+			it.Stop()
+		}
+	}
+	assert.NoError(t, it.Err())
+	assert.EqualValues(t, 12, n)
+	assert.EqualValues(t, 2, len(requests))
+}
+
+func TestAccessor_IterateEntitiesError(t *testing.T) {
+	ts := newErrorServer(http.StatusBadRequest, `{"error":"BadRequest","description":"Invalid query"}`)
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	it := a.IterateEntities("", "", nil)
+	assert.False(t, it.Next())
+	assert.True(t, orion.IsBadRequest(it.Err()))
+
+	it = a.IterateEntities("", "", nil).SetPageSize(orion.MaxPageSize + 1)
+	assert.False(t, it.Next())
+	assert.EqualError(t, it.Err(), orion.InvalidPageSizeError.Error())
+}
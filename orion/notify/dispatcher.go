@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+var (
+	NoHandlerError = fmt.Errorf("no handler for the notification")
+)
+
+// HandlerFunc handles a notification.
+type HandlerFunc func(ctx context.Context, n *Notification) error
+
+// Dispatcher calls handlers registered per subscription ID or per entity type.
+// A handler for the subscription ID receives whole notification. Otherwise, data is split by entity type and
+// each handler for the type receives its part. Data which has no handler is passed to the default handler.
+type Dispatcher struct {
+	mu             sync.RWMutex
+	bySubscription map[string]HandlerFunc
+	byType         map[string]HandlerFunc
+	fallback       HandlerFunc
+}
+
+// NewDispatcher returns new Dispatcher instance that has no handlers.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		bySubscription: make(map[string]HandlerFunc),
+		byType:         make(map[string]HandlerFunc),
+	}
+}
+
+// HandleSubscription registers handler for specified subscription ID.
+func (d *Dispatcher) HandleSubscription(id string, h HandlerFunc) *Dispatcher {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.bySubscription[id] = h
+	return d
+}
+
+// HandleEntityType registers handler for specified entity type.
+func (d *Dispatcher) HandleEntityType(typeName string, h HandlerFunc) *Dispatcher {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.byType[typeName] = h
+	return d
+}
+
+// HandleDefault registers handler for notifications that no other handler matches.
+func (d *Dispatcher) HandleDefault(h HandlerFunc) *Dispatcher {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.fallback = h
+	return d
+}
+
+// route holds a handler and the data passed to it.
+type route struct {
+	handler HandlerFunc
+	data    []json.RawMessage
+}
+
+// routes returns handlers and the data for them in the order of the data.
+func (d *Dispatcher) routes(n *Notification) ([]*route, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if h, ok := d.bySubscription[n.SubscriptionID]; ok {
+		return []*route{{handler: h, data: n.Data}}, nil
+	}
+
+	var rs []*route
+	var fallback *route // data of unknown types are gathered to the default handler.
+	index := make(map[string]*route)
+	for _, raw := range n.Data {
+		t := n.entityType(raw)
+		r, ok := index[t]
+		if !ok {
+			if h, found := d.byType[t]; found {
+				r = &route{handler: h}
+				index[t] = r
+				rs = append(rs, r)
+			} else if d.fallback == nil {
+				return nil, NoHandlerError
+			} else {
+				if fallback == nil {
+					fallback = &route{handler: d.fallback}
+					rs = append(rs, fallback)
+				}
+				r = fallback
+			}
+		}
+		r.data = append(r.data, raw)
+	}
+	if len(rs) <= 0 {
+		if d.fallback == nil {
+			return nil, NoHandlerError
+		}
+		rs = append(rs, &route{handler: d.fallback, data: n.Data})
+	}
+	return rs, nil
+}
+
+// Dispatch passes given notification to registered handlers.
+// Returns NoHandlerError without calling any handler when some data have no handler.
+func (d *Dispatcher) Dispatch(ctx context.Context, n *Notification) error {
+	rs, err := d.routes(n)
+	if err != nil {
+		return err
+	}
+	for _, r := range rs {
+		sub := &Notification{SubscriptionID: n.SubscriptionID, Data: r.data, Format: n.Format}
+		if err := r.handler(ctx, sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
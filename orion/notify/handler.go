@@ -0,0 +1,88 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/marrbor/go-fiware-api/common"
+)
+
+const (
+	// DefaultMaxBodySize is a limit of notification payload. Orion does not send a payload larger than 1MB by default.
+	DefaultMaxBodySize = 1024 * 1024
+)
+
+// Handler is an http.Handler that receives notifications from Orion and passes them to the dispatcher.
+// It responds 204 when the handlers succeed, 400 for broken payloads, 404 when no handler matches and
+// 500 when a handler returns an error.
+type Handler struct {
+	Dispatcher  *Dispatcher
+	MaxBodySize int64
+}
+
+// NewHandler returns new Handler instance that uses given dispatcher.
+func NewHandler(d *Dispatcher) *Handler {
+	return &Handler{Dispatcher: d, MaxBodySize: DefaultMaxBodySize}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if ct := r.Header.Get("Content-Type"); 0 < len(ct) {
+		if mt, _, err := mime.ParseMediaType(ct); err != nil || mt != "application/json" {
+			http.Error(w, "content type has to be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+	}
+	format, err := ParseFormat(r.Header.Get(AttrsFormatHeader))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, status, err := h.decode(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	n.Format = format
+
+	ctx := common.ContextFromHeader(r.Context(), r.Header)
+	if err := h.Dispatcher.Dispatch(ctx, n); err != nil {
+		if errors.Is(err, NoHandlerError) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decode reads notification payload. Returns http status code for the error.
+func (h *Handler) decode(body io.Reader) (*Notification, int, error) {
+	limit := h.MaxBodySize
+	if limit <= 0 {
+		limit = DefaultMaxBodySize
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if limit < int64(len(b)) {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("payload too large")
+	}
+	n, err := decodeNotification(b)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	return n, http.StatusOK, nil
+}
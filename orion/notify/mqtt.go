@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/marrbor/go-fiware-api/common"
+)
+
+var (
+	ConsumerAlreadyStartedError = fmt.Errorf("consumer already started")
+	ConsumerNotStartedError     = fmt.Errorf("consumer not started")
+)
+
+// MQTTSubscriber is a client of an MQTT broker. The library does not depend on a specific MQTT client;
+// wrap one (e.g. eclipse paho) with this interface.
+type MQTTSubscriber interface {
+	Subscribe(topic string, qos byte, callback func(topic string, payload []byte)) error
+	Unsubscribe(topic string) error
+}
+
+// MQTTConsumer subscribes the topic that is specified in `mqtt` or `mqttCustom` notification of a subscription and
+// passes received notifications to the dispatcher.
+// MQTT messages have no headers, so attrsFormat, fiware-service and fiware-servicepath are given by the consumer.
+type MQTTConsumer struct {
+	Subscriber  MQTTSubscriber
+	Dispatcher  *Dispatcher
+	Topic       string
+	QoS         byte
+	Format      Format
+	Service     string
+	ServicePath string
+
+	// ErrorHandler is called when a message cannot be decoded or handlers return an error. Can be nil.
+	ErrorHandler func(topic string, err error)
+
+	mu      sync.Mutex
+	ctx     context.Context
+	started bool
+}
+
+// NewMQTTConsumer returns new MQTTConsumer instance that receives normalized notifications on given topic with QoS 0.
+func NewMQTTConsumer(s MQTTSubscriber, d *Dispatcher, topic string) *MQTTConsumer {
+	return &MQTTConsumer{Subscriber: s, Dispatcher: d, Topic: topic, Format: Formats.Normalized}
+}
+
+// Start subscribes the topic. Given context is passed to handlers, including ones of retained messages that the
+// subscriber delivers while subscribing.
+func (c *MQTTConsumer) Start(ctx context.Context) error {
+	c.mu.Lock()
+	if c.started {
+		c.mu.Unlock()
+		return ConsumerAlreadyStartedError
+	}
+	c.ctx = ctx
+	c.started = true
+	c.mu.Unlock()
+
+	if err := c.Subscriber.Subscribe(c.Topic, c.QoS, c.onMessage); err != nil {
+		c.mu.Lock()
+		c.ctx = nil
+		c.started = false
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Stop unsubscribes the topic. The consumer keeps started when unsubscribing failed.
+func (c *MQTTConsumer) Stop() error {
+	c.mu.Lock()
+	if !c.started {
+		c.mu.Unlock()
+		return ConsumerNotStartedError
+	}
+	topic := c.Topic
+	c.mu.Unlock()
+
+	// the subscriber may wait for in-flight callbacks, which take the lock.
+	if err := c.Subscriber.Unsubscribe(topic); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.ctx = nil
+	c.started = false
+	c.mu.Unlock()
+	return nil
+}
+
+// onMessage is a callback for the subscriber.
+func (c *MQTTConsumer) onMessage(topic string, payload []byte) {
+	c.mu.Lock()
+	ctx := c.ctx
+	c.mu.Unlock()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := c.HandleMessage(ctx, payload); err != nil && c.ErrorHandler != nil {
+		c.ErrorHandler(topic, err)
+	}
+}
+
+// HandleMessage decodes given MQTT payload and passes it to the dispatcher.
+func (c *MQTTConsumer) HandleMessage(ctx context.Context, payload []byte) error {
+	n, err := decodeNotification(payload)
+	if err != nil {
+		return err
+	}
+	n.Format = c.Format
+	return c.Dispatcher.Dispatch(common.WithFiwareHeaders(ctx, c.Service, c.ServicePath, ""), n)
+}
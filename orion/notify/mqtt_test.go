@@ -0,0 +1,153 @@
+package notify_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/orion/notify"
+	"github.com/stretchr/testify/assert"
+)
+
+// broker is an in-process stand-in of MQTT broker.
+type broker struct {
+	mu             sync.Mutex
+	subs           map[string]func(topic string, payload []byte)
+	retained       map[string]string
+	inFlight       map[string]string // delivered while unsubscribing.
+	unsubscribeErr error
+}
+
+func newBroker() *broker {
+	return &broker{subs: make(map[string]func(string, []byte)), retained: make(map[string]string), inFlight: make(map[string]string)}
+}
+
+// Subscribe delivers the retained message of the topic before returning, like MQTT clients do.
+func (b *broker) Subscribe(topic string, qos byte, callback func(topic string, payload []byte)) error {
+	b.mu.Lock()
+	if 2 < qos {
+		b.mu.Unlock()
+		return fmt.Errorf("invalid qos")
+	}
+	b.subs[topic] = callback
+	payload, ok := b.retained[topic]
+	b.mu.Unlock()
+	if ok {
+		callback(topic, []byte(payload))
+	}
+	return nil
+}
+
+// Unsubscribe waits for the in-flight message of the topic before returning, like MQTT clients do.
+func (b *broker) Unsubscribe(topic string) error {
+	b.mu.Lock()
+	if b.unsubscribeErr != nil {
+		b.mu.Unlock()
+		return b.unsubscribeErr
+	}
+	cb := b.subs[topic]
+	payload, ok := b.inFlight[topic]
+	delete(b.subs, topic)
+	b.mu.Unlock()
+	if ok && cb != nil {
+		cb(topic, []byte(payload))
+	}
+	return nil
+}
+
+// publish delivers payload to the subscriber of the topic. Returns false when nobody subscribes it.
+func (b *broker) publish(topic string, payload string) bool {
+	b.mu.Lock()
+	cb, ok := b.subs[topic]
+	b.mu.Unlock()
+	if ok {
+		cb(topic, []byte(payload))
+	}
+	return ok
+}
+
+type ctxKey struct{}
+
+func TestMQTTConsumer(t *testing.T) {
+	var ids []string
+	var service, servicePath string
+	var fromStart interface{}
+	d := notify.NewDispatcher().HandleEntityType("Room", func(ctx context.Context, n *notify.Notification) error {
+		es, err := n.Entities()
+		if err != nil {
+			return err
+		}
+		for _, e := range es {
+			ids = append(ids, e.ID)
+		}
+		service, servicePath = notify.Service(ctx), notify.ServicePath(ctx)
+		fromStart = ctx.Value(ctxKey{})
+		return nil
+	})
+
+	b := newBroker()
+	c := notify.NewMQTTConsumer(b, d, "/orion/rooms")
+	c.Format = notify.Formats.KeyValues
+	c.Service = "openiot"
+	c.ServicePath = "/floor1"
+	var errs []error
+	c.ErrorHandler = func(topic string, err error) {
+		errs = append(errs, err)
+	}
+
+	assert.NoError(t, c.Start(context.WithValue(context.Background(), ctxKey{}, "started")))
+	assert.EqualError(t, c.Start(context.Background()), notify.ConsumerAlreadyStartedError.Error())
+
+	assert.True(t, b.publish("/orion/rooms", `{"subscriptionId":"sub1","data":[{"id":"Room1","type":"Room","temperature":23}]}`))
+	assert.EqualValues(t, []string{"Room1"}, ids)
+	assert.EqualValues(t, "openiot", service)
+	assert.EqualValues(t, "/floor1", servicePath)
+	assert.EqualValues(t, "started", fromStart)
+	assert.EqualValues(t, 0, len(errs))
+
+	// errors are reported to the error handler.
+	assert.True(t, b.publish("/orion/rooms", `{"subscriptionId":"sub1","data":[{"id":"Car1","type":"Car"}]}`))
+	assert.True(t, b.publish("/orion/rooms", `{"data":[]}`))
+	assert.EqualValues(t, 2, len(errs))
+	assert.EqualError(t, errs[0], notify.NoHandlerError.Error())
+	assert.EqualError(t, errs[1], notify.NoSubscriptionIDError.Error())
+
+	assert.NoError(t, c.Stop())
+	assert.False(t, b.publish("/orion/rooms", `{"subscriptionId":"sub1","data":[]}`))
+	assert.EqualError(t, c.Stop(), notify.ConsumerNotStartedError.Error())
+}
+
+func TestMQTTConsumer_Start(t *testing.T) {
+	var fromStart []interface{}
+	d := notify.NewDispatcher().HandleEntityType("Room", func(ctx context.Context, n *notify.Notification) error {
+		fromStart = append(fromStart, ctx.Value(ctxKey{}))
+		return nil
+	})
+	b := newBroker()
+	b.retained["/orion/rooms"] = `{"subscriptionId":"sub1","data":[{"id":"Room1","type":"Room"}]}`
+	c := notify.NewMQTTConsumer(b, d, "/orion/rooms")
+
+	// failed subscription leaves the consumer stopped.
+	c.QoS = 3
+	assert.Error(t, c.Start(context.WithValue(context.Background(), ctxKey{}, "failed")))
+	assert.EqualError(t, c.Stop(), notify.ConsumerNotStartedError.Error())
+
+	// retained message delivered while subscribing gets the context of Start.
+	c.QoS = 1
+	assert.NoError(t, c.Start(context.WithValue(context.Background(), ctxKey{}, "started")))
+	assert.EqualValues(t, []interface{}{"started"}, fromStart)
+
+	// failed unsubscription leaves the consumer started.
+	b.unsubscribeErr = fmt.Errorf("not connected")
+	assert.EqualError(t, c.Stop(), "not connected")
+	assert.True(t, b.publish("/orion/rooms", `{"subscriptionId":"sub1","data":[{"id":"Room1","type":"Room"}]}`))
+	assert.EqualValues(t, []interface{}{"started", "started"}, fromStart)
+
+	// in-flight message is handled while unsubscribing.
+	b.unsubscribeErr = nil
+	b.inFlight["/orion/rooms"] = `{"subscriptionId":"sub1","data":[{"id":"Room1","type":"Room"}]}`
+	assert.NoError(t, c.Stop())
+	assert.EqualValues(t, []interface{}{"started", "started", "started"}, fromStart)
+	assert.EqualError(t, c.Stop(), notify.ConsumerNotStartedError.Error())
+}
@@ -0,0 +1,157 @@
+// fiware orion subscription notification
+// https://fiware.github.io/specifications/ngsiv2/stable/ #Notification Messages
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/go-fiware-api/ngsi"
+)
+
+const (
+	AttrsFormatHeader = "Ngsiv2-AttrsFormat"
+)
+
+var (
+	UnsupportedFormatError = fmt.Errorf("unsupported notification format")
+	NoSubscriptionIDError  = fmt.Errorf("no subscriptionId")
+)
+
+// Format
+type (
+	Format struct{ value string }
+)
+
+// String returns attrsFormat strings.
+func (f Format) String() string {
+	return f.value
+}
+
+var (
+	// Formats holds possible attrsFormat of notification.
+	Formats = struct {
+		Normalized Format
+		KeyValues  Format
+		Values     Format
+	}{
+		Normalized: Format{"normalized"},
+		KeyValues:  Format{"keyValues"},
+		Values:     Format{"values"},
+	}
+)
+
+// ParseFormat returns Format that has given name. Empty name means normalized.
+func ParseFormat(name string) (Format, error) {
+	switch name {
+	case "", Formats.Normalized.value:
+		return Formats.Normalized, nil
+	case Formats.KeyValues.value:
+		return Formats.KeyValues, nil
+	case Formats.Values.value:
+		return Formats.Values, nil
+	}
+	return Format{}, UnsupportedFormatError
+}
+
+// Notification is a payload Orion sends to the subscriber.
+// Each item of Data is an entity (normalized or keyValues) or an array of attribute values (values).
+type Notification struct {
+	SubscriptionID string            `json:"subscriptionId"`
+	Data           []json.RawMessage `json:"data"`
+	Format         Format            `json:"-"`
+}
+
+// decodeNotification decodes notification payload.
+func decodeNotification(b []byte) (*Notification, error) {
+	var n Notification
+	if err := json.Unmarshal(b, &n); err != nil {
+		return nil, err
+	}
+	if len(n.SubscriptionID) <= 0 {
+		return nil, NoSubscriptionIDError
+	}
+	return &n, nil
+}
+
+// Decode decodes data into given slice pointer such as *[]MyEntity.
+func (n *Notification) Decode(v interface{}) error {
+	b, err := json.Marshal(n.Data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// Entities returns data as ngsi.Entity list. It is not available for values format.
+func (n *Notification) Entities() ([]ngsi.Entity, error) {
+	es := make([]ngsi.Entity, len(n.Data))
+	for i, raw := range n.Data {
+		var err error
+		switch n.Format {
+		case Formats.Normalized:
+			err = es[i].UnmarshalNormalized(raw)
+		case Formats.KeyValues:
+			err = es[i].UnmarshalKeyValues(raw)
+		default:
+			err = UnsupportedFormatError
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return es, nil
+}
+
+// Values returns data of values format. Each item holds attribute values in the order of the subscription.
+func (n *Notification) Values() ([][]interface{}, error) {
+	if n.Format != Formats.Values {
+		return nil, UnsupportedFormatError
+	}
+	var vs [][]interface{}
+	if err := n.Decode(&vs); err != nil {
+		return nil, err
+	}
+	return vs, nil
+}
+
+// entityType returns type of given data item. Returns empty string for values format.
+func (n *Notification) entityType(raw json.RawMessage) string {
+	if n.Format == Formats.Values {
+		return ""
+	}
+	var e struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &e); err != nil {
+		return ""
+	}
+	return e.Type
+}
+
+// Service returns fiware-service of the notification.
+func Service(ctx context.Context) string {
+	return common.ServiceFromContext(ctx)
+}
+
+// ServicePath returns fiware-servicepath of the notification.
+func ServicePath(ctx context.Context) string {
+	return common.ServicePathFromContext(ctx)
+}
+
+// Correlator returns fiware-correlator of the notification.
+func Correlator(ctx context.Context) string {
+	return common.CorrelatorFromContext(ctx)
+}
+
+// TraceParent returns W3C traceparent of the notification.
+func TraceParent(ctx context.Context) string {
+	return common.TraceParentFromContext(ctx)
+}
+
+// TraceState returns W3C tracestate of the notification.
+func TraceState(ctx context.Context) string {
+	return common.TraceStateFromContext(ctx)
+}
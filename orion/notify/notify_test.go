@@ -0,0 +1,140 @@
+package notify_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/ngsi"
+	"github.com/marrbor/go-fiware-api/orion/notify"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	normalizedPayload = `{"subscriptionId":"sub1","data":[` +
+		`{"id":"Room1","type":"Room","temperature":{"type":"Number","value":23,"metadata":{}}},` +
+		`{"id":"Car1","type":"Car","speed":{"type":"Number","value":98,"metadata":{}}},` +
+		`{"id":"Room2","type":"Room","temperature":{"type":"Number","value":21,"metadata":{}}}]}`
+)
+
+// post sends given payload to the handler and returns the response.
+func post(h http.Handler, header map[string]string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_DispatchByEntityType(t *testing.T) {
+	var rooms, others []string
+	var service, servicePath, correlator, traceParent, traceState string
+	d := notify.NewDispatcher().
+		HandleEntityType("Room", func(ctx context.Context, n *notify.Notification) error {
+			es, err := n.Entities()
+			if err != nil {
+				return err
+			}
+			for _, e := range es {
+				rooms = append(rooms, e.ID)
+			}
+			service, servicePath, correlator = notify.Service(ctx), notify.ServicePath(ctx), notify.Correlator(ctx)
+			traceParent, traceState = notify.TraceParent(ctx), notify.TraceState(ctx)
+			return nil
+		}).
+		HandleDefault(func(ctx context.Context, n *notify.Notification) error {
+			var es []struct{ ID string }
+			if err := n.Decode(&es); err != nil {
+				return err
+			}
+			for _, e := range es {
+				others = append(others, e.ID)
+			}
+			return nil
+		})
+
+	w := post(notify.NewHandler(d), map[string]string{
+		"Fiware-Service":     "openiot",
+		"Fiware-ServicePath": "/floor1",
+		"Fiware-Correlator":  "abc-123",
+		"traceparent":        "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"tracestate":         "vendor=x",
+	}, normalizedPayload)
+	assert.EqualValues(t, http.StatusNoContent, w.Code)
+	assert.EqualValues(t, []string{"Room1", "Room2"}, rooms)
+	assert.EqualValues(t, []string{"Car1"}, others)
+	assert.EqualValues(t, "openiot", service)
+	assert.EqualValues(t, "/floor1", servicePath)
+	assert.EqualValues(t, "abc-123", correlator)
+	assert.EqualValues(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", traceParent)
+	assert.EqualValues(t, "vendor=x", traceState)
+}
+
+func TestHandler_DispatchBySubscription(t *testing.T) {
+	var got *notify.Notification
+	d := notify.NewDispatcher().
+		HandleSubscription("sub1", func(ctx context.Context, n *notify.Notification) error {
+			got = n
+			return nil
+		}).
+		HandleEntityType("Room", func(ctx context.Context, n *notify.Notification) error {
+			return fmt.Errorf("should not be called")
+		})
+
+	w := post(notify.NewHandler(d), map[string]string{notify.AttrsFormatHeader: "keyValues"},
+		`{"subscriptionId":"sub1","data":[{"id":"Room1","type":"Room","temperature":23}]}`)
+	assert.EqualValues(t, http.StatusNoContent, w.Code)
+	assert.EqualValues(t, notify.Formats.KeyValues, got.Format)
+	es, err := got.Entities()
+	assert.NoError(t, err)
+	temp, ok := es[0].Get("temperature")
+	assert.True(t, ok)
+	assert.EqualValues(t, ngsi.Number, temp.Type)
+
+	_, err = got.Values()
+	assert.EqualError(t, err, notify.UnsupportedFormatError.Error())
+}
+
+func TestHandler_Values(t *testing.T) {
+	var got [][]interface{}
+	d := notify.NewDispatcher().HandleDefault(func(ctx context.Context, n *notify.Notification) error {
+		var err error
+		got, err = n.Values()
+		return err
+	})
+	w := post(notify.NewHandler(d), map[string]string{notify.AttrsFormatHeader: "values"},
+		`{"subscriptionId":"sub2","data":[[23,"on"],[21,"off"]]}`)
+	assert.EqualValues(t, http.StatusNoContent, w.Code)
+	assert.EqualValues(t, [][]interface{}{{float64(23), "on"}, {float64(21), "off"}}, got)
+}
+
+func TestHandler_Errors(t *testing.T) {
+	d := notify.NewDispatcher().HandleEntityType("Room", func(ctx context.Context, n *notify.Notification) error {
+		return fmt.Errorf("failed")
+	})
+	h := notify.NewHandler(d)
+
+	// no handler for Car.
+	assert.EqualValues(t, http.StatusNotFound, post(h, nil, normalizedPayload).Code)
+	// handler error.
+	assert.EqualValues(t, http.StatusInternalServerError, post(h, nil, `{"subscriptionId":"s","data":[{"id":"Room1","type":"Room"}]}`).Code)
+	// broken payloads.
+	assert.EqualValues(t, http.StatusBadRequest, post(h, nil, `{"subscriptionId":`).Code)
+	assert.EqualValues(t, http.StatusBadRequest, post(h, nil, `{"data":[]}`).Code)
+	assert.EqualValues(t, http.StatusBadRequest, post(h, map[string]string{notify.AttrsFormatHeader: "legacy"}, normalizedPayload).Code)
+	assert.EqualValues(t, http.StatusUnsupportedMediaType, post(h, map[string]string{"Content-Type": "text/plain"}, normalizedPayload).Code)
+
+	h.MaxBodySize = 10
+	assert.EqualValues(t, http.StatusRequestEntityTooLarge, post(h, nil, normalizedPayload).Code)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/notify", nil))
+	assert.EqualValues(t, http.StatusMethodNotAllowed, w.Code)
+	assert.EqualValues(t, http.MethodPost, w.Header().Get("Allow"))
+}
@@ -0,0 +1,206 @@
+package oriontest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/marrbor/go-fiware-api/ngsi"
+	"github.com/marrbor/go-fiware-api/orion"
+)
+
+// serveBatchUpdate serves POST /v2/op/update. All entities are processed and failed ones are reported together.
+func (b *Broker) serveBatchUpdate(w http.ResponseWriter, r *request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	o := parseOptions(r.URL.Query())
+	body, err := readBody(r)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	var bu orion.BatchUpdateBody
+	if err := json.Unmarshal(body, &bu); err != nil {
+		writeError(w, http.StatusBadRequest, "ParseError", err.Error())
+		return
+	}
+	at, err := orion.ParseActionType(bu.ActionType)
+	if err != nil {
+		badRequest(w, fmt.Sprintf("invalid update action type: /%s/", bu.ActionType))
+		return
+	}
+	sp, err := r.writePath()
+	if err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := b.tenant(r.service)
+
+	var failures []string
+	notFound := 0
+	for _, raw := range bu.Entities {
+		ne, err := decodeEntity(raw, o["keyValues"])
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		if len(ne.ID) <= 0 {
+			badRequest(w, "entity id length: 0, min length supported: 1")
+			return
+		}
+		if missing := b.batchUpdate(r, t, at, ne, sp, o["forcedUpdate"]); 0 < len(missing) {
+			if missing[0] == "entity itself" {
+				notFound++
+			}
+			failures = append(failures, fmt.Sprintf("%s - [ %s ]", ne.ID, strings.Join(missing, ", ")))
+		}
+	}
+
+	switch {
+	case len(failures) <= 0:
+		w.WriteHeader(http.StatusNoContent)
+	case notFound == len(bu.Entities):
+		writeHTTPError(w, newNotFound())
+	default:
+		writeError(w, http.StatusUnprocessableEntity, orion.ErrorCodePartialUpdate, "do not exist: "+strings.Join(failures, ", "))
+	}
+}
+
+// batchUpdate applies given action to the entity. Returns names of the attributes that cannot be processed,
+// `entity itself` when the entity does not exist.
+func (b *Broker) batchUpdate(r *request, t *tenant, at orion.ActionType, ne *ngsi.Entity, sp string, forced bool) []string {
+	e, err := t.find(ne.ID, ne.Type, sp)
+	if err != nil {
+		if at != orion.ActionTypes.Append && at != orion.ActionTypes.AppendStrict {
+			return []string{"entity itself"}
+		}
+		if len(ne.Type) <= 0 {
+			ne.Type = DefaultEntityType
+		}
+		e = &entity{Entity: *ne, servicePath: sp}
+		t.entities = append(t.entities, e)
+		b.notify(r, t, e, e.Names(), true)
+		return nil
+	}
+
+	var missing, changed []string
+	switch at {
+	case orion.ActionTypes.Append:
+		changed = setAttrs(&e.Entity, ne, false)
+	case orion.ActionTypes.AppendStrict:
+		for _, n := range ne.Names() {
+			if _, ok := e.Get(n); ok {
+				missing = append(missing, n)
+				ne.Delete(n)
+			}
+		}
+		changed = setAttrs(&e.Entity, ne, false)
+	case orion.ActionTypes.Update:
+		for _, n := range ne.Names() {
+			if _, ok := e.Get(n); !ok {
+				missing = append(missing, n)
+				ne.Delete(n)
+			}
+		}
+		changed = setAttrs(&e.Entity, ne, true)
+	case orion.ActionTypes.Replace:
+		for _, n := range e.Names() {
+			if _, ok := ne.Get(n); !ok {
+				e.Delete(n)
+				changed = append(changed, n)
+			}
+		}
+		changed = append(changed, setAttrs(&e.Entity, ne, false)...)
+	case orion.ActionTypes.Delete:
+		if ne.Len() <= 0 {
+			t.remove(e)
+			return nil
+		}
+		for _, n := range ne.Names() {
+			if _, ok := e.Get(n); !ok {
+				missing = append(missing, n)
+				continue
+			}
+			e.Delete(n)
+		}
+		return missing
+	}
+	b.notify(r, t, e, changed, forced)
+	return missing
+}
+
+// serveBatchQuery serves POST /v2/op/query.
+func (b *Broker) serveBatchQuery(w http.ResponseWriter, r *request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	var bq orion.BatchQuery
+	if err := json.Unmarshal(body, &bq); err != nil {
+		writeError(w, http.StatusBadRequest, "ParseError", err.Error())
+		return
+	}
+	if err := bq.Validate(); err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+
+	// each item of entities and the expression are applied as the query parameters of GET /v2/entities.
+	var filters []*entityFilter
+	items := bq.Entities
+	if len(items) <= 0 {
+		items = []orion.BatchQueryEntity{{IDPattern: ".*"}}
+	}
+	for _, item := range items {
+		q := url.Values{
+			"id": {item.ID}, "idPattern": {item.IDPattern}, "type": {item.Type}, "typePattern": {item.TypePattern},
+		}
+		if ex := bq.Expression; ex != nil {
+			q["q"], q["mq"] = []string{ex.Q}, []string{ex.MQ}
+			q["georel"], q["geometry"], q["coords"] = []string{ex.Georel}, []string{ex.Geometry}, []string{ex.Coords}
+		}
+		f, err := newEntityFilter(q, r.readScope())
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		filters = append(filters, f)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := b.tenant(r.service)
+
+	var es []*entity
+	for _, e := range t.entities {
+		for _, f := range filters {
+			if f.match(e) {
+				es = append(es, e)
+				break
+			}
+		}
+	}
+	q := r.URL.Query()
+	orderEntities(es, q.Get("orderBy"))
+	from, to, err := page(w, q, len(es))
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	if 0 < len(bq.Attrs) {
+		q.Set("attrs", strings.Join(bq.Attrs, ","))
+	}
+	writeEntities(w, q, es[from:to])
+}
@@ -0,0 +1,365 @@
+package oriontest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/marrbor/go-fiware-api/ngsi"
+	"github.com/marrbor/go-fiware-api/orion"
+)
+
+const (
+	// DefaultEntityType is a type of entities created without type.
+	DefaultEntityType = "Thing"
+
+	notFoundDescription = "The requested entity has not been found. Check type and id"
+)
+
+// entity is an entity stored in the broker.
+type entity struct {
+	ngsi.Entity
+	servicePath string
+}
+
+// options holds `options` parameter of the request.
+type options map[string]bool
+
+func parseOptions(q url.Values) options {
+	o := options{}
+	for _, s := range strings.Split(q.Get("options"), ",") {
+		if s = strings.TrimSpace(s); 0 < len(s) {
+			o[s] = true
+		}
+	}
+	return o
+}
+
+// httpError is an error with status and NGSIv2 error code.
+type httpError struct {
+	status      int
+	code        string
+	description string
+}
+
+func (e *httpError) Error() string {
+	return e.description
+}
+
+func newBadRequest(format string, a ...interface{}) *httpError {
+	return &httpError{status: http.StatusBadRequest, code: orion.ErrorCodeBadRequest, description: fmt.Sprintf(format, a...)}
+}
+
+func newNotFound() *httpError {
+	return &httpError{status: http.StatusNotFound, code: orion.ErrorCodeNotFound, description: notFoundDescription}
+}
+
+func writeHTTPError(w http.ResponseWriter, err error) {
+	if he, ok := err.(*httpError); ok {
+		writeError(w, he.status, he.code, he.description)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, orion.ErrorCodeInternalServerError, err.Error())
+}
+
+// readBody reads JSON payload of the request.
+func readBody(r *request) ([]byte, error) {
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, newBadRequest("%s", err)
+	}
+	if len(b) <= 0 {
+		return nil, &httpError{status: http.StatusBadRequest, code: "ParseError", description: "Errors found in incoming JSON buffer"}
+	}
+	return b, nil
+}
+
+// decodeEntity decodes given payload in normalized or keyValues representation. Attribute types are completed.
+func decodeEntity(b []byte, keyValues bool) (*ngsi.Entity, error) {
+	var e ngsi.Entity
+	var err error
+	if keyValues {
+		err = e.UnmarshalKeyValues(b)
+	} else {
+		err = e.UnmarshalNormalized(b)
+	}
+	if err != nil {
+		return nil, &httpError{status: http.StatusBadRequest, code: "ParseError", description: err.Error()}
+	}
+	for _, n := range e.Names() {
+		a, _ := e.Get(n)
+		e.Set(n, completeType(a))
+	}
+	return &e, nil
+}
+
+// completeType sets default type of the attribute when it is not specified.
+func completeType(a ngsi.Attribute) ngsi.Attribute {
+	if 0 < len(a.Type) {
+		return a
+	}
+	switch a.Value.(type) {
+	case nil:
+		a.Type = ngsi.None
+	case float64:
+		a.Type = ngsi.Number
+	case string:
+		a.Type = ngsi.Text
+	case bool:
+		a.Type = ngsi.Boolean
+	case []interface{}:
+		a.Type = ngsi.Array
+	default:
+		a.Type = ngsi.StructuredValue
+	}
+	return a
+}
+
+// entityFilter selects entities.
+type entityFilter struct {
+	ids         []string
+	types       []string
+	idPattern   *regexp.Regexp
+	typePattern *regexp.Regexp
+	q           *orion.Expression
+	mq          *orion.Expression
+	scope       string
+}
+
+// match returns whether given entity passes the filter.
+func (f *entityFilter) match(e *entity) bool {
+	if !matchScope(f.scope, e.servicePath) {
+		return false
+	}
+	if 0 < len(f.ids) && !contains(f.ids, e.ID) {
+		return false
+	}
+	if f.idPattern != nil && !f.idPattern.MatchString(e.ID) {
+		return false
+	}
+	if 0 < len(f.types) && !contains(f.types, e.Type) {
+		return false
+	}
+	if f.typePattern != nil && !f.typePattern.MatchString(e.Type) {
+		return false
+	}
+	if f.q != nil && !matchExpression(f.q, &e.Entity, false) {
+		return false
+	}
+	if f.mq != nil && !matchExpression(f.mq, &e.Entity, true) {
+		return false
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList splits comma separated list.
+func splitList(s string) []string {
+	if len(s) <= 0 {
+		return nil
+	}
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); 0 < len(v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// newEntityFilter returns filter given by query parameters.
+func newEntityFilter(q url.Values, scope string) (*entityFilter, error) {
+	f := &entityFilter{ids: splitList(q.Get("id")), types: splitList(q.Get("type")), scope: scope}
+	if 0 < len(f.ids) && 0 < len(q.Get("idPattern")) {
+		return nil, newBadRequest("Incompatible parameters: id, IdPattern")
+	}
+	if 0 < len(f.types) && 0 < len(q.Get("typePattern")) {
+		return nil, newBadRequest("Incompatible parameters: type, typePattern")
+	}
+	var err error
+	if f.idPattern, err = compilePattern(q.Get("idPattern")); err != nil {
+		return nil, err
+	}
+	if f.typePattern, err = compilePattern(q.Get("typePattern")); err != nil {
+		return nil, err
+	}
+	if f.q, err = parseExpression(q.Get("q")); err != nil {
+		return nil, err
+	}
+	if f.mq, err = parseExpression(q.Get("mq")); err != nil {
+		return nil, err
+	}
+	for _, k := range []string{"georel", "geometry", "coords"} {
+		if 0 < len(q.Get(k)) {
+			return nil, newBadRequest("geo query is not supported by oriontest")
+		}
+	}
+	return f, nil
+}
+
+func compilePattern(p string) (*regexp.Regexp, error) {
+	if len(p) <= 0 {
+		return nil, nil
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, newBadRequest("invalid regex %s", p)
+	}
+	return re, nil
+}
+
+func parseExpression(s string) (*orion.Expression, error) {
+	if len(s) <= 0 {
+		return nil, nil
+	}
+	e, err := orion.ParseExpression(s)
+	if err != nil {
+		return nil, newBadRequest("%s", err)
+	}
+	return e, nil
+}
+
+// matchExpression returns whether given entity matches all statements. Path of mq statement begins with attribute and
+// metadata names.
+func matchExpression(ex *orion.Expression, e *ngsi.Entity, metadata bool) bool {
+	for _, s := range ex.Statements {
+		if !matchStatement(s, e, metadata) {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup returns the value specified by the path.
+func lookup(e *ngsi.Entity, path []string, metadata bool) (interface{}, bool) {
+	if len(path) <= 0 {
+		return nil, false
+	}
+	a, ok := e.Get(path[0])
+	if !ok {
+		return nil, false
+	}
+	v := a.Value
+	rest := path[1:]
+	if metadata {
+		if len(rest) <= 0 {
+			return nil, false
+		}
+		md, ok := a.Metadata[rest[0]]
+		if !ok {
+			return nil, false
+		}
+		v, rest = md.Value, rest[1:]
+	}
+	for _, k := range rest {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[k]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func matchStatement(s orion.Statement, e *ngsi.Entity, metadata bool) bool {
+	v, ok := lookup(e, s.Path, metadata)
+	switch s.Operator {
+	case orion.Operators.Exists:
+		return ok
+	case orion.Operators.NotExists:
+		return !ok
+	}
+	if !ok {
+		return false
+	}
+	switch s.Operator {
+	case orion.Operators.Equal, orion.Operators.NotEqual:
+		var eq bool
+		if s.Range {
+			eq = compareWith(v, s.Values[0], func(c int) bool { return 0 <= c }) &&
+				compareWith(v, s.Values[1], func(c int) bool { return c <= 0 })
+		} else {
+			for _, lit := range s.Values {
+				if equal(v, lit) {
+					eq = true
+					break
+				}
+			}
+		}
+		return eq == (s.Operator == orion.Operators.Equal)
+	case orion.Operators.Greater:
+		return compareWith(v, s.Values[0], func(c int) bool { return 0 < c })
+	case orion.Operators.GreaterOrEqual:
+		return compareWith(v, s.Values[0], func(c int) bool { return 0 <= c })
+	case orion.Operators.Less:
+		return compareWith(v, s.Values[0], func(c int) bool { return c < 0 })
+	case orion.Operators.LessOrEqual:
+		return compareWith(v, s.Values[0], func(c int) bool { return c <= 0 })
+	case orion.Operators.Match:
+		str, ok := v.(string)
+		if !ok {
+			return false
+		}
+		re, err := regexp.Compile(orion.Unquote(s.Values[0]))
+		return err == nil && re.MatchString(str)
+	}
+	return false
+}
+
+// equal returns whether given value equals to the literal.
+func equal(v interface{}, lit string) bool {
+	switch x := v.(type) {
+	case float64:
+		f, err := strconv.ParseFloat(lit, 64)
+		return err == nil && x == f
+	case bool:
+		return strconv.FormatBool(x) == lit
+	case nil:
+		return lit == "null"
+	case string:
+		return x == orion.Unquote(lit)
+	}
+	return false
+}
+
+// compare compares given value with the literal. Numbers are compared numerically, strings lexically.
+// Returns false when they cannot be compared.
+func compare(v interface{}, lit string) (int, bool) {
+	switch x := v.(type) {
+	case float64:
+		f, err := strconv.ParseFloat(lit, 64)
+		if err != nil {
+			return 0, false
+		}
+		switch {
+		case x < f:
+			return -1, true
+		case f < x:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		return strings.Compare(x, orion.Unquote(lit)), true
+	}
+	return 0, false
+}
+
+// compareWith returns whether comparison of given value with the literal satisfies given function.
+func compareWith(v interface{}, lit string, ok func(int) bool) bool {
+	c, comparable := compare(v, lit)
+	return comparable && ok(c)
+}
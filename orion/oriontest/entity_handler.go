@@ -0,0 +1,656 @@
+package oriontest
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/marrbor/go-fiware-api/ngsi"
+	"github.com/marrbor/go-fiware-api/orion"
+)
+
+// serveEntities serves /v2/entities and below.
+func (b *Broker) serveEntities(w http.ResponseWriter, r *request, segs []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := b.tenant(r.service)
+
+	switch {
+	case len(segs) == 0 && r.Method == http.MethodGet:
+		b.listEntities(w, r, t)
+	case len(segs) == 0 && r.Method == http.MethodPost:
+		b.createEntity(w, r, t)
+	case len(segs) == 0:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	case len(segs) == 1 && r.Method == http.MethodGet:
+		b.getEntity(w, r, t, segs[0])
+	case len(segs) == 1 && r.Method == http.MethodDelete:
+		b.deleteEntity(w, r, t, segs[0])
+	case len(segs) == 1:
+		methodNotAllowed(w, http.MethodGet, http.MethodDelete)
+	case len(segs) == 2 && segs[1] == "attrs":
+		b.serveAttrs(w, r, t, segs[0])
+	case len(segs) == 3 && segs[1] == "attrs":
+		b.serveAttr(w, r, t, segs[0], segs[2])
+	case len(segs) == 4 && segs[1] == "attrs" && segs[3] == "value":
+		b.serveValue(w, r, t, segs[0], segs[2])
+	default:
+		writeError(w, http.StatusNotFound, orion.ErrorCodeBadRequest, "service not found")
+	}
+}
+
+// find returns the entity that has given id (and type) in given scope.
+func (t *tenant) find(id, typeName, scope string) (*entity, error) {
+	var found []*entity
+	for _, e := range t.entities {
+		if e.ID == id && (len(typeName) <= 0 || e.Type == typeName) && matchScope(scope, e.servicePath) {
+			found = append(found, e)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, newNotFound()
+	case 1:
+		return found[0], nil
+	}
+	return nil, &httpError{status: http.StatusConflict, code: orion.ErrorCodeTooManyResults, description: "More than one matching entity. Please refine your query"}
+}
+
+// findForUpdate returns the entity to be updated by the request.
+func (t *tenant) findForUpdate(r *request, id string) (*entity, error) {
+	sp, err := r.writePath()
+	if err != nil {
+		return nil, newBadRequest("%s", err)
+	}
+	return t.find(id, r.URL.Query().Get("type"), sp)
+}
+
+// selectAttrs returns copy of given entity that has only specified attributes. All attributes when attrs is empty or has `*`.
+func selectAttrs(e *ngsi.Entity, attrs []string) *ngsi.Entity {
+	if len(attrs) <= 0 || contains(attrs, "*") {
+		return e
+	}
+	c := ngsi.NewEntity(e.ID, e.Type)
+	for _, n := range attrs {
+		if a, ok := e.Get(n); ok {
+			c.Set(n, a)
+		}
+	}
+	return c
+}
+
+// attrValues returns values of given entity in order.
+func attrValues(e *ngsi.Entity) []interface{} {
+	vs := make([]interface{}, 0, e.Len())
+	for _, n := range e.Names() {
+		a, _ := e.Get(n)
+		vs = append(vs, a.Value)
+	}
+	return vs
+}
+
+// render returns representation of given entity specified by options.
+func render(e *ngsi.Entity, o options) (interface{}, error) {
+	switch {
+	case o["values"] || o["unique"]:
+		return attrValues(e), nil
+	case o["keyValues"]:
+		b, err := e.MarshalKeyValues()
+		return json.RawMessage(b), err
+	}
+	return e, nil
+}
+
+// renderAttrs returns attributes of given entity without id and type.
+func renderAttrs(e *ngsi.Entity, o options) (interface{}, error) {
+	c := ngsi.NewEntity("", "")
+	for _, n := range e.Names() {
+		a, _ := e.Get(n)
+		c.Set(n, a)
+	}
+	return render(c, o)
+}
+
+// parseLimit returns limit and offset of the request.
+func parseLimit(q url.Values) (int, int, error) {
+	limit, offset := DefaultLimit, 0
+	if s := q.Get("limit"); 0 < len(s) {
+		l, err := strconv.Atoi(s)
+		if err != nil || l <= 0 {
+			return 0, 0, newBadRequest("Bad pagination limit: /%s/ [must be a decimal number]", s)
+		}
+		if MaxLimit < l {
+			return 0, 0, newBadRequest("Bad pagination limit: /%d/ [max: %d]", l, MaxLimit)
+		}
+		limit = l
+	}
+	if s := q.Get("offset"); 0 < len(s) {
+		o, err := strconv.Atoi(s)
+		if err != nil || o < 0 {
+			return 0, 0, newBadRequest("Bad pagination offset: /%s/ [must be a decimal number]", s)
+		}
+		offset = o
+	}
+	return limit, offset, nil
+}
+
+// page returns the page of given length and sets Fiware-Total-Count when count option is set.
+func page(w http.ResponseWriter, q url.Values, n int) (int, int, error) {
+	limit, offset, err := parseLimit(q)
+	if err != nil {
+		return 0, 0, err
+	}
+	if parseOptions(q)["count"] {
+		w.Header().Set(orion.TotalCountHeader, strconv.Itoa(n))
+	}
+	if n < offset {
+		offset = n
+	}
+	end := offset + limit
+	if n < end {
+		end = n
+	}
+	return offset, end, nil
+}
+
+// orderEntities sorts entities by orderBy parameter, e.g. `temperature,!humidity`.
+func orderEntities(es []*entity, orderBy string) {
+	keys := splitList(orderBy)
+	if len(keys) <= 0 {
+		return
+	}
+	sort.SliceStable(es, func(i, j int) bool {
+		for _, k := range keys {
+			desc := strings.HasPrefix(k, "!")
+			k = strings.TrimPrefix(k, "!")
+			c := compareAttr(&es[i].Entity, &es[j].Entity, k)
+			if c == 0 {
+				continue
+			}
+			if desc {
+				return 0 < c
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// compareAttr compares given entities by specified key. Entities without the attribute come last.
+func compareAttr(a, b *ngsi.Entity, key string) int {
+	value := func(e *ngsi.Entity) (interface{}, bool) {
+		switch key {
+		case "id":
+			return e.ID, true
+		case "type":
+			return e.Type, true
+		}
+		at, ok := e.Get(key)
+		return at.Value, ok
+	}
+	va, oka := value(a)
+	vb, okb := value(b)
+	switch {
+	case !oka && !okb:
+		return 0
+	case !oka:
+		return 1
+	case !okb:
+		return -1
+	}
+	fa, na := va.(float64)
+	fb, nb := vb.(float64)
+	if na && nb {
+		switch {
+		case fa < fb:
+			return -1
+		case fb < fa:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(fmt.Sprint(va), fmt.Sprint(vb))
+}
+
+// writeEntities writes given entities in the representation requested.
+func writeEntities(w http.ResponseWriter, q url.Values, es []*entity) {
+	o := parseOptions(q)
+	attrs := splitList(q.Get("attrs"))
+	list := make([]interface{}, 0, len(es))
+	seen := map[string]bool{}
+	for _, e := range es {
+		v, err := render(selectAttrs(&e.Entity, attrs), o)
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		if o["unique"] {
+			b, _ := json.Marshal(v)
+			if seen[string(b)] {
+				continue
+			}
+			seen[string(b)] = true
+		}
+		list = append(list, v)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// listEntities serves GET /v2/entities.
+func (b *Broker) listEntities(w http.ResponseWriter, r *request, t *tenant) {
+	q := r.URL.Query()
+	f, err := newEntityFilter(q, r.readScope())
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	var es []*entity
+	for _, e := range t.entities {
+		if f.match(e) {
+			es = append(es, e)
+		}
+	}
+	orderEntities(es, q.Get("orderBy"))
+	from, to, err := page(w, q, len(es))
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	writeEntities(w, q, es[from:to])
+}
+
+// createEntity serves POST /v2/entities.
+func (b *Broker) createEntity(w http.ResponseWriter, r *request, t *tenant) {
+	o := parseOptions(r.URL.Query())
+	body, err := readBody(r)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	ne, err := decodeEntity(body, o["keyValues"])
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	if len(ne.ID) <= 0 {
+		badRequest(w, "entity id length: 0, min length supported: 1")
+		return
+	}
+	if len(ne.Type) <= 0 {
+		ne.Type = DefaultEntityType
+	}
+	sp, err := r.writePath()
+	if err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+
+	if e, err := t.find(ne.ID, ne.Type, sp); err == nil {
+		if !o["upsert"] {
+			writeError(w, http.StatusUnprocessableEntity, orion.ErrorCodeUnprocessable, orion.AlreadyExistsDescription)
+			return
+		}
+		b.notify(r, t, e, setAttrs(&e.Entity, ne, false), o["forcedUpdate"])
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	e := &entity{Entity: *ne, servicePath: sp}
+	t.entities = append(t.entities, e)
+	b.notify(r, t, e, e.Names(), true)
+	w.Header().Set("Location", fmt.Sprintf("/v2/entities/%s?type=%s", url.PathEscape(e.ID), url.QueryEscape(e.Type)))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// setAttrs sets attributes of src to dst. Type of the attribute is kept when it is not specified and keepType is set.
+// Returns names of the attributes actually changed.
+func setAttrs(dst, src *ngsi.Entity, keepType bool) []string {
+	var changed []string
+	for _, n := range src.Names() {
+		a, _ := src.Get(n)
+		old, ok := dst.Get(n)
+		if ok && keepType && len(a.Type) <= 0 {
+			a.Type = old.Type
+		}
+		a = completeType(a)
+		if ok && reflect.DeepEqual(old, a) {
+			continue
+		}
+		dst.Set(n, a)
+		changed = append(changed, n)
+	}
+	return changed
+}
+
+// getEntity serves GET /v2/entities/{id}.
+func (b *Broker) getEntity(w http.ResponseWriter, r *request, t *tenant, id string) {
+	q := r.URL.Query()
+	e, err := t.find(id, q.Get("type"), r.readScope())
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	v, err := render(selectAttrs(&e.Entity, splitList(q.Get("attrs"))), parseOptions(q))
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+// deleteEntity serves DELETE /v2/entities/{id}.
+func (b *Broker) deleteEntity(w http.ResponseWriter, r *request, t *tenant, id string) {
+	e, err := t.findForUpdate(r, id)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	t.remove(e)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// remove removes given entity.
+func (t *tenant) remove(e *entity) {
+	for i, x := range t.entities {
+		if x == e {
+			t.entities = append(t.entities[:i], t.entities[i+1:]...)
+			return
+		}
+	}
+}
+
+// serveAttrs serves /v2/entities/{id}/attrs.
+func (b *Broker) serveAttrs(w http.ResponseWriter, r *request, t *tenant, id string) {
+	q := r.URL.Query()
+	o := parseOptions(q)
+	if r.Method == http.MethodGet {
+		e, err := t.find(id, q.Get("type"), r.readScope())
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		v, err := renderAttrs(selectAttrs(&e.Entity, splitList(q.Get("attrs"))), o)
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, v)
+		return
+	}
+	if r.Method != http.MethodPost && r.Method != http.MethodPatch && r.Method != http.MethodPut {
+		methodNotAllowed(w, http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodPut)
+		return
+	}
+
+	e, err := t.findForUpdate(r, id)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	attrs, err := decodeEntity(body, o["keyValues"])
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+
+	var changed []string
+	switch r.Method {
+	case http.MethodPost:
+		if o["append"] {
+			for _, n := range attrs.Names() {
+				if _, ok := e.Get(n); ok {
+					writeError(w, http.StatusUnprocessableEntity, orion.ErrorCodeUnprocessable, fmt.Sprintf("one or more of the attributes in the request already exist: [ %s ]", n))
+					return
+				}
+			}
+		}
+		changed = setAttrs(&e.Entity, attrs, false)
+	case http.MethodPatch:
+		var missing []string
+		for _, n := range attrs.Names() {
+			if _, ok := e.Get(n); !ok {
+				missing = append(missing, n)
+			}
+		}
+		if 0 < len(missing) {
+			writeError(w, http.StatusUnprocessableEntity, orion.ErrorCodeUnprocessable, fmt.Sprintf("do not exist: %s - [ %s ]", id, strings.Join(missing, ", ")))
+			return
+		}
+		changed = setAttrs(&e.Entity, attrs, true)
+	case http.MethodPut:
+		for _, n := range e.Names() {
+			if _, ok := attrs.Get(n); !ok {
+				e.Delete(n)
+				changed = append(changed, n)
+			}
+		}
+		changed = append(changed, setAttrs(&e.Entity, attrs, false)...)
+	}
+	b.notify(r, t, e, changed, o["forcedUpdate"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveAttr serves /v2/entities/{id}/attrs/{name}.
+func (b *Broker) serveAttr(w http.ResponseWriter, r *request, t *tenant, id, name string) {
+	q := r.URL.Query()
+	var e *entity
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		e, err = t.find(id, q.Get("type"), r.readScope())
+	case http.MethodPut, http.MethodDelete:
+		e, err = t.findForUpdate(r, id)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+		return
+	}
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	a, ok := e.Get(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, orion.ErrorCodeNotFound, "The entity does not have such an attribute")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, a)
+	case http.MethodPut:
+		body, err := readBody(r)
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		var na ngsi.Attribute
+		if err := json.Unmarshal(body, &na); err != nil {
+			writeError(w, http.StatusBadRequest, "ParseError", err.Error())
+			return
+		}
+		b.notify(r, t, e, setAttrs(&e.Entity, ngsi.NewEntity("", "").Set(name, na), false), parseOptions(q)["forcedUpdate"])
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		e.Delete(name)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// accepts returns whether Accept header of the request accepts given media type.
+func accepts(r *request, mediaType string) bool {
+	accept := r.Header.Get("Accept")
+	if len(accept) <= 0 {
+		return true
+	}
+	for _, a := range strings.Split(accept, ",") {
+		mt, _, err := mime.ParseMediaType(strings.TrimSpace(a))
+		if err != nil {
+			continue
+		}
+		if mt == mediaType || mt == "*/*" || mt == strings.SplitN(mediaType, "/", 2)[0]+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
+// serveValue serves /v2/entities/{id}/attrs/{name}/value.
+func (b *Broker) serveValue(w http.ResponseWriter, r *request, t *tenant, id, name string) {
+	q := r.URL.Query()
+	var e *entity
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		e, err = t.find(id, q.Get("type"), r.readScope())
+	case http.MethodPut:
+		e, err = t.findForUpdate(r, id)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut)
+		return
+	}
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	a, ok := e.Get(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, orion.ErrorCodeNotFound, "The entity does not have such an attribute")
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		switch a.Value.(type) {
+		case map[string]interface{}, []interface{}:
+			if !accepts(r, orion.ContentTypeJSON) {
+				writeError(w, http.StatusNotAcceptable, orion.ErrorCodeNotAcceptable, "accepted MIME types: application/json")
+				return
+			}
+			writeJSON(w, http.StatusOK, a.Value)
+		default:
+			if !accepts(r, orion.ContentTypeText) {
+				writeError(w, http.StatusNotAcceptable, orion.ErrorCodeNotAcceptable, "accepted MIME types: text/plain")
+				return
+			}
+			b, _ := json.Marshal(a.Value)
+			w.Header().Set("Content-Type", orion.ContentTypeText)
+			_, _ = w.Write(b)
+		}
+		return
+	}
+
+	body, err := readBody(r)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	var v interface{}
+	mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err := json.Unmarshal(body, &v); err != nil {
+		if mt != orion.ContentTypeText {
+			writeError(w, http.StatusBadRequest, "ParseError", err.Error())
+			return
+		}
+		v = string(body) // bare text.
+	}
+	na := a
+	na.Value = v
+	na.Type = ""
+	if reflect.TypeOf(a.Value) == reflect.TypeOf(v) {
+		na.Type = a.Type
+	}
+	b.notify(r, t, e, setAttrs(&e.Entity, ngsi.NewEntity("", "").Set(name, na), false), parseOptions(q)["forcedUpdate"])
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// attributeTypes returns names and types of the attributes of given entities.
+func attributeTypes(es []*entity) map[string][]string {
+	types := map[string][]string{}
+	for _, e := range es {
+		for _, n := range e.Names() {
+			a, _ := e.Get(n)
+			if !contains(types[n], a.Type) {
+				types[n] = append(types[n], a.Type)
+			}
+		}
+	}
+	return types
+}
+
+// serveTypes serves /v2/types and /v2/types/{type}.
+func (b *Broker) serveTypes(w http.ResponseWriter, r *request, segs []string) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := b.tenant(r.service)
+
+	scope := r.readScope()
+	byType := map[string][]*entity{}
+	var names []string
+	for _, e := range t.entities {
+		if !matchScope(scope, e.servicePath) {
+			continue
+		}
+		if _, ok := byType[e.Type]; !ok {
+			names = append(names, e.Type)
+		}
+		byType[e.Type] = append(byType[e.Type], e)
+	}
+	sort.Strings(names)
+
+	q := r.URL.Query()
+	o := parseOptions(q)
+	typeInfo := func(name string) orion.EntityType {
+		et := orion.EntityType{Attrs: map[string]orion.EntityTypeAttribute{}, Count: len(byType[name])}
+		for n, types := range attributeTypes(byType[name]) {
+			if o["noAttrDetail"] {
+				types = []string{}
+			}
+			et.Attrs[n] = orion.EntityTypeAttribute{Types: types}
+		}
+		return et
+	}
+
+	if len(segs) == 1 {
+		if _, ok := byType[segs[0]]; !ok {
+			writeError(w, http.StatusNotFound, orion.ErrorCodeNotFound, "Entity type not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, typeInfo(segs[0]))
+		return
+	}
+	if 1 < len(segs) {
+		writeError(w, http.StatusNotFound, orion.ErrorCodeBadRequest, "service not found")
+		return
+	}
+
+	from, to, err := page(w, q, len(names))
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	if o["values"] {
+		writeJSON(w, http.StatusOK, names[from:to])
+		return
+	}
+	list := make([]orion.EntityType, 0, to-from)
+	for _, n := range names[from:to] {
+		et := typeInfo(n)
+		et.Type = n
+		list = append(list, et)
+	}
+	writeJSON(w, http.StatusOK, list)
+}
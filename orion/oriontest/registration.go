@@ -0,0 +1,153 @@
+package oriontest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/marrbor/go-fiware-api/orion"
+)
+
+const (
+	registrationNotFoundDescription = "The requested registration has not been found. Check id"
+)
+
+// registration is a registration stored in the broker. Requests are not forwarded to the provider.
+type registration struct {
+	orion.Registration
+	servicePath string
+}
+
+// registrationIndex returns index of the registration that has given id. -1 when not found.
+func (t *tenant) registrationIndex(id string) int {
+	for i, r := range t.registrations {
+		if r.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// serveRegistrations serves /v2/registrations and /v2/registrations/{id}.
+func (b *Broker) serveRegistrations(w http.ResponseWriter, r *request, segs []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := b.tenant(r.service)
+
+	if len(segs) <= 0 {
+		switch r.Method {
+		case http.MethodGet:
+			var regs []*registration
+			for _, reg := range t.registrations {
+				if matchScope(r.readScope(), reg.servicePath) {
+					regs = append(regs, reg)
+				}
+			}
+			from, to, err := page(w, r.URL.Query(), len(regs))
+			if err != nil {
+				writeHTTPError(w, err)
+				return
+			}
+			list := make([]orion.Registration, 0, to-from)
+			for _, reg := range regs[from:to] {
+				list = append(list, reg.Registration)
+			}
+			writeJSON(w, http.StatusOK, list)
+		case http.MethodPost:
+			b.createRegistration(w, r, t)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		}
+		return
+	}
+	if 1 < len(segs) {
+		writeError(w, http.StatusNotFound, orion.ErrorCodeBadRequest, "service not found")
+		return
+	}
+
+	i := t.registrationIndex(segs[0])
+	if i < 0 {
+		writeError(w, http.StatusNotFound, orion.ErrorCodeNotFound, registrationNotFoundDescription)
+		return
+	}
+	reg := t.registrations[i]
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, reg.Registration)
+	case http.MethodPatch:
+		body, err := readBody(r)
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		var u orion.RegistrationUpdate
+		if err := json.Unmarshal(body, &u); err != nil {
+			writeError(w, http.StatusBadRequest, "ParseError", err.Error())
+			return
+		}
+		if err := u.Validate(); err != nil {
+			badRequest(w, err.Error())
+			return
+		}
+		reg.update(&u)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		t.registrations = append(t.registrations[:i], t.registrations[i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPatch, http.MethodDelete)
+	}
+}
+
+// createRegistration serves POST /v2/registrations.
+func (b *Broker) createRegistration(w http.ResponseWriter, r *request, t *tenant) {
+	body, err := readBody(r)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	var reg registration
+	if err := json.Unmarshal(body, &reg.Registration); err != nil {
+		writeError(w, http.StatusBadRequest, "ParseError", err.Error())
+		return
+	}
+	if err := reg.Validate(); err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+	sp, err := r.writePath()
+	if err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+	reg.Id = newID()
+	reg.servicePath = sp
+	if len(reg.Status) <= 0 {
+		reg.Status = orion.RegistrationStatusActive
+	}
+	if len(reg.Provider.SupportedForwardingMode) <= 0 {
+		reg.Provider.SupportedForwardingMode = orion.ForwardingModeAll
+	}
+	reg.ForwardingInformation = nil
+	t.registrations = append(t.registrations, &reg)
+	w.Header().Set("Location", "/v2/registrations/"+reg.Id)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// update modifies the registration by given update.
+func (r *registration) update(u *orion.RegistrationUpdate) {
+	if u.Description != nil {
+		r.Description = *u.Description
+	}
+	if u.Provider != nil {
+		r.Provider = *u.Provider
+	}
+	if u.DataProvided != nil {
+		r.DataProvided = *u.DataProvided
+	}
+	if u.Status != nil {
+		r.Status = *u.Status
+	}
+	if u.Expires != nil {
+		r.Expires = u.Expires
+	}
+}
@@ -0,0 +1,282 @@
+// in-memory NGSIv2 broker that behaves like Orion for tests.
+// It serves entities, attributes and attribute values, types, batch operations, subscriptions (with notifications),
+// registrations, /version and /v2 honouring Fiware-Service and Fiware-ServicePath. Geo queries, metadata filters of
+// attrs and forwarding to context providers are not supported.
+package oriontest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/go-fiware-api/orion"
+)
+
+const (
+	// Version is the Orion version the broker reports.
+	Version = "3.10.1"
+
+	DefaultLimit = 20
+	MaxLimit     = 1000
+
+	// DefaultNotificationTimeout is a time limit of sending a notification.
+	DefaultNotificationTimeout = 5 * time.Second
+)
+
+// Server is an httptest server that serves the in-memory broker.
+type Server struct {
+	*httptest.Server
+	Broker *Broker
+}
+
+// NewServer starts and returns new Server instance. Close it when finished.
+func NewServer() *Server {
+	b := NewBroker()
+	return &Server{Server: httptest.NewServer(b), Broker: b}
+}
+
+// EntryPoints is the response of GET /v2.
+var EntryPoints = orion.EntryPoints{
+	EntitiesURL:      "/v2/entities",
+	TypesURL:         "/v2/types",
+	SubscriptionsURL: "/v2/subscriptions",
+	RegistrationsURL: "/v2/registrations",
+}
+
+// StubHandler returns a handler that serves GET /v2 with EntryPoints and passes other requests to h.
+// Use it to stub responses the broker does not return, such as errors, delays or fixed payloads.
+func StubHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2" || r.URL.Path == "/v2/" {
+			writeJSON(w, http.StatusOK, EntryPoints)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// NewStubServer starts and returns an httptest server of StubHandler. Close it when finished.
+func NewStubServer(h http.HandlerFunc) *httptest.Server {
+	return httptest.NewServer(StubHandler(h))
+}
+
+// Close shuts down the server and waits for notifications in flight.
+func (s *Server) Close() {
+	s.Server.Close()
+	s.Broker.Wait()
+}
+
+// MQTTPublisher publishes MQTT notifications. url is the broker url of the subscription, e.g. mqtt://localhost:1883.
+type MQTTPublisher func(url, topic string, qos int, payload []byte) error
+
+// Broker is an http.Handler that behaves like Orion.
+type Broker struct {
+	HttpClient *http.Client  // sends http notifications.
+	MQTT       MQTTPublisher // sends mqtt notifications. They are dropped when nil.
+
+	mu      sync.Mutex
+	tenants map[string]*tenant
+	started time.Time
+	wg      sync.WaitGroup
+}
+
+// tenant holds resources of a fiware-service.
+type tenant struct {
+	entities      []*entity
+	subscriptions []*subscription
+	registrations []*registration
+	notifications int // sequence number of the notifications sent.
+}
+
+// NewBroker returns new Broker instance that has no entities.
+func NewBroker() *Broker {
+	return &Broker{
+		HttpClient: &http.Client{Timeout: DefaultNotificationTimeout},
+		tenants:    map[string]*tenant{},
+		started:    time.Now(),
+	}
+}
+
+// Reset removes all entities, subscriptions and registrations of all services.
+func (b *Broker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tenants = map[string]*tenant{}
+}
+
+// Wait waits for notifications in flight.
+func (b *Broker) Wait() {
+	b.wg.Wait()
+}
+
+// tenant returns tenant of given service. Have to be called with the lock.
+func (b *Broker) tenant(service string) *tenant {
+	t, ok := b.tenants[service]
+	if !ok {
+		t = &tenant{}
+		b.tenants[service] = t
+	}
+	return t
+}
+
+// request holds a request and fiware headers.
+type request struct {
+	*http.Request
+	service     string
+	servicePath string // as it is. empty when not specified.
+	correlator  string
+}
+
+// readScope returns service path scope for queries. /# when not specified.
+func (r *request) readScope() string {
+	if len(r.servicePath) <= 0 {
+		return common.HierarchicalWildcard
+	}
+	return r.servicePath
+}
+
+// writePath returns service path for updates. / when not specified.
+func (r *request) writePath() (string, error) {
+	if len(r.servicePath) <= 0 {
+		return "/", nil
+	}
+	if common.IsServicePathScope(r.servicePath) {
+		return "", fmt.Errorf("service path scope is not allowed for updates")
+	}
+	return r.servicePath, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (b *Broker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := &request{
+		Request:     r,
+		service:     strings.ToLower(r.Header.Get(common.ServiceHeader)),
+		servicePath: r.Header.Get(common.ServicePathHeader),
+		correlator:  r.Header.Get(common.CorrelatorHeader),
+	}
+	if len(req.correlator) <= 0 {
+		req.correlator = newID()
+	}
+	w.Header().Set(common.CorrelatorHeader, req.correlator)
+
+	if 0 < len(req.servicePath) && !common.IsValidServicePathScope(req.servicePath) {
+		writeError(w, http.StatusBadRequest, orion.ErrorCodeBadRequest, "a component of ServicePath contains an illegal character")
+		return
+	}
+
+	p := r.URL.Path
+	switch {
+	case p == "/version":
+		b.serveVersion(w, req)
+	case p == "/v2" || p == "/v2/":
+		writeJSON(w, http.StatusOK, EntryPoints)
+	case p == "/v2/entities" || strings.HasPrefix(p, "/v2/entities/"):
+		b.serveEntities(w, req, splitPath(strings.TrimPrefix(p, "/v2/entities")))
+	case p == "/v2/types" || strings.HasPrefix(p, "/v2/types/"):
+		b.serveTypes(w, req, splitPath(strings.TrimPrefix(p, "/v2/types")))
+	case p == orion.OperationsURL+orion.BatchUpdatePath:
+		b.serveBatchUpdate(w, req)
+	case p == orion.OperationsURL+orion.BatchQueryPath:
+		b.serveBatchQuery(w, req)
+	case p == "/v2/subscriptions" || strings.HasPrefix(p, "/v2/subscriptions/"):
+		b.serveSubscriptions(w, req, splitPath(strings.TrimPrefix(p, "/v2/subscriptions")))
+	case p == "/v2/registrations" || strings.HasPrefix(p, "/v2/registrations/"):
+		b.serveRegistrations(w, req, splitPath(strings.TrimPrefix(p, "/v2/registrations")))
+	default:
+		writeError(w, http.StatusNotFound, orion.ErrorCodeBadRequest, "service not found")
+	}
+}
+
+// serveVersion serves GET /version.
+func (b *Broker) serveVersion(w http.ResponseWriter, r *request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	up := time.Since(b.started)
+	d, h, m, s := int(up.Hours())/24, int(up.Hours())%24, int(up.Minutes())%60, int(up.Seconds())%60
+	writeJSON(w, http.StatusOK, map[string]orion.VersionStrings{"orion": {
+		Version:     Version,
+		Uptime:      fmt.Sprintf(orion.UptimeFormatInVersion, d, h, m, s),
+		GitHash:     "nogitversion",
+		CompileTime: b.started.UTC().Format(time.UnixDate),
+		CompiledBy:  "oriontest",
+		CompiledIn:  "localhost",
+		ReleaseDate: b.started.UTC().Format(time.UnixDate),
+		Doc:         "https://fiware-orion.rtfd.io/",
+	}})
+}
+
+// splitPath splits the rest of the path into segments.
+func splitPath(p string) []string {
+	p = strings.Trim(p, "/")
+	if len(p) <= 0 {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// ngsiError is an error payload of NGSIv2.
+type ngsiError struct {
+	Error       string `json:"error"`
+	Description string `json:"description"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, ngsiError{Error: code, Description: description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, orion.ErrorCodeInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", orion.ContentTypeJSON)
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, orion.ErrorCodeMethodNotAllowed, "method not allowed")
+}
+
+func badRequest(w http.ResponseWriter, description string) {
+	writeError(w, http.StatusBadRequest, orion.ErrorCodeBadRequest, description)
+}
+
+// newID returns 24 hex characters like ids that Orion generates.
+func newID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// matchScope returns whether given service path is in given scope, e.g. /a/# or /a,/b.
+func matchScope(scope, servicePath string) bool {
+	for _, s := range strings.Split(scope, ",") {
+		s = strings.TrimSpace(s)
+		if s == common.HierarchicalWildcard {
+			return true
+		}
+		if strings.HasSuffix(s, common.HierarchicalWildcard) {
+			base := strings.TrimSuffix(s, common.HierarchicalWildcard)
+			if servicePath == base || strings.HasPrefix(servicePath, base+"/") {
+				return true
+			}
+			continue
+		}
+		if servicePath == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+package oriontest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/go-fiware-api/ngsi"
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/notify"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
+	"github.com/stretchr/testify/assert"
+)
+
+func room(id string, temperature float64, floor string) *ngsi.Entity {
+	return ngsi.NewEntity(id, "Room").
+		Set("temperature", ngsi.NewAttribute(ngsi.Number, temperature)).
+		Set("floor", ngsi.NewAttribute(ngsi.Text, floor))
+}
+
+func TestServer_Tenancy(t *testing.T) {
+	ts := oriontest.NewServer()
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	assert.NoError(t, a.CreateEntity("openiot", "/a", nil, room("Room1", 20, "1F")))
+	assert.NoError(t, a.CreateEntity("openiot", "/a/b", nil, room("Room2", 21, "2F")))
+	assert.NoError(t, a.CreateEntity("other", "/a", nil, room("Room1", 22, "3F")))
+
+	for _, tc := range []struct {
+		service, servicePath string
+		want                 int
+	}{
+		{"openiot", "", 2},
+		{"openiot", "/a", 1},
+		{"openiot", "/a/#", 2},
+		{"openiot", "/b", 0},
+		{"other", "", 1},
+		{"", "", 0},
+	} {
+		es, err := a.GetNgsiEntityList(tc.service, tc.servicePath, nil)
+		assert.NoError(t, err)
+		assert.EqualValues(t, tc.want, len(es), "%s %s", tc.service, tc.servicePath)
+	}
+
+	// the same id in the other service is another entity.
+	e, err := a.GetNgsiEntity("other", "", "Room1", nil)
+	assert.NoError(t, err)
+	at, _ := e.Get("floor")
+	assert.EqualValues(t, "3F", at.Value)
+
+	err = a.CreateEntity("openiot", "/a", nil, room("Room1", 20, "1F"))
+	assert.True(t, orion.IsAlreadyExists(err))
+	_, err = a.GetNgsiEntity("openiot", "", "Room3", nil)
+	assert.True(t, orion.IsNotFound(err))
+}
+
+func TestServer_Query(t *testing.T) {
+	ts := oriontest.NewServer()
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+	for i, temperature := range []float64{18, 25, 30, 22} {
+		assert.NoError(t, a.CreateEntity("", "", nil, room("Room"+string(rune('1'+i)), temperature, "1F")))
+	}
+
+	q := orion.NewQuery().SetQQuery([]string{"temperature>20"}).SetOrderBy([]string{"!temperature"})
+	es, err := a.GetNgsiEntityList("", "", q)
+	assert.NoError(t, err)
+	var ids []string
+	for _, e := range es {
+		ids = append(ids, e.ID)
+	}
+	assert.EqualValues(t, []string{"Room3", "Room2", "Room4"}, ids)
+
+	q = orion.NewQuery().SetQQuery([]string{"temperature==18..22", "floor=='1F'"})
+	es, err = a.GetNgsiEntityList("", "", q)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(es))
+
+	// paging with count.
+	it := a.IterateEntities("", "", nil).SetPageSize(3)
+	n := 0
+	for it.Next() {
+		n++
+	}
+	assert.NoError(t, it.Err())
+	assert.EqualValues(t, 4, n)
+	assert.EqualValues(t, 4, it.Total())
+
+	res, err := http.Get(ts.URL + "/v2/entities?limit=0")
+	assert.NoError(t, err)
+	_ = res.Body.Close()
+	assert.EqualValues(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestServer_Attributes(t *testing.T) {
+	ts := oriontest.NewServer()
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+	assert.NoError(t, a.CreateEntity("", "", nil, room("Room1", 20, "1F")))
+
+	assert.NoError(t, a.UpdateEntityAttributeValue("", "", "Room1", "temperature", "", 23.5))
+	assert.NoError(t, a.UpdateEntityAttributeValue("", "", "Room1", "floor", "", "2F"))
+	err := a.UpdateEntityAttributeValue("", "", "Room1", "location", "", map[string]interface{}{"x": 1})
+	assert.True(t, orion.IsNotFound(err))
+	var temperature float64
+	assert.NoError(t, a.GetEntityAttributeValue("", "", "Room1", "temperature", "", &temperature))
+	assert.EqualValues(t, 23.5, temperature)
+	var floor string
+	assert.NoError(t, a.GetEntityAttributeValue("", "", "Room1", "floor", "", &floor))
+	assert.EqualValues(t, "2F", floor)
+
+	assert.NoError(t, a.DeleteEntityAttribute("", "", "Room1", "floor", ""))
+	err = a.DeleteEntityAttribute("", "", "Room1", "floor", "")
+	assert.True(t, orion.IsNotFound(err))
+
+	types, err := a.GetEntityTypes("", "", nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(types))
+	assert.EqualValues(t, "Room", types[0].Type)
+	assert.EqualValues(t, []string{"temperature"}, types[0].AttributeNames())
+}
+
+func TestServer_BatchUpdate(t *testing.T) {
+	ts := oriontest.NewServer()
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	assert.NoError(t, a.BatchUpdate("", "", orion.ActionTypes.Append, []*ngsi.Entity{room("Room1", 20, "1F"), room("Room2", 21, "2F")}))
+	err := a.BatchUpdate("", "", orion.ActionTypes.Update, []*ngsi.Entity{room("Room1", 22, "1F"), room("Room3", 23, "3F")})
+	var be *orion.BatchError
+	assert.True(t, errors.As(err, &be))
+	assert.EqualValues(t, []string{"Room3"}, be.FailedIDs)
+
+	e, err := a.GetNgsiEntity("", "", "Room1", nil)
+	assert.NoError(t, err)
+	at, _ := e.Get("temperature")
+	assert.EqualValues(t, 22, at.Value)
+
+	assert.NoError(t, a.BatchUpdate("", "", orion.ActionTypes.Delete, []*ngsi.Entity{ngsi.NewEntity("Room2", "Room")}))
+	var es []ngsi.Entity
+	assert.NoError(t, a.BatchQuery("", "", orion.NewBatchQuery().AddEntity(orion.BatchQueryEntity{IDPattern: "^Room"}), nil, &es))
+	assert.EqualValues(t, 1, len(es))
+}
+
+func TestServer_Subscription(t *testing.T) {
+	var mu sync.Mutex
+	var received []*notify.Notification
+	var headers []http.Header
+	d := notify.NewDispatcher().HandleDefault(func(ctx context.Context, n *notify.Notification) error {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, n)
+		return nil
+	})
+	h := notify.NewHandler(d)
+	rs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers = append(headers, r.Header.Clone())
+		mu.Unlock()
+		h.ServeHTTP(w, r)
+	}))
+	defer rs.Close()
+
+	ts := oriontest.NewServer()
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	format := orion.AttrsFormatKeyValues
+	attrs := []string{"temperature"}
+	id, err := a.CreateSubscription("openiot", "/a", &orion.Subscription{
+		Subject: orion.SubscriptionSubject{
+			Entities:  []map[string]string{{"idPattern": ".*", "type": "Room"}},
+			Condition: &orion.SubscriptionSubjectCondition{Attrs: &attrs},
+		},
+		Notification: orion.SubscriptionNotification{Http: &orion.Http{Url: rs.URL}, Attrs: &attrs, AttrsFormat: &format},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, a.CreateEntity("openiot", "/a", nil, room("Room1", 20, "1F")))
+	assert.NoError(t, a.CreateEntity("openiot", "/b", nil, room("Room2", 20, "1F")))                 // out of the service path.
+	assert.NoError(t, a.UpdateEntityAttributeValue("openiot", "/a", "Room1", "floor", "", "2F"))     // not in the condition.
+	assert.NoError(t, a.UpdateEntityAttributeValue("openiot", "/a", "Room1", "temperature", "", 20)) // not changed.
+	assert.NoError(t, a.UpdateEntityAttributeValue("openiot", "/a", "Room1", "temperature", "", 25))
+	ts.Broker.Wait()
+
+	mu.Lock()
+	assert.EqualValues(t, 2, len(received))
+	for _, n := range received {
+		assert.EqualValues(t, id, n.SubscriptionID)
+		assert.EqualValues(t, notify.Formats.KeyValues, n.Format)
+	}
+	assert.JSONEq(t, `{"id":"Room1","type":"Room","temperature":25}`, string(received[1].Data[0]))
+	assert.EqualValues(t, "openiot", headers[0].Get(common.ServiceHeader))
+	assert.EqualValues(t, "/a", headers[0].Get(common.ServicePathHeader))
+	assert.True(t, strings.HasSuffix(headers[0].Get(common.CorrelatorHeader), "; cbnotif=1"))
+	mu.Unlock()
+
+	var s orion.Subscription
+	assert.NoError(t, a.GetSubscription("openiot", "/a", id, &s))
+	assert.EqualValues(t, 2, *s.Notification.TimesSent)
+	assert.EqualValues(t, http.StatusNoContent, *s.Notification.LastSuccessCode)
+
+	// paused subscription does not notify.
+	assert.NoError(t, a.PauseSubscription("openiot", "/a", id))
+	assert.NoError(t, a.UpdateEntityAttributeValue("openiot", "/a", "Room1", "temperature", "", 26))
+	ts.Broker.Wait()
+	mu.Lock()
+	assert.EqualValues(t, 2, len(received))
+	mu.Unlock()
+
+	assert.NoError(t, a.DeleteSubscription("openiot", "/a", id))
+	err = a.GetSubscription("openiot", "/a", id, &s)
+	assert.True(t, orion.IsNotFound(err))
+}
+
+func TestServer_Registration(t *testing.T) {
+	ts := oriontest.NewServer()
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	id, err := a.CreateRegistration("openiot", "/a", &orion.Registration{
+		Description:  "weather",
+		Provider:     orion.RegistrationProvider{Http: orion.Http{Url: "http://localhost:1234"}},
+		DataProvided: orion.RegistrationDataProvided{Entities: []map[string]string{{"id": "Room1", "type": "Room"}}, Attrs: []string{"temperature"}},
+	})
+	assert.NoError(t, err)
+
+	var r orion.Registration
+	assert.NoError(t, a.GetRegistration("openiot", "/a", id, &r))
+	assert.EqualValues(t, "weather", r.Description)
+	assert.EqualValues(t, orion.RegistrationStatusActive, r.Status)
+
+	status := orion.RegistrationStatusInactive
+	assert.NoError(t, a.UpdateRegistration("openiot", "/a", id, &orion.RegistrationUpdate{Status: &status}))
+	var list []orion.Registration
+	assert.NoError(t, a.GetRegistrationList("openiot", "/a", nil, &list))
+	assert.EqualValues(t, 1, len(list))
+	assert.EqualValues(t, status, list[0].Status)
+
+	assert.NoError(t, a.DeleteRegistration("openiot", "/a", id))
+	err = a.GetRegistration("openiot", "/a", id, &r)
+	assert.True(t, orion.IsNotFound(err))
+}
@@ -0,0 +1,549 @@
+package oriontest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/go-fiware-api/ngsi"
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/notify"
+)
+
+const (
+	subscriptionNotFoundDescription = "The requested subscription has not been found. Check id"
+)
+
+var (
+	reMacro = regexp.MustCompile(`\$\{([^}]+)\}`)
+)
+
+// subscription is a subscription stored in the broker.
+type subscription struct {
+	orion.Subscription
+	servicePath string
+}
+
+// message is a notification to be sent.
+type message struct {
+	sub          *subscription
+	id           string
+	notification orion.SubscriptionNotification // copy taken when triggered.
+	service      string
+	servicePath  string
+	correlator   string
+	format       string
+	entity       *ngsi.Entity // copy of the notified entity.
+}
+
+// serveSubscriptions serves /v2/subscriptions and /v2/subscriptions/{id}.
+func (b *Broker) serveSubscriptions(w http.ResponseWriter, r *request, segs []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := b.tenant(r.service)
+
+	if len(segs) <= 0 {
+		switch r.Method {
+		case http.MethodGet:
+			from, to, err := page(w, r.URL.Query(), len(t.subscriptions))
+			if err != nil {
+				writeHTTPError(w, err)
+				return
+			}
+			list := make([]orion.Subscription, 0, to-from)
+			for _, s := range t.subscriptions[from:to] {
+				list = append(list, s.view())
+			}
+			writeJSON(w, http.StatusOK, list)
+		case http.MethodPost:
+			b.createSubscription(w, r, t)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		}
+		return
+	}
+	if 1 < len(segs) {
+		writeError(w, http.StatusNotFound, orion.ErrorCodeBadRequest, "service not found")
+		return
+	}
+
+	i := t.subscriptionIndex(segs[0])
+	if i < 0 {
+		writeError(w, http.StatusNotFound, orion.ErrorCodeNotFound, subscriptionNotFoundDescription)
+		return
+	}
+	s := t.subscriptions[i]
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, s.view())
+	case http.MethodPatch:
+		body, err := readBody(r)
+		if err != nil {
+			writeHTTPError(w, err)
+			return
+		}
+		var u orion.SubscriptionUpdate
+		if err := json.Unmarshal(body, &u); err != nil {
+			writeError(w, http.StatusBadRequest, "ParseError", err.Error())
+			return
+		}
+		if err := u.Validate(); err != nil {
+			badRequest(w, err.Error())
+			return
+		}
+		s.update(&u)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		t.subscriptions = append(t.subscriptions[:i], t.subscriptions[i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPatch, http.MethodDelete)
+	}
+}
+
+// subscriptionIndex returns index of the subscription that has given id. -1 when not found.
+func (t *tenant) subscriptionIndex(id string) int {
+	for i, s := range t.subscriptions {
+		if *s.Id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// createSubscription serves POST /v2/subscriptions.
+func (b *Broker) createSubscription(w http.ResponseWriter, r *request, t *tenant) {
+	body, err := readBody(r)
+	if err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	var s subscription
+	if err := json.Unmarshal(body, &s.Subscription); err != nil {
+		writeError(w, http.StatusBadRequest, "ParseError", err.Error())
+		return
+	}
+	if err := s.Validate(); err != nil {
+		badRequest(w, err.Error())
+		return
+	}
+	if _, err := s.expressionFilter(); err != nil {
+		writeHTTPError(w, err)
+		return
+	}
+	id := newID()
+	s.Id = &id
+	if s.Status == nil {
+		status := orion.SubscriptionStatusActive
+		s.Status = &status
+	}
+	s.servicePath = r.readScope()
+	s.Notification.TimesSent, s.Notification.LastNotification, s.Notification.FailsCounter = nil, nil, nil
+	s.Notification.LastSuccess, s.Notification.LastSuccessCode = nil, nil
+	s.Notification.LastFailure, s.Notification.LastFailureReason = nil, nil
+	t.subscriptions = append(t.subscriptions, &s)
+	w.Header().Set("Location", "/v2/subscriptions/"+id)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// view returns the subscription as GET returns. Status is expired when it has expired.
+func (s *subscription) view() orion.Subscription {
+	v := s.Subscription
+	if s.expired(time.Now()) {
+		status := orion.SubscriptionStatusExpired
+		v.Status = &status
+	}
+	return v
+}
+
+func (s *subscription) expired(now time.Time) bool {
+	return s.Expires != nil && s.Expires.Before(now)
+}
+
+// update modifies the subscription by given update.
+func (s *subscription) update(u *orion.SubscriptionUpdate) {
+	if u.Description != nil {
+		s.Description = u.Description
+	}
+	if u.Subject != nil {
+		s.Subject = *u.Subject
+	}
+	if u.Notification != nil {
+		n := *u.Notification
+		n.TimesSent, n.LastNotification, n.FailsCounter = s.Notification.TimesSent, s.Notification.LastNotification, s.Notification.FailsCounter
+		n.LastSuccess, n.LastSuccessCode = s.Notification.LastSuccess, s.Notification.LastSuccessCode
+		n.LastFailure, n.LastFailureReason = s.Notification.LastFailure, s.Notification.LastFailureReason
+		s.Notification = n
+	}
+	if u.Expires != nil {
+		s.Expires = u.Expires
+	}
+	if u.Throttling != nil {
+		s.Throttling = u.Throttling
+	}
+	if u.Status != nil {
+		s.Status = u.Status
+	}
+}
+
+// expressionFilter returns filter given by the condition expression. nil when no expression.
+func (s *subscription) expressionFilter() (*entityFilter, error) {
+	c := s.Subject.Condition
+	if c == nil {
+		return nil, nil
+	}
+	ex := c.GetExpression()
+	if ex == (orion.SubscriptionExpression{}) {
+		return nil, nil
+	}
+	return newEntityFilter(url.Values{
+		"q": {ex.Q}, "mq": {ex.MQ}, "georel": {ex.Georel}, "geometry": {ex.Geometry}, "coords": {ex.Coords},
+	}, common.HierarchicalWildcard)
+}
+
+// matchSubject returns whether given entity is one of the subject entities.
+func (s *subscription) matchSubject(e *entity) bool {
+	for _, se := range s.Subject.Entities {
+		f, err := newEntityFilter(url.Values{
+			"id": {se["id"]}, "idPattern": {se["idPattern"]}, "type": {se["type"]}, "typePattern": {se["typePattern"]},
+		}, common.HierarchicalWildcard)
+		if err == nil && f.match(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// triggered returns whether the change of given entity triggers the subscription.
+func (s *subscription) triggered(e *entity, changed []string, now time.Time) bool {
+	if *s.Status != orion.SubscriptionStatusActive || s.expired(now) {
+		return false
+	}
+	if !matchScope(s.servicePath, e.servicePath) || !s.matchSubject(e) {
+		return false
+	}
+	if c := s.Subject.Condition; c != nil && c.Attrs != nil && 0 < len(*c.Attrs) {
+		hit := false
+		for _, n := range changed {
+			if contains(*c.Attrs, n) {
+				hit = true
+				break
+			}
+		}
+		if !hit {
+			return false
+		}
+	}
+	if f, err := s.expressionFilter(); err != nil || (f != nil && !f.match(e)) {
+		return false
+	}
+	n := s.Notification
+	if s.Throttling != nil && n.LastNotification != nil && now.Sub(*n.LastNotification) < time.Duration(*s.Throttling)*time.Second {
+		return false
+	}
+	return true
+}
+
+// notifiedEntity returns copy of given entity that has attributes to be notified.
+func (s *subscription) notifiedEntity(e *entity, changed []string) *ngsi.Entity {
+	n := s.Notification
+	c := ngsi.NewEntity(e.ID, e.Type)
+	for _, name := range e.Names() {
+		if n.Attrs != nil && 0 < len(*n.Attrs) && !contains(*n.Attrs, name) {
+			continue
+		}
+		if n.ExceptAttrs != nil && contains(*n.ExceptAttrs, name) {
+			continue
+		}
+		if n.OnlyChangedAttrs != nil && *n.OnlyChangedAttrs && !contains(changed, name) {
+			continue
+		}
+		a, _ := e.Get(name)
+		c.Set(name, a)
+	}
+	return c
+}
+
+// notify sends notifications of the subscriptions triggered by the change of given entity. Nothing is notified when
+// no attributes are changed unless force is set. Have to be called with the lock.
+func (b *Broker) notify(r *request, t *tenant, e *entity, changed []string, force bool) {
+	if len(changed) <= 0 && !force {
+		return
+	}
+	now := time.Now()
+	for _, s := range t.subscriptions {
+		if !s.triggered(e, changed, now) {
+			continue
+		}
+		s.Notification.LastNotification = &now
+		sent := int64(1)
+		if s.Notification.TimesSent != nil {
+			sent += *s.Notification.TimesSent
+		}
+		s.Notification.TimesSent = &sent
+
+		format := orion.AttrsFormatNormalized
+		if s.Notification.AttrsFormat != nil {
+			format = *s.Notification.AttrsFormat
+		}
+		t.notifications++
+		m := &message{
+			sub:          s,
+			id:           *s.Id,
+			notification: s.Notification,
+			service:      r.service,
+			servicePath:  e.servicePath,
+			correlator:   fmt.Sprintf("%s; cbnotif=%d", r.correlator, t.notifications),
+			format:       format,
+			entity:       s.notifiedEntity(e, changed),
+		}
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+			status, err := b.send(m)
+			b.record(m.sub, status, err)
+		}()
+	}
+}
+
+// record records the result of the notification into the subscription.
+func (b *Broker) record(s *subscription, status int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	n := &s.Notification
+	if err == nil {
+		n.LastSuccess = &now
+		if 0 < status {
+			n.LastSuccessCode = &status
+		}
+		n.FailsCounter = nil
+		return
+	}
+	reason := err.Error()
+	fails := int64(1)
+	if n.FailsCounter != nil {
+		fails += *n.FailsCounter
+	}
+	n.LastFailure, n.LastFailureReason, n.FailsCounter = &now, &reason, &fails
+}
+
+// data returns notified entity in the format of the subscription.
+func (m *message) data() (json.RawMessage, error) {
+	switch m.format {
+	case orion.AttrsFormatKeyValues:
+		return m.entity.MarshalKeyValues()
+	case orion.AttrsFormatValues:
+		return json.Marshal(attrValues(m.entity))
+	}
+	return json.Marshal(m.entity)
+}
+
+// payload returns body and content type of the notification.
+func (m *message) payload(custom string, js interface{}, fragment *map[string]interface{}) ([]byte, string, error) {
+	switch {
+	case 0 < len(custom):
+		return []byte(m.expand(custom)), orion.ContentTypeText, nil
+	case js != nil:
+		b, err := json.Marshal(m.expandJSON(js))
+		return b, orion.ContentTypeJSON, err
+	case fragment != nil:
+		e := ngsi.NewEntity(m.entity.ID, m.entity.Type)
+		for _, n := range m.entity.Names() {
+			a, _ := m.entity.Get(n)
+			e.Set(n, a)
+		}
+		b, err := json.Marshal(m.expandJSON(*fragment))
+		if err != nil {
+			return nil, "", err
+		}
+		f, err := decodeEntity(b, false)
+		if err != nil {
+			return nil, "", err
+		}
+		if 0 < len(f.ID) {
+			e.ID = f.ID
+		}
+		if 0 < len(f.Type) {
+			e.Type = f.Type
+		}
+		setAttrs(e, f, false)
+		m.entity = e
+	}
+	data, err := m.data()
+	if err != nil {
+		return nil, "", err
+	}
+	b, err := json.Marshal(notify.Notification{SubscriptionID: m.id, Data: []json.RawMessage{data}})
+	return b, orion.ContentTypeJSON, err
+}
+
+// macro returns the value of ${name} macro.
+func (m *message) macro(name string) (interface{}, bool) {
+	switch name {
+	case "id":
+		return m.entity.ID, true
+	case "type":
+		return m.entity.Type, true
+	case "service":
+		return m.service, true
+	case "servicePath":
+		return m.servicePath, true
+	}
+	if a, ok := m.entity.Get(name); ok {
+		return a.Value, true
+	}
+	return nil, false
+}
+
+// expand replaces ${...} macros in given text.
+func (m *message) expand(s string) string {
+	return reMacro.ReplaceAllStringFunc(s, func(mac string) string {
+		v, ok := m.macro(mac[2 : len(mac)-1])
+		if !ok || v == nil {
+			return ""
+		}
+		if str, ok := v.(string); ok {
+			return str
+		}
+		b, _ := json.Marshal(v)
+		return string(b)
+	})
+}
+
+// expandJSON replaces macros in the strings of given JSON value. A string that is a single macro is replaced by the
+// value itself.
+func (m *message) expandJSON(v interface{}) interface{} {
+	switch x := v.(type) {
+	case string:
+		if loc := reMacro.FindStringIndex(x); loc != nil && loc[0] == 0 && loc[1] == len(x) {
+			mv, _ := m.macro(x[2 : len(x)-1])
+			return mv
+		}
+		return m.expand(x)
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(x))
+		for k, e := range x {
+			c[k] = m.expandJSON(e)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(x))
+		for i, e := range x {
+			c[i] = m.expandJSON(e)
+		}
+		return c
+	}
+	return v
+}
+
+// send sends the notification. Returns http status code for http notifications.
+func (b *Broker) send(m *message) (int, error) {
+	n := m.notification
+	switch {
+	case n.Mqtt != nil:
+		body, _, err := m.payload("", nil, nil)
+		if err != nil {
+			return 0, err
+		}
+		return 0, b.publish(n.Mqtt.Url, m.expand(n.Mqtt.Topic), n.Mqtt.Qos, body)
+	case n.MqttCustom != nil:
+		mc := n.MqttCustom
+		var custom string
+		if mc.Payload != nil {
+			custom = *mc.Payload
+		}
+		body, _, err := m.payload(custom, mc.Json, mc.Ngsi)
+		if err != nil {
+			return 0, err
+		}
+		return 0, b.publish(mc.Url, m.expand(mc.Topic), mc.Qos, body)
+	case n.HttpCustom != nil:
+		return b.sendHTTP(m, n.HttpCustom)
+	}
+	return b.sendHTTP(m, &orion.SubscriptionHttpCustom{Url: n.Http.Url})
+}
+
+func (b *Broker) publish(url, topic string, qos *int, payload []byte) error {
+	if b.MQTT == nil {
+		return fmt.Errorf("no mqtt publisher")
+	}
+	q := 0
+	if qos != nil {
+		q = *qos
+	}
+	return b.MQTT(url, topic, q, payload)
+}
+
+// sendHTTP sends http notification.
+func (b *Broker) sendHTTP(m *message, hc *orion.SubscriptionHttpCustom) (int, error) {
+	var custom string
+	if hc.Payload != nil {
+		custom = *hc.Payload
+	}
+	body, contentType, err := m.payload(custom, hc.Json, hc.Ngsi)
+	if err != nil {
+		return 0, err
+	}
+
+	u, err := url.Parse(m.expand(hc.Url))
+	if err != nil {
+		return 0, err
+	}
+	if hc.Qs != nil {
+		q := u.Query()
+		for _, qs := range *hc.Qs {
+			for k, v := range qs {
+				q.Set(m.expand(k), m.expand(v))
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+	method := http.MethodPost
+	if hc.Method != nil {
+		method = *hc.Method
+	}
+
+	ctx := context.Background()
+	if hc.Timeout != nil && 0 < *hc.Timeout {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(*hc.Timeout)*time.Millisecond)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "orion/"+Version)
+	if 0 < len(m.service) {
+		req.Header.Set(common.ServiceHeader, m.service)
+	}
+	req.Header.Set(common.ServicePathHeader, m.servicePath)
+	req.Header.Set(common.CorrelatorHeader, m.correlator)
+	req.Header.Set(notify.AttrsFormatHeader, m.format)
+	if hc.Headers != nil {
+		for _, h := range *hc.Headers {
+			for k, v := range h {
+				req.Header.Set(k, m.expand(v))
+			}
+		}
+	}
+
+	res, err := b.HttpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || 300 <= res.StatusCode {
+		return res.StatusCode, fmt.Errorf("HTTP %d %s", res.StatusCode, strings.TrimSpace(http.StatusText(res.StatusCode)))
+	}
+	return res.StatusCode, nil
+}
@@ -0,0 +1,158 @@
+// fiware orion context provider
+// https://fiware-orion.readthedocs.io/en/master/user/context_providers.html
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/go-fiware-api/ngsi"
+	"github.com/marrbor/go-fiware-api/orion"
+)
+
+const (
+	QueryPath  = orion.OperationsURL + orion.BatchQueryPath  // /v2/op/query
+	UpdatePath = orion.OperationsURL + orion.BatchUpdatePath // /v2/op/update
+
+	// DefaultMaxBodySize is a limit of forwarded request payload.
+	DefaultMaxBodySize = orion.MaxBatchPayloadSize
+)
+
+var (
+	// NotFoundError is returned by the provider when it does not have requested entities or attributes.
+	NotFoundError = fmt.Errorf("not found")
+)
+
+// Provider provides entities and attributes that Orion forwards requests for.
+// fiware-service, fiware-servicepath and fiware-correlator of the forwarded request are available through
+// common.ServiceFromContext, common.ServicePathFromContext and common.CorrelatorFromContext, and traceparent and
+// tracestate through common.TraceParentFromContext and common.TraceStateFromContext.
+// They are propagated when the context is passed to accessors.
+type Provider interface {
+	// Query returns entities that match the query. Attributes have to be in normalized format.
+	Query(ctx context.Context, q *orion.BatchQuery) ([]ngsi.Entity, error)
+	// Update applies given entities with specified action.
+	Update(ctx context.Context, actionType orion.ActionType, entities []ngsi.Entity) error
+}
+
+// Handler is an http.Handler that serves POST /v2/op/query and POST /v2/op/update forwarded by Orion.
+type Handler struct {
+	Provider    Provider
+	MaxBodySize int64
+}
+
+// NewHandler returns new Handler instance that uses given provider.
+func NewHandler(p Provider) *Handler {
+	return &Handler{Provider: p, MaxBodySize: DefaultMaxBodySize}
+}
+
+// ngsiError is an error payload of NGSIv2.
+type ngsiError struct {
+	Error       string `json:"error"`
+	Description string `json:"description"`
+}
+
+// writeError writes NGSIv2 error response.
+func writeError(w http.ResponseWriter, status int, code string, err error) {
+	w.Header().Set("Content-Type", orion.ContentTypeJSON)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ngsiError{Error: code, Description: err.Error()})
+}
+
+// writeProviderError writes error returned by the provider.
+func writeProviderError(w http.ResponseWriter, err error) {
+	if errors.Is(err, NotFoundError) {
+		writeError(w, http.StatusNotFound, orion.ErrorCodeNotFound, err)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, orion.ErrorCodeInternalServerError, err)
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var serve func(context.Context, http.ResponseWriter, []byte)
+	switch {
+	case strings.HasSuffix(r.URL.Path, orion.BatchQueryPath):
+		serve = h.serveQuery
+	case strings.HasSuffix(r.URL.Path, orion.BatchUpdatePath):
+		serve = h.serveUpdate
+	default:
+		writeError(w, http.StatusNotFound, orion.ErrorCodeNotFound, fmt.Errorf("no such path %s", r.URL.Path))
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, orion.ErrorCodeMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	limit := h.MaxBodySize
+	if limit <= 0 {
+		limit = DefaultMaxBodySize
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(r.Body, limit+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, orion.ErrorCodeBadRequest, err)
+		return
+	}
+	if limit < int64(len(b)) {
+		writeError(w, http.StatusRequestEntityTooLarge, orion.ErrorCodeRequestEntityTooLarge, fmt.Errorf("payload too large"))
+		return
+	}
+
+	ctx := common.ContextFromHeader(r.Context(), r.Header)
+	serve(ctx, w, b)
+}
+
+// serveQuery handles POST /v2/op/query.
+func (h *Handler) serveQuery(ctx context.Context, w http.ResponseWriter, b []byte) {
+	var q orion.BatchQuery
+	if err := json.Unmarshal(b, &q); err != nil {
+		writeError(w, http.StatusBadRequest, orion.ErrorCodeBadRequest, err)
+		return
+	}
+	es, err := h.Provider.Query(ctx, &q)
+	if err != nil {
+		writeProviderError(w, err)
+		return
+	}
+	if es == nil {
+		es = []ngsi.Entity{}
+	}
+	res, err := json.Marshal(es)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, orion.ErrorCodeInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", orion.ContentTypeJSON)
+	_, _ = w.Write(res)
+}
+
+// serveUpdate handles POST /v2/op/update.
+func (h *Handler) serveUpdate(ctx context.Context, w http.ResponseWriter, b []byte) {
+	var body struct {
+		ActionType string        `json:"actionType"`
+		Entities   []ngsi.Entity `json:"entities"`
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		writeError(w, http.StatusBadRequest, orion.ErrorCodeBadRequest, err)
+		return
+	}
+	at, err := orion.ParseActionType(body.ActionType)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, orion.ErrorCodeBadRequest, err)
+		return
+	}
+	if err := h.Provider.Update(ctx, at, body.Entities); err != nil {
+		writeProviderError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,154 @@
+package provider_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/go-fiware-api/ngsi"
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
+	"github.com/marrbor/go-fiware-api/orion/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+// weather provides temperature of rooms.
+type weather struct {
+	mu      sync.Mutex
+	temps   map[string]float64
+	service string
+	query   *orion.BatchQuery
+}
+
+func (p *weather) Query(ctx context.Context, q *orion.BatchQuery) ([]ngsi.Entity, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.service = common.ServiceFromContext(ctx)
+	p.query = q
+	var es []ngsi.Entity
+	for _, e := range q.Entities {
+		t, ok := p.temps[e.ID]
+		if !ok {
+			continue
+		}
+		es = append(es, *ngsi.NewEntity(e.ID, e.Type).Set("temperature", ngsi.NewNumber(t)))
+	}
+	if len(es) <= 0 {
+		return nil, provider.NotFoundError
+	}
+	return es, nil
+}
+
+func (p *weather) Update(ctx context.Context, actionType orion.ActionType, entities []ngsi.Entity) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if actionType != orion.ActionTypes.Update {
+		return fmt.Errorf("unsupported action %s", actionType)
+	}
+	for _, e := range entities {
+		a, ok := e.Get("temperature")
+		if !ok {
+			continue
+		}
+		f, err := a.AsFloat()
+		if err != nil {
+			return err
+		}
+		p.temps[e.ID] = f
+	}
+	return nil
+}
+
+func newWeather() *weather {
+	return &weather{temps: map[string]float64{"Room1": 23.5}}
+}
+
+func request(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Fiware-Service", "openiot")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestHandler_Query(t *testing.T) {
+	p := newWeather()
+	h := provider.NewHandler(p)
+
+	w := request(h, http.MethodPost, "/v2/op/query", `{"entities":[{"id":"Room1","type":"Room"}],"attrs":["temperature"]}`)
+	assert.EqualValues(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"id":"Room1","type":"Room","temperature":{"type":"Number","value":23.5}}]`, w.Body.String())
+	assert.EqualValues(t, "openiot", p.service)
+	assert.EqualValues(t, []string{"temperature"}, p.query.Attrs)
+
+	w = request(h, http.MethodPost, "/v2/op/query", `{"entities":[{"id":"Room9","type":"Room"}]}`)
+	assert.EqualValues(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"error":"NotFound","description":"not found"}`, w.Body.String())
+
+	w = request(h, http.MethodPost, "/v2/op/query", `{"entities":`)
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+	w = request(h, http.MethodGet, "/v2/op/query", ``)
+	assert.EqualValues(t, http.StatusMethodNotAllowed, w.Code)
+	w = request(h, http.MethodPost, "/v2/entities", `{}`)
+	assert.EqualValues(t, http.StatusNotFound, w.Code)
+}
+
+func TestHandler_Update(t *testing.T) {
+	p := newWeather()
+	h := provider.NewHandler(p)
+
+	w := request(h, http.MethodPost, "/v2/op/update", `{"actionType":"update","entities":[{"id":"Room1","type":"Room","temperature":{"type":"Number","value":25}}]}`)
+	assert.EqualValues(t, http.StatusNoContent, w.Code)
+	assert.EqualValues(t, 25, p.temps["Room1"])
+
+	w = request(h, http.MethodPost, "/v2/op/update", `{"actionType":"delete","entities":[{"id":"Room1","type":"Room"}]}`)
+	assert.EqualValues(t, http.StatusInternalServerError, w.Code)
+	w = request(h, http.MethodPost, "/v2/op/update", `{"actionType":"upsert","entities":[]}`)
+	assert.EqualValues(t, http.StatusBadRequest, w.Code)
+}
+
+func TestServer_Serve(t *testing.T) {
+	ts := oriontest.NewServer()
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	s := &provider.Server{
+		URL:          fmt.Sprintf("http://%s/v2", l.Addr()),
+		Provider:     newWeather(),
+		Accessor:     a,
+		DataProvided: orion.RegistrationDataProvided{Entities: []map[string]string{{"id": "Room1", "type": "Room"}}, Attrs: []string{"temperature"}},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- s.Serve(ctx, l)
+	}()
+
+	// wait for the registration, then query the provider like Orion does.
+	var list []orion.Registration
+	assert.Eventually(t, func() bool {
+		return a.GetRegistrationList("", "", nil, &list) == nil && 0 < len(list)
+	}, time.Second, 10*time.Millisecond)
+	assert.EqualValues(t, 1, len(list))
+	assert.EqualValues(t, s.URL, list[0].Provider.Http.Url)
+	assert.EqualValues(t, orion.ForwardingModeAll, list[0].Provider.SupportedForwardingMode)
+	res, err := http.Post(s.URL+"/op/query", orion.ContentTypeJSON, strings.NewReader(`{"entities":[{"id":"Room1","type":"Room"}]}`))
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	_ = res.Body.Close()
+
+	cancel()
+	assert.NoError(t, <-done)
+	assert.NoError(t, a.GetRegistrationList("", "", nil, &list))
+	assert.EqualValues(t, 0, len(list))
+}
@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/marrbor/go-fiware-api/orion"
+)
+
+const (
+	// ShutdownTimeout is a time limit to unregister and stop the server after the context is done.
+	ShutdownTimeout = 10 * time.Second
+)
+
+// Registration is a registration of this process as a context provider.
+type Registration struct {
+	a           *orion.Accessor
+	service     string
+	servicePath string
+	id          string
+}
+
+// Register creates registration whose provider.http.url is providerURL and dataProvided is given one.
+// providerURL is the base of this provider that Orion appends /op/query or /op/update to, e.g. http://myhost:8080/v2.
+func Register(ctx context.Context, a *orion.Accessor, service, servicePath, providerURL string, dp orion.RegistrationDataProvided) (*Registration, error) {
+	u, err := url.Parse(providerURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, orion.InvalidRegistrationError
+	}
+	id, err := a.CreateRegistrationCtx(ctx, service, servicePath, &orion.Registration{
+		Description: "context provider " + providerURL,
+		Provider: orion.RegistrationProvider{
+			Http:                    orion.Http{Url: providerURL},
+			SupportedForwardingMode: orion.ForwardingModeAll,
+		},
+		DataProvided: dp,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Registration{a: a, service: service, servicePath: servicePath, id: id}, nil
+}
+
+// ID returns registration ID.
+func (r *Registration) ID() string {
+	return r.id
+}
+
+// Unregister deletes the registration.
+func (r *Registration) Unregister(ctx context.Context) error {
+	return r.a.DeleteRegistrationCtx(ctx, r.service, r.servicePath, r.id)
+}
+
+// Server serves the provider and keeps it registered while running.
+type Server struct {
+	Addr         string // listen address, e.g. :8080
+	URL          string // provider url Orion calls, e.g. http://myhost:8080/v2
+	Provider     Provider
+	Accessor     *orion.Accessor
+	Service      string
+	ServicePath  string
+	DataProvided orion.RegistrationDataProvided
+}
+
+// Run listens, registers the provider and serves until given context is done. Then unregisters and stops.
+func (s *Server) Run(ctx context.Context) error {
+	l, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, l)
+}
+
+// Serve is Run with given listener.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	hs := &http.Server{Handler: NewHandler(s.Provider)}
+	served := make(chan error, 1)
+	go func() {
+		served <- hs.Serve(l)
+	}()
+
+	reg, err := Register(ctx, s.Accessor, s.Service, s.ServicePath, s.URL, s.DataProvided)
+	if err != nil {
+		_ = hs.Close()
+		<-served
+		return err
+	}
+
+	select {
+	case err = <-served: // server stopped unexpectedly.
+	case <-ctx.Done():
+	}
+
+	sctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	uerr := reg.Unregister(sctx)
+	if err == nil {
+		if serr := hs.Shutdown(sctx); serr != nil {
+			err = serr
+		} else {
+			err = <-served
+		}
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	return uerr
+}
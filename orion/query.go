@@ -42,6 +42,11 @@ func (q *Query) IsExists(k string) bool {
 	return ok
 }
 
+// GetQuery returns value of given key. Returns empty string when the key does not exist.
+func (q *Query) GetQuery(k string) string {
+	return q.queries[k]
+}
+
 // SetQuery sets given query strings (key & value) into this instance.
 // If the same key has been already exists, replace it.
 func (q *Query) SetQuery(k, v string) *Query {
@@ -117,6 +122,7 @@ func (q *Query) SetTypePatternQuery(re string) error {
 // q A query expression, composed of a list of statements separated by ;, i.e., q=statement1;statement2;statement3.
 // See [Simple Query Language specification](https://jsapi.apiary.io/previews/null/reference/entities/list-entities/list-entities#simple_query_language).
 // Example: temperature>40.
+// Use SetQExpression to validate the expression before sending.
 func (q *Query) SetQQuery(list []string) *Query {
 	return q.SetQuery("q", strings.Join(list, ";"))
 }
@@ -125,6 +131,7 @@ func (q *Query) SetQQuery(list []string) *Query {
 // mq A query expression for attribute metadata, composed of a list of statements separated by ;, i.e., mq=statement1;statement2;statement3.
 // See [Simple Query Language specification](https://jsapi.apiary.io/previews/null/reference/entities/list-entities/list-entities#simple_query_language).
 // Example: temperature.accuracy<0.9.
+// Use SetMQExpression to validate the expression before sending.
 func (q *Query) SetMQQuery(list []string) *Query {
 	return q.SetQuery("mq", strings.Join(list, ";"))
 }
@@ -132,9 +139,12 @@ func (q *Query) SetMQQuery(list []string) *Query {
 // SetGeoRelQuery sets given string as georel query strings into this instance.
 // georel Spatial relationship between matching entities and a reference shape.
 // See [Geographical Queries](https://jsapi.apiary.io/previews/null/reference/entities/list-entities/list-entities#geographical_queries).
-// Example: near.
-// TODO check whether given string is suit to parameter or not.
+// Example: near;maxDistance:1000.
+// Use SetGeoQuery to set georel, geometry and coords together.
 func (q *Query) SetGeorelQuery(gq string) error {
+	if _, err := parseGeorel(gq); err != nil {
+		return err
+	}
 	q.SetQuery("georel", gq)
 	return nil
 }
@@ -143,8 +153,11 @@ func (q *Query) SetGeorelQuery(gq string) error {
 // geometry Geografical area to which the query is restricted.
 // See [Geographical Queries](https://jsapi.apiary.io/previews/null/reference/entities/list-entities/list-entities#geographical_queries).
 // Example: point.
-// TODO check whether given string is suit to parameter or not.
+// Use SetGeoQuery to set georel, geometry and coords together.
 func (q *Query) SetGeometryQuery(gm string) error {
+	if _, err := parseGeometry(gm); err != nil {
+		return err
+	}
 	q.SetQuery("geometry", gm)
 	return nil
 }
@@ -202,7 +215,7 @@ func (q *Query) SetOrderBy(ob []string) *Query {
 }
 
 // SetOptions sets query options.
-// Possible values:  count , keyValues , values , unique .
+// Possible values:  count , keyValues , values , unique , append , noAttrDetail .
 func (q *Query) SetOptions(list []Option) *Query {
 	var opts []string
 	for _, o := range list {
@@ -212,6 +225,43 @@ func (q *Query) SetOptions(list []Option) *Query {
 	return q
 }
 
+// AddOptions adds given options into current options of this instance. Options that already set are ignored.
+func (q *Query) AddOptions(list []Option) *Query {
+	var opts []string
+	if q.IsExists("options") {
+		opts = strings.Split(q.queries["options"], ",")
+	}
+	for _, o := range list {
+		if !q.HasOption(o) {
+			opts = append(opts, o.value)
+		}
+	}
+	q.SetQuery("options", strings.Join(opts, ","))
+	return q
+}
+
+// HasOption returns whether given option has been set into this instance or not.
+func (q *Query) HasOption(o Option) bool {
+	if !q.IsExists("options") {
+		return false
+	}
+	for _, v := range strings.Split(q.queries["options"], ",") {
+		if v == o.value {
+			return true
+		}
+	}
+	return false
+}
+
+// Clone returns a copy of this instance.
+func (q *Query) Clone() *Query {
+	c := NewQuery()
+	for k, v := range q.queries {
+		c.queries[k] = v
+	}
+	return c
+}
+
 // NewQuery returns new (empty) Query instance.
 func NewQuery() *Query {
 	return &Query{queries: make(map[string]string)}
@@ -230,16 +280,18 @@ type (
 var (
 	// QueryOptions holds possible query option value.
 	QueryOptions = struct {
-		Count     Option
-		KeyValues Option
-		Values    Option
-		Unique    Option
-		Append    Option
+		Count        Option
+		KeyValues    Option
+		Values       Option
+		Unique       Option
+		Append       Option
+		NoAttrDetail Option
 	}{
-		Count:     Option{"count"},
-		KeyValues: Option{"keyValues"},
-		Values:    Option{"values"},
-		Unique:    Option{"unique"},
-		Append:    Option{"append"},
+		Count:        Option{"count"},
+		KeyValues:    Option{"keyValues"},
+		Values:       Option{"values"},
+		Unique:       Option{"unique"},
+		Append:       Option{"append"},
+		NoAttrDetail: Option{"noAttrDetail"},
 	}
 )
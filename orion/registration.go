@@ -1,19 +1,36 @@
 /* fiware orion registration api
  * https://fiware.github.io/specifications/ngsiv2/stable/
- * TODO not tested yet
  */
 package orion
 
 import (
+	"context"
 	"fmt"
+	"path"
 	"time"
 
 	"github.com/marrbor/gohttp"
 )
 
+const (
+	RegistrationStatusActive   = "active"
+	RegistrationStatusInactive = "inactive"
+
+	ForwardingModeNone   = "none"
+	ForwardingModeQuery  = "query"
+	ForwardingModeUpdate = "update"
+	ForwardingModeAll    = "all"
+)
+
+var (
+	EmptyRegistrationUpdateError = fmt.Errorf("nothing to update")
+	InvalidRegistrationError     = fmt.Errorf("invalid registration")
+)
+
 type RegistrationProvider struct {
 	Http                    Http   `json:"http"`
 	SupportedForwardingMode string `json:"supportedForwardingMode,omitempty"`
+	LegacyForwarding        *bool  `json:"legacyForwarding,omitempty"`
 }
 
 type RegistrationDataProvided struct {
@@ -23,39 +40,127 @@ type RegistrationDataProvided struct {
 }
 
 type RegistrationForwardingInformation struct {
-	TimesSent        int64     `json:"timesSent,omitempty"`        // not editable, only present in GET operations
-	LastNotification time.Time `json:"lastNotification,omitempty"` // not editable, only present in GET operations
-	LastFailure      time.Time `json:"lastFailure,omitempty"`      // not editable, only present in GET operations
-	LastSuccess      time.Time `json:"lastSuccess,omitempty"`      // not editable, only present in GET operations
+	TimesSent      int64      `json:"timesSent,omitempty"`      // not editable, only present in GET operations
+	LastForwarding *time.Time `json:"lastForwarding,omitempty"` // not editable, only present in GET operations
+	LastFailure    *time.Time `json:"lastFailure,omitempty"`    // not editable, only present in GET operations
+	LastSuccess    *time.Time `json:"lastSuccess,omitempty"`    // not editable, only present in GET operations
 }
 
 type Registration struct {
-	Id                    string                            `json:"id,omitempty"`
-	Description           string                            `json:"description,omitempty"`
-	Provider              RegistrationProvider              `json:"provider"`
-	DataProvided          RegistrationDataProvided          `json:"dataProvided"`
-	Status                string                            `json:"status,omitempty"`
-	Expires               time.Time                         `json:"expires,omitempty"`
-	ForwardingInformation RegistrationForwardingInformation `json:"forwardingInformation,omitempty"`
+	Id                    string                             `json:"id,omitempty"`
+	Description           string                             `json:"description,omitempty"`
+	Provider              RegistrationProvider               `json:"provider"`
+	DataProvided          RegistrationDataProvided           `json:"dataProvided"`
+	Status                string                             `json:"status,omitempty"`
+	Expires               *time.Time                         `json:"expires,omitempty"`
+	ForwardingInformation *RegistrationForwardingInformation `json:"forwardingInformation,omitempty"`
 }
 
-// CreateRegistration
-func (a Accessor) CreateRegistration(service, servicePath string, q *Query, registration interface{}) error {
-	return a.access(&AccessParameter{
+// RegistrationUpdate holds fields to be modified by PATCH /v2/registrations/{id}. Nil fields are left as they are.
+type RegistrationUpdate struct {
+	Description  *string                   `json:"description,omitempty"`
+	Provider     *RegistrationProvider     `json:"provider,omitempty"`
+	DataProvided *RegistrationDataProvided `json:"dataProvided,omitempty"`
+	Status       *string                   `json:"status,omitempty"`
+	Expires      *time.Time                `json:"expires,omitempty"`
+}
+
+// Validate checks whether this provider is acceptable or not.
+func (p *RegistrationProvider) Validate() error {
+	if len(p.Http.Url) <= 0 {
+		return fmt.Errorf("%w: provider requires http url", InvalidRegistrationError)
+	}
+	switch p.SupportedForwardingMode {
+	case "", ForwardingModeNone, ForwardingModeQuery, ForwardingModeUpdate, ForwardingModeAll:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown forwarding mode %s", InvalidRegistrationError, p.SupportedForwardingMode)
+}
+
+// Validate checks whether this data provided is acceptable or not.
+func (dp *RegistrationDataProvided) Validate() error {
+	if len(dp.Entities) <= 0 {
+		return fmt.Errorf("%w: dataProvided requires entities", InvalidRegistrationError)
+	}
+	return nil
+}
+
+// validateRegistrationStatus checks given status is settable by clients.
+func validateRegistrationStatus(status string) error {
+	switch status {
+	case "", RegistrationStatusActive, RegistrationStatusInactive:
+		return nil
+	}
+	return fmt.Errorf("%w: unknown status %s", InvalidRegistrationError, status)
+}
+
+// Validate checks whether this registration is acceptable or not.
+func (r *Registration) Validate() error {
+	if err := r.Provider.Validate(); err != nil {
+		return err
+	}
+	if err := r.DataProvided.Validate(); err != nil {
+		return err
+	}
+	return validateRegistrationStatus(r.Status)
+}
+
+// Validate checks whether this update is acceptable or not.
+func (u *RegistrationUpdate) Validate() error {
+	if u.Description == nil && u.Provider == nil && u.DataProvided == nil && u.Status == nil && u.Expires == nil {
+		return EmptyRegistrationUpdateError
+	}
+	if u.Provider != nil {
+		if err := u.Provider.Validate(); err != nil {
+			return err
+		}
+	}
+	if u.DataProvided != nil {
+		if err := u.DataProvided.Validate(); err != nil {
+			return err
+		}
+	}
+	if u.Status != nil {
+		return validateRegistrationStatus(*u.Status)
+	}
+	return nil
+}
+
+// CreateRegistration post request to create registration and return registration ID or error.
+func (a Accessor) CreateRegistration(service, servicePath string, registration *Registration) (string, error) {
+	return a.CreateRegistrationCtx(context.Background(), service, servicePath, registration)
+}
+
+// CreateRegistrationCtx is CreateRegistration with given context.
+func (a Accessor) CreateRegistrationCtx(ctx context.Context, service, servicePath string, registration *Registration) (string, error) {
+	if err := registration.Validate(); err != nil {
+		return "", err
+	}
+	ap := AccessParameter{
+		Ctx:          ctx,
 		EpID:         EntryPointIDs.Registrations,
 		Method:       gohttp.HttpMethods.POST,
 		Service:      service,
 		ServicePath:  servicePath,
 		Path:         "",
-		Query:        q,
 		BodyToSend:   registration,
 		ReceivedBody: nil,
-	})
+	}
+	if err := a.access(&ap); err != nil {
+		return "", err
+	}
+	return path.Base(ap.ReceivedHeader.Get("Location")), nil
+}
+
+// GetRegistrationList gets registration list. limit and offset of q are used for pagination. q can be nil.
+func (a Accessor) GetRegistrationList(service, servicePath string, q *Query, registrations *[]Registration) error {
+	return a.GetRegistrationListCtx(context.Background(), service, servicePath, q, registrations)
 }
 
-// GetRegistrationList
-func (a Accessor) GetRegistrationList(service, servicePath string, q *Query, registrations interface{}) error {
+// GetRegistrationListCtx is GetRegistrationList with given context.
+func (a Accessor) GetRegistrationListCtx(ctx context.Context, service, servicePath string, q *Query, registrations *[]Registration) error {
 	return a.access(&AccessParameter{
+		Ctx:          ctx,
 		EpID:         EntryPointIDs.Registrations,
 		Method:       gohttp.HttpMethods.GET,
 		Service:      service,
@@ -68,72 +173,62 @@ func (a Accessor) GetRegistrationList(service, servicePath string, q *Query, reg
 }
 
 // GetRegistration gets registration that has specified ID.
-func (a *Accessor) GetRegistration(service, servicePath, id string, q *Query, registration interface{}) error {
-	return a.access(&AccessParameter{
-		EpID:         EntryPointIDs.Entities,
-		Method:       gohttp.HttpMethods.GET,
-		Service:      service,
-		ServicePath:  servicePath,
-		Path:         fmt.Sprintf("/%s", id),
-		Query:        q,
-		BodyToSend:   nil,
-		ReceivedBody: registration,
-	})
+func (a *Accessor) GetRegistration(service, servicePath, id string, registration *Registration) error {
+	return a.GetRegistrationCtx(context.Background(), service, servicePath, id, registration)
 }
 
-// GetRegistrationAttribute gets specified attribute of specified registration.
-func (a *Accessor) GetRegistrationAttribute(service, servicePath, id, attrName string, q *Query, attr interface{}) error {
+// GetRegistrationCtx gets registration that has specified ID with given context.
+func (a *Accessor) GetRegistrationCtx(ctx context.Context, service, servicePath, id string, registration *Registration) error {
 	return a.access(&AccessParameter{
-		EpID:         EntryPointIDs.Entities,
+		Ctx:          ctx,
+		EpID:         EntryPointIDs.Registrations,
 		Method:       gohttp.HttpMethods.GET,
 		Service:      service,
 		ServicePath:  servicePath,
-		Path:         fmt.Sprintf("/%s/attrs/%s", id, attrName),
-		Query:        q,
+		Path:         fmt.Sprintf("/%s", id),
 		BodyToSend:   nil,
-		ReceivedBody: attr,
+		ReceivedBody: registration,
 	})
 }
 
 /// Update
 
-// UpdateRegistration updates registration
-func (a *Accessor) UpdateRegistration(service, servicePath, id, typeName string, param interface{}) error {
-	return a.access(&AccessParameter{
-		EpID:         EntryPointIDs.Entities,
-		Method:       gohttp.HttpMethods.PATCH,
-		Service:      service,
-		ServicePath:  servicePath,
-		Path:         fmt.Sprintf("/%s/attrs", id),
-		Query:        NewQuery().SetQuery("type", typeName),
-		BodyToSend:   param,
-		ReceivedBody: nil,
-	})
+// UpdateRegistration updates fields of registration that are set in given update.
+func (a *Accessor) UpdateRegistration(service, servicePath, id string, update *RegistrationUpdate) error {
+	return a.UpdateRegistrationCtx(context.Background(), service, servicePath, id, update)
 }
 
-// UpdateRegistrationAttribute updates or append registration attribute.
-func (a *Accessor) UpdateRegistrationAttribute(service, servicePath, id, attrName string, q *Query, attr interface{}) error {
+// UpdateRegistrationCtx updates fields of registration with given context.
+func (a *Accessor) UpdateRegistrationCtx(ctx context.Context, service, servicePath, id string, update *RegistrationUpdate) error {
+	if err := update.Validate(); err != nil {
+		return err
+	}
 	return a.access(&AccessParameter{
-		EpID:         EntryPointIDs.Entities,
-		Method:       gohttp.HttpMethods.POST,
+		Ctx:          ctx,
+		EpID:         EntryPointIDs.Registrations,
+		Method:       gohttp.HttpMethods.PATCH,
 		Service:      service,
 		ServicePath:  servicePath,
-		Path:         fmt.Sprintf("/%s/attrs", id),
-		Query:        q,
-		BodyToSend:   attr,
+		Path:         fmt.Sprintf("/%s", id),
+		BodyToSend:   update,
 		ReceivedBody: nil,
 	})
 }
 
 /// Delete
-func (a *Accessor) DeleteRegistration(service, servicePath, id, typeName string) error {
+func (a *Accessor) DeleteRegistration(service, servicePath, id string) error {
+	return a.DeleteRegistrationCtx(context.Background(), service, servicePath, id)
+}
+
+// DeleteRegistrationCtx is DeleteRegistration with given context.
+func (a *Accessor) DeleteRegistrationCtx(ctx context.Context, service, servicePath, id string) error {
 	return a.access(&AccessParameter{
-		EpID:         EntryPointIDs.Entities,
+		Ctx:          ctx,
+		EpID:         EntryPointIDs.Registrations,
 		Method:       gohttp.HttpMethods.DELETE,
 		Service:      service,
 		ServicePath:  servicePath,
 		Path:         fmt.Sprintf("/%s", id),
-		Query:        NewQuery().SetQuery("type", typeName),
 		BodyToSend:   nil,
 		ReceivedBody: nil,
 	})
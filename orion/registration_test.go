@@ -0,0 +1,91 @@
+package orion_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
+	"github.com/stretchr/testify/assert"
+)
+
+func genRegistration() *orion.Registration {
+	return &orion.Registration{
+		Description: "Weather provider",
+		Provider: orion.RegistrationProvider{
+			Http:                    orion.Http{Url: "http://provider:8080/v2"},
+			SupportedForwardingMode: orion.ForwardingModeQuery,
+		},
+		DataProvided: orion.RegistrationDataProvided{
+			Entities: []map[string]string{{"id": "Room1", "type": "Room"}},
+			Attrs:    []string{"temperature"},
+		},
+	}
+}
+
+func TestAccessor_CRUDRegistration(t *testing.T) {
+	var bodies []string
+	ts := oriontest.NewServer()
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+	a.Middlewares = []common.Middleware{common.MiddlewareFuncs{BeforeFunc: func(ex *common.Exchange) error {
+		if 0 < len(ex.Body) {
+			bodies = append(bodies, string(ex.Body))
+		}
+		return nil
+	}}}
+
+	id, err := a.CreateRegistration("", "", genRegistration())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, id)
+	// zero time fields are omitted.
+	assert.JSONEq(t, `{"description":"Weather provider","provider":{"http":{"url":"http://provider:8080/v2"},"supportedForwardingMode":"query"},`+
+		`"dataProvided":{"entities":[{"id":"Room1","type":"Room"}],"attrs":["temperature"]}}`, bodies[0])
+
+	var reg orion.Registration
+	assert.NoError(t, a.GetRegistration("", "", id, &reg))
+	assert.EqualValues(t, id, reg.Id)
+	assert.EqualValues(t, "http://provider:8080/v2", reg.Provider.Http.Url)
+	assert.Nil(t, reg.Expires)
+
+	expires := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	status := orion.RegistrationStatusInactive
+	assert.NoError(t, a.UpdateRegistration("", "", id, &orion.RegistrationUpdate{Status: &status, Expires: &expires}))
+	assert.JSONEq(t, `{"status":"inactive","expires":"2030-01-01T00:00:00Z"}`, bodies[1])
+	assert.NoError(t, a.GetRegistration("", "", id, &reg))
+	assert.EqualValues(t, orion.RegistrationStatusInactive, reg.Status)
+	assert.True(t, expires.Equal(*reg.Expires))
+
+	_, err = a.CreateRegistration("", "", genRegistration())
+	assert.NoError(t, err)
+	var list []orion.Registration
+	assert.NoError(t, a.GetRegistrationList("", "", nil, &list))
+	assert.EqualValues(t, 2, len(list))
+
+	assert.NoError(t, a.DeleteRegistration("", "", id))
+	err = a.GetRegistration("", "", id, &reg)
+	assert.True(t, orion.IsNotFound(err))
+	assert.True(t, orion.IsNotFound(a.DeleteRegistration("", "", id)))
+}
+
+func TestRegistration_Validate(t *testing.T) {
+	r := genRegistration()
+	assert.NoError(t, r.Validate())
+	r.Provider.SupportedForwardingMode = "push"
+	assert.Error(t, r.Validate())
+
+	r = genRegistration()
+	r.Provider.Http.Url = ""
+	assert.Error(t, r.Validate())
+
+	r = genRegistration()
+	r.DataProvided.Entities = nil
+	assert.Error(t, r.Validate())
+
+	r = genRegistration()
+	r.Status = "expired"
+	assert.Error(t, r.Validate())
+
+	assert.EqualError(t, (&orion.RegistrationUpdate{}).Validate(), orion.EmptyRegistrationUpdateError.Error())
+}
@@ -0,0 +1,497 @@
+// fiware orion simple query language
+// https://fiware.github.io/specifications/ngsiv2/stable/ #Simple Query Language
+package orion
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marrbor/go-fiware-api/ngsi"
+)
+
+const (
+	statementSeparator = ";"
+	listSeparator      = ","
+	rangeSeparator     = ".."
+	pathSeparator      = "."
+	quote              = "'"
+
+	// characters that cannot be used in attribute names or paths.
+	forbiddenPathChars = "<>\"'=;()&?/#!~,:"
+)
+
+var (
+	InvalidExpressionError = fmt.Errorf("invalid query expression")
+)
+
+// Operator
+type (
+	Operator struct{ value string }
+)
+
+// String returns operator strings.
+func (o Operator) String() string {
+	return o.value
+}
+
+var (
+	// Operators holds possible operators of simple query language statement.
+	Operators = struct {
+		Exists         Operator
+		NotExists      Operator
+		Equal          Operator
+		NotEqual       Operator
+		Greater        Operator
+		GreaterOrEqual Operator
+		Less           Operator
+		LessOrEqual    Operator
+		Match          Operator
+	}{
+		Exists:         Operator{""},
+		NotExists:      Operator{"!"},
+		Equal:          Operator{"=="},
+		NotEqual:       Operator{"!="},
+		Greater:        Operator{">"},
+		GreaterOrEqual: Operator{">="},
+		Less:           Operator{"<"},
+		LessOrEqual:    Operator{"<="},
+		Match:          Operator{"~="},
+	}
+
+	// binary operators in the order to be tried by the parser. longer one first.
+	binaryOperators = []Operator{
+		Operators.Equal, Operators.NotEqual, Operators.GreaterOrEqual, Operators.LessOrEqual, Operators.Match,
+		Operators.Greater, Operators.Less,
+	}
+)
+
+type (
+	// Statement is a condition of simple query language, such as `temperature>40`.
+	// Path holds attribute name and sub keys (q) or attribute name, metadata name and sub keys (mq).
+	// Values holds literals as they appear in the query. Strings may be enclosed in single quotes.
+	Statement struct {
+		Path     []string
+		Operator Operator
+		Values   []string
+		Range    bool // Values[0]..Values[1]
+	}
+
+	// Expression is a list of statements. Entities have to match all statements.
+	Expression struct {
+		Statements []Statement
+	}
+)
+
+// Exists returns statement that matches entities have specified attribute.
+func Exists(path string) Statement {
+	return Statement{Path: splitPath(path), Operator: Operators.Exists}
+}
+
+// NotExists returns statement that matches entities do not have specified attribute.
+func NotExists(path string) Statement {
+	return Statement{Path: splitPath(path), Operator: Operators.NotExists}
+}
+
+// Equal returns statement that matches entities whose value is equal to one of given values.
+func Equal(path string, values ...interface{}) Statement {
+	return newStatement(path, Operators.Equal, values...)
+}
+
+// NotEqual returns statement that matches entities whose value is equal to none of given values.
+func NotEqual(path string, values ...interface{}) Statement {
+	return newStatement(path, Operators.NotEqual, values...)
+}
+
+// Greater returns statement that matches entities whose value is greater than given value.
+func Greater(path string, value interface{}) Statement {
+	return newStatement(path, Operators.Greater, value)
+}
+
+// GreaterOrEqual returns statement that matches entities whose value is greater than or equal to given value.
+func GreaterOrEqual(path string, value interface{}) Statement {
+	return newStatement(path, Operators.GreaterOrEqual, value)
+}
+
+// Less returns statement that matches entities whose value is less than given value.
+func Less(path string, value interface{}) Statement {
+	return newStatement(path, Operators.Less, value)
+}
+
+// LessOrEqual returns statement that matches entities whose value is less than or equal to given value.
+func LessOrEqual(path string, value interface{}) Statement {
+	return newStatement(path, Operators.LessOrEqual, value)
+}
+
+// Range returns statement that matches entities whose value is between from and to (both inclusive).
+func Range(path string, from, to interface{}) Statement {
+	s := newStatement(path, Operators.Equal, from, to)
+	s.Range = true
+	return s
+}
+
+// OutOfRange returns statement that matches entities whose value is not between from and to.
+func OutOfRange(path string, from, to interface{}) Statement {
+	s := newStatement(path, Operators.NotEqual, from, to)
+	s.Range = true
+	return s
+}
+
+// Match returns statement that matches entities whose string value matches given regular expression.
+func Match(path, pattern string) Statement {
+	return Statement{Path: splitPath(path), Operator: Operators.Match, Values: []string{pattern}}
+}
+
+func newStatement(path string, op Operator, values ...interface{}) Statement {
+	s := Statement{Path: splitPath(path), Operator: op}
+	for _, v := range values {
+		s.Values = append(s.Values, literal(v))
+	}
+	return s
+}
+
+// splitPath splits dotted path. A segment enclosed in single quotes may contain dots, e.g. `'a.b'.c`.
+func splitPath(path string) []string {
+	var segs []string
+	for 0 < len(path) {
+		var seg string
+		if strings.HasPrefix(path, quote) {
+			end := strings.Index(path[1:], quote)
+			if end < 0 {
+				return append(segs, path) // unterminated quote. Validate reports it.
+			}
+			seg, path = path[1:end+1], path[end+2:]
+		} else if i := strings.Index(path, pathSeparator); 0 <= i {
+			seg, path = path[:i], path[i:]
+		} else {
+			seg, path = path, ""
+		}
+		segs = append(segs, seg)
+		if strings.HasPrefix(path, pathSeparator) {
+			path = path[1:]
+			if len(path) <= 0 {
+				segs = append(segs, "") // trailing dot. Validate reports it.
+			}
+		}
+	}
+	return segs
+}
+
+// literal converts given value into literal of simple query language.
+func literal(v interface{}) string {
+	switch vv := v.(type) {
+	case string:
+		return quoteIfNeeded(vv)
+	case *string:
+		if vv == nil {
+			return "null"
+		}
+		return quoteIfNeeded(*vv)
+	case time.Time:
+		return vv.UTC().Format(ngsi.DateTimeFormat)
+	case float64:
+		return strconv.FormatFloat(vv, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(vv), 'f', -1, 32)
+	case nil:
+		return "null"
+	}
+	return fmt.Sprint(v)
+}
+
+// quoteIfNeeded encloses given string in single quotes when it would be parsed as another literal or list/range.
+func quoteIfNeeded(s string) string {
+	if len(s) <= 0 || strings.ContainsAny(s, listSeparator+statementSeparator) || strings.Contains(s, rangeSeparator) {
+		return quote + s + quote
+	}
+	if s == "true" || s == "false" || s == "null" {
+		return quote + s + quote
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return quote + s + quote
+	}
+	return s
+}
+
+// Unquote returns literal without enclosing single quotes.
+func Unquote(lit string) string {
+	if 2 <= len(lit) && strings.HasPrefix(lit, quote) && strings.HasSuffix(lit, quote) {
+		return lit[1 : len(lit)-1]
+	}
+	return lit
+}
+
+func invalidExpression(format string, a ...interface{}) error {
+	return fmt.Errorf("%w: %s", InvalidExpressionError, fmt.Sprintf(format, a...))
+}
+
+// Validate checks this statement.
+func (s Statement) Validate() error {
+	if len(s.Path) <= 0 {
+		return invalidExpression("no attribute")
+	}
+	for _, seg := range s.Path {
+		if len(seg) <= 0 {
+			return invalidExpression("empty path segment")
+		}
+		if strings.ContainsAny(seg, forbiddenPathChars) {
+			return invalidExpression("forbidden character in %q", seg)
+		}
+		for _, c := range seg {
+			if c <= ' ' || c == 0x7f {
+				return invalidExpression("forbidden character in %q", seg)
+			}
+		}
+	}
+
+	switch s.Operator {
+	case Operators.Exists, Operators.NotExists:
+		if 0 < len(s.Values) {
+			return invalidExpression("unary statement cannot have values")
+		}
+		return nil
+	case Operators.Match:
+		if len(s.Values) != 1 || len(s.Values[0]) <= 0 {
+			return invalidExpression("%s requires a pattern", s.Operator)
+		}
+		if strings.Contains(s.Values[0], statementSeparator) {
+			return invalidExpression("pattern cannot contain %q", statementSeparator)
+		}
+		return nil
+	case Operators.Equal, Operators.NotEqual:
+		if len(s.Values) <= 0 {
+			return invalidExpression("%s requires values", s.Operator)
+		}
+		if s.Range && len(s.Values) != 2 {
+			return invalidExpression("range requires 2 values")
+		}
+	case Operators.Greater, Operators.GreaterOrEqual, Operators.Less, Operators.LessOrEqual:
+		if len(s.Values) != 1 || s.Range {
+			return invalidExpression("%s requires a single value", s.Operator)
+		}
+	default:
+		return invalidExpression("unknown operator %q", s.Operator)
+	}
+
+	for _, v := range s.Values {
+		if err := validateLiteral(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateLiteral checks a literal is a quoted string or an unquoted token which does not contain separators.
+func validateLiteral(lit string) error {
+	if len(lit) <= 0 {
+		return invalidExpression("empty value")
+	}
+	if strings.HasPrefix(lit, quote) {
+		if len(lit) < 2 || !strings.HasSuffix(lit, quote) {
+			return invalidExpression("unterminated quote in %s", lit)
+		}
+		if strings.Contains(lit[1:len(lit)-1], quote) {
+			return invalidExpression("value cannot contain single quote: %s", lit)
+		}
+		return nil
+	}
+	if strings.ContainsAny(lit, quote+listSeparator+statementSeparator) || strings.Contains(lit, rangeSeparator) {
+		return invalidExpression("value has to be quoted: %s", lit)
+	}
+	return nil
+}
+
+// String returns canonical form of this statement.
+func (s Statement) String() string {
+	segs := make([]string, len(s.Path))
+	for i, seg := range s.Path {
+		if strings.Contains(seg, pathSeparator) {
+			seg = quote + seg + quote
+		}
+		segs[i] = seg
+	}
+	path := strings.Join(segs, pathSeparator)
+
+	switch s.Operator {
+	case Operators.Exists:
+		return path
+	case Operators.NotExists:
+		return Operators.NotExists.value + path
+	}
+	sep := listSeparator
+	if s.Range {
+		sep = rangeSeparator
+	}
+	return path + s.Operator.value + strings.Join(s.Values, sep)
+}
+
+// NewExpression returns new Expression instance that has given statements.
+func NewExpression(statements ...Statement) *Expression {
+	return &Expression{Statements: statements}
+}
+
+// And adds given statements.
+func (e *Expression) And(statements ...Statement) *Expression {
+	e.Statements = append(e.Statements, statements...)
+	return e
+}
+
+// Validate checks all statements.
+func (e *Expression) Validate() error {
+	if len(e.Statements) <= 0 {
+		return invalidExpression("no statement")
+	}
+	for _, s := range e.Statements {
+		if err := s.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns canonical form of this expression.
+func (e *Expression) String() string {
+	list := make([]string, len(e.Statements))
+	for i, s := range e.Statements {
+		list[i] = s.String()
+	}
+	return strings.Join(list, statementSeparator)
+}
+
+// ParseExpression parses given q or mq string and returns validated expression.
+// `:` is accepted as a synonym of `==`.
+func ParseExpression(str string) (*Expression, error) {
+	stmts, err := splitOutsideQuotes(str, statementSeparator)
+	if err != nil {
+		return nil, err
+	}
+	e := NewExpression()
+	for _, stmt := range stmts {
+		s, err := parseStatement(stmt)
+		if err != nil {
+			return nil, err
+		}
+		e.And(s)
+	}
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// splitOutsideQuotes splits given string with sep that is not enclosed in single quotes.
+func splitOutsideQuotes(str, sep string) ([]string, error) {
+	var list []string
+	inQuote := false
+	start := 0
+	for i := 0; i < len(str); i++ {
+		if str[i] == quote[0] {
+			inQuote = !inQuote
+			continue
+		}
+		if !inQuote && strings.HasPrefix(str[i:], sep) {
+			list = append(list, str[start:i])
+			i += len(sep) - 1
+			start = i + 1
+		}
+	}
+	if inQuote {
+		return nil, invalidExpression("unterminated quote in %s", str)
+	}
+	return append(list, str[start:]), nil
+}
+
+// parseStatement parses a statement.
+func parseStatement(stmt string) (Statement, error) {
+	if len(stmt) <= 0 {
+		return Statement{}, invalidExpression("empty statement")
+	}
+	if strings.HasPrefix(stmt, Operators.NotExists.value) {
+		return Statement{Path: splitPath(stmt[1:]), Operator: Operators.NotExists}, nil
+	}
+
+	// find the operator outside of quoted path segments.
+	inQuote := false
+	for i := 0; i < len(stmt); i++ {
+		if stmt[i] == quote[0] {
+			inQuote = !inQuote
+			continue
+		}
+		if inQuote {
+			continue
+		}
+		if stmt[i] == ':' {
+			return parseValues(stmt[:i], Operators.Equal, stmt[i+1:])
+		}
+		for _, op := range binaryOperators {
+			if strings.HasPrefix(stmt[i:], op.value) {
+				return parseValues(stmt[:i], op, stmt[i+len(op.value):])
+			}
+		}
+	}
+	return Statement{Path: splitPath(stmt), Operator: Operators.Exists}, nil
+}
+
+// parseValues parses right hand side of a binary statement.
+func parseValues(path string, op Operator, rhs string) (Statement, error) {
+	s := Statement{Path: splitPath(path), Operator: op}
+	if op == Operators.Match {
+		s.Values = []string{rhs}
+		return s, nil
+	}
+	values, err := splitOutsideQuotes(rhs, listSeparator)
+	if err != nil {
+		return Statement{}, err
+	}
+	if len(values) == 1 {
+		r, err := splitOutsideQuotes(rhs, rangeSeparator)
+		if err != nil {
+			return Statement{}, err
+		}
+		if len(r) == 2 {
+			s.Range = true
+			values = r
+		} else if 2 < len(r) {
+			return Statement{}, invalidExpression("invalid range %s", rhs)
+		}
+	}
+	s.Values = values
+	return s, nil
+}
+
+// SetQExpression validates given expression and sets it as q query.
+func (q *Query) SetQExpression(e *Expression) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	q.SetQuery("q", e.String())
+	return nil
+}
+
+// SetMQExpression validates given expression and sets it as mq query.
+func (q *Query) SetMQExpression(e *Expression) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	q.SetQuery("mq", e.String())
+	return nil
+}
+
+// SetQExpression validates given expression and sets it as q.
+func (ex *BatchQueryExpression) SetQExpression(e *Expression) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	ex.Q = e.String()
+	return nil
+}
+
+// SetMQExpression validates given expression and sets it as mq.
+func (ex *BatchQueryExpression) SetMQExpression(e *Expression) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	ex.MQ = e.String()
+	return nil
+}
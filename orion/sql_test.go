@@ -0,0 +1,95 @@
+package orion_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpression_String(t *testing.T) {
+	e := orion.NewExpression(
+		orion.Greater("temperature", 40),
+		orion.Equal("color", "red", "blue"),
+		orion.NotEqual("status", "1.0"),
+		orion.Range("humidity", 10.5, 20),
+		orion.Exists("pressure"),
+		orion.NotExists("location"),
+		orion.Match("name", "^Room.*"),
+		orion.LessOrEqual("address.zip", 100),
+		orion.Equal("'a.b'.c", true),
+		orion.GreaterOrEqual("dateObserved", time.Date(2020, 4, 1, 9, 0, 0, 0, time.UTC)),
+	)
+	assert.NoError(t, e.Validate())
+	assert.EqualValues(t, "temperature>40;color==red,blue;status!='1.0';humidity==10.5..20;pressure;!location;name~=^Room.*;address.zip<=100;'a.b'.c==true;dateObserved>=2020-04-01T09:00:00.000Z", e.String())
+
+	assert.EqualValues(t, "a!=1..2", orion.OutOfRange("a", 1, 2).String())
+	assert.EqualValues(t, "a=='x,y'", orion.Equal("a", "x,y").String())
+}
+
+func TestExpression_Validate(t *testing.T) {
+	for _, s := range []orion.Statement{
+		orion.Greater("", 1),
+		orion.Greater("a..b", 1),
+		orion.Greater("a b", 1),
+		orion.Equal("a"),
+		orion.Equal("a", "it's"),
+		orion.Match("a", ""),
+		{Path: []string{"a"}, Operator: orion.Operators.Less, Values: []string{"1", "2"}},
+		{Path: []string{"a"}, Operator: orion.Operators.Equal, Values: []string{"1"}, Range: true},
+		{Path: []string{"a"}, Operator: orion.Operators.Exists, Values: []string{"1"}},
+	} {
+		err := orion.NewExpression(s).Validate()
+		assert.True(t, errors.Is(err, orion.InvalidExpressionError), s.String())
+	}
+	assert.True(t, errors.Is(orion.NewExpression().Validate(), orion.InvalidExpressionError))
+}
+
+func TestParseExpression(t *testing.T) {
+	for _, tc := range []struct {
+		in, out string
+	}{
+		{"temperature>40", "temperature>40"},
+		{"color:red,blue", "color==red,blue"},
+		{"humidity==10..20;!location", "humidity==10..20;!location"},
+		{"temperature.accuracy<0.9", "temperature.accuracy<0.9"},
+		{"name=='a;b'", "name=='a;b'"},
+		{"'a.b'.c>=1", "'a.b'.c>=1"},
+		{"name~=^Ro.m,[0-9]+", "name~=^Ro.m,[0-9]+"},
+		{"pressure", "pressure"},
+	} {
+		e, err := orion.ParseExpression(tc.in)
+		assert.NoError(t, err, tc.in)
+		assert.EqualValues(t, tc.out, e.String())
+	}
+
+	e, err := orion.ParseExpression("a==1..5;b!=x,'y'")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(e.Statements))
+	assert.EqualValues(t, []string{"a"}, e.Statements[0].Path)
+	assert.EqualValues(t, orion.Operators.Equal, e.Statements[0].Operator)
+	assert.True(t, e.Statements[0].Range)
+	assert.EqualValues(t, []string{"1", "5"}, e.Statements[0].Values)
+	assert.EqualValues(t, orion.Operators.NotEqual, e.Statements[1].Operator)
+	assert.EqualValues(t, "y", orion.Unquote(e.Statements[1].Values[1]))
+
+	for _, in := range []string{"", "a>", "a>1,2", "a=1", "a==1..2..3", "a=='b", "a;;b", ".a>1", "a.>1", "a<1..2", "a b==1"} {
+		_, err := orion.ParseExpression(in)
+		assert.True(t, errors.Is(err, orion.InvalidExpressionError), in)
+	}
+}
+
+func TestQuery_SetQExpression(t *testing.T) {
+	q := orion.NewQuery()
+	assert.NoError(t, q.SetQExpression(orion.NewExpression(orion.Greater("temperature", 40))))
+	assert.EqualValues(t, "temperature>40", q.GetQuery("q"))
+	assert.NoError(t, q.SetMQExpression(orion.NewExpression(orion.Less("temperature.accuracy", 0.9))))
+	assert.EqualValues(t, "temperature.accuracy<0.9", q.GetQuery("mq"))
+	assert.Error(t, q.SetQExpression(orion.NewExpression(orion.Greater("", 40))))
+
+	var ex orion.BatchQueryExpression
+	assert.NoError(t, ex.SetQExpression(orion.NewExpression(orion.Exists("a"))))
+	assert.EqualValues(t, "a", ex.Q)
+}
@@ -4,16 +4,43 @@
 package orion
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/marrbor/gohttp"
 )
 
+const (
+	SubscriptionStatusActive   = "active"
+	SubscriptionStatusInactive = "inactive"
+	SubscriptionStatusFailed   = "failed"  // not editable, only present in GET operations
+	SubscriptionStatusExpired  = "expired" // not editable, only present in GET operations
+
+	AttrsFormatNormalized = "normalized"
+	AttrsFormatKeyValues  = "keyValues"
+	AttrsFormatValues     = "values"
+	AttrsFormatLegacy     = "legacy"
+)
+
+var (
+	EmptySubscriptionUpdateError    = fmt.Errorf("nothing to update")
+	InvalidSubscriptionError        = fmt.Errorf("invalid subscription")
+	InvalidSubscriptionStatusError  = fmt.Errorf("invalid subscription status")
+	InvalidSubscriptionPayloadError = fmt.Errorf("only one of payload, json and ngsi can be specified")
+)
+
+// SubscriptionExpression has the same fields as the expression of batch query. Use SetQExpression and SetGeoQuery to build it.
+type SubscriptionExpression = BatchQueryExpression
+
 type SubscriptionSubjectCondition struct {
-	Attrs      *[]string `json:"attrs,omitempty"`
-	Expression *string   `json:"expression,omitempty"`
+	Attrs      *[]string              `json:"attrs,omitempty"`
+	Expression *string                `json:"-"` // q of the expression. Overrides Q of Filter when set.
+	Filter     SubscriptionExpression `json:"-"` // whole expression including mq and geo query.
 }
 
 type SubscriptionSubject struct {
@@ -22,24 +49,53 @@ type SubscriptionSubject struct {
 }
 
 type SubscriptionHttpCustom struct {
-	Url     string               `json:"url"`
-	Headers *[]map[string]string `json:"headers,omitempty"`
-	Qs      *[]map[string]string `json:"qs,omitempty"`
-	Method  *string              `json:"method,omitempty"`
-	Payload *string              `json:"payload,omitempty"`
+	Url     string                  `json:"url"`
+	Headers *[]map[string]string    `json:"headers,omitempty"` // merged into an object when sent.
+	Qs      *[]map[string]string    `json:"qs,omitempty"`      // merged into an object when sent.
+	Method  *string                 `json:"method,omitempty"`
+	Payload *string                 `json:"payload,omitempty"`
+	Json    interface{}             `json:"json,omitempty"`
+	Ngsi    *map[string]interface{} `json:"ngsi,omitempty"`    // entity fragment merged into the notified entity. attributes are in normalized format.
+	Timeout *int64                  `json:"timeout,omitempty"` // milliseconds
+}
+
+type SubscriptionMqtt struct {
+	Url    string  `json:"url"` // e.g. mqtt://localhost:1883
+	Topic  string  `json:"topic"`
+	Qos    *int    `json:"qos,omitempty"` // 0, 1 or 2
+	User   *string `json:"user,omitempty"`
+	Passwd *string `json:"passwd,omitempty"`
+}
+
+type SubscriptionMqttCustom struct {
+	Url     string                  `json:"url"`
+	Topic   string                  `json:"topic"`
+	Qos     *int                    `json:"qos,omitempty"`
+	User    *string                 `json:"user,omitempty"`
+	Passwd  *string                 `json:"passwd,omitempty"`
+	Payload *string                 `json:"payload,omitempty"`
+	Json    interface{}             `json:"json,omitempty"`
+	Ngsi    *map[string]interface{} `json:"ngsi,omitempty"`
 }
 
-// TODO. add optional attributes `attrsFormat`
 type SubscriptionNotification struct {
-	Attrs            *[]string               `json:"attrs,omitempty"`
-	MetaData         *[]string               `json:"metadata,omitempty"`
-	ExceptAttrs      *[]string               `json:"exceptAttrs,omitempty"`
-	Http             *Http                   `json:"http,omitempty"`
-	HttpCustom       *SubscriptionHttpCustom `json:"httpCustom,omitempty"`
-	TimesSent        *int64                  `json:"timesSent,omitempty"`        // not editable, only present in GET operations
-	LastNotification *time.Time              `json:"lastNotification,omitempty"` // not editable, only present in GET operations
-	LastFailure      *time.Time              `json:"lastFailure,omitempty"`      // not editable, only present in GET operations
-	LastSuccess      *time.Time              `json:"lastSuccess,omitempty"`      // not editable, only present in GET operations
+	Attrs             *[]string               `json:"attrs,omitempty"`
+	ExceptAttrs       *[]string               `json:"exceptAttrs,omitempty"`
+	MetaData          *[]string               `json:"metadata,omitempty"`
+	AttrsFormat       *string                 `json:"attrsFormat,omitempty"`
+	OnlyChangedAttrs  *bool                   `json:"onlyChangedAttrs,omitempty"`
+	Covered           *bool                   `json:"covered,omitempty"`
+	Http              *Http                   `json:"http,omitempty"`
+	HttpCustom        *SubscriptionHttpCustom `json:"httpCustom,omitempty"`
+	Mqtt              *SubscriptionMqtt       `json:"mqtt,omitempty"`
+	MqttCustom        *SubscriptionMqttCustom `json:"mqttCustom,omitempty"`
+	TimesSent         *int64                  `json:"timesSent,omitempty"`         // not editable, only present in GET operations
+	LastNotification  *time.Time              `json:"lastNotification,omitempty"`  // not editable, only present in GET operations
+	LastFailure       *time.Time              `json:"lastFailure,omitempty"`       // not editable, only present in GET operations
+	LastFailureReason *string                 `json:"lastFailureReason,omitempty"` // not editable, only present in GET operations
+	LastSuccess       *time.Time              `json:"lastSuccess,omitempty"`       // not editable, only present in GET operations
+	LastSuccessCode   *int                    `json:"lastSuccessCode,omitempty"`   // not editable, only present in GET operations
+	FailsCounter      *int64                  `json:"failsCounter,omitempty"`      // not editable, only present in GET operations
 }
 
 type Subscription struct {
@@ -52,9 +108,225 @@ type Subscription struct {
 	Throttling   *int64                   `json:"throttling,omitempty"`
 }
 
+// SubscriptionUpdate holds fields to be modified by PATCH /v2/subscriptions/{id}. Nil fields are left as they are.
+type SubscriptionUpdate struct {
+	Description  *string                   `json:"description,omitempty"`
+	Subject      *SubscriptionSubject      `json:"subject,omitempty"`
+	Notification *SubscriptionNotification `json:"notification,omitempty"`
+	Expires      *time.Time                `json:"expires,omitempty"`
+	Throttling   *int64                    `json:"throttling,omitempty"`
+	Status       *string                   `json:"status,omitempty"`
+}
+
+// GetExpression returns the expression sent to Orion, which is Filter with Q overridden by Expression.
+func (c *SubscriptionSubjectCondition) GetExpression() SubscriptionExpression {
+	ex := c.Filter
+	if c.Expression != nil {
+		ex.Q = *c.Expression
+	}
+	return ex
+}
+
+// MarshalJSON encodes the expression as an object. It is omitted when empty.
+func (c SubscriptionSubjectCondition) MarshalJSON() ([]byte, error) {
+	type plain SubscriptionSubjectCondition
+	v := struct {
+		plain
+		Expression *SubscriptionExpression `json:"expression,omitempty"`
+	}{plain: plain(c)}
+	if ex := c.GetExpression(); ex != (SubscriptionExpression{}) {
+		v.Expression = &ex
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes the expression object into Filter, and its q into Expression.
+func (c *SubscriptionSubjectCondition) UnmarshalJSON(b []byte) error {
+	type plain SubscriptionSubjectCondition
+	v := struct {
+		*plain
+		Expression SubscriptionExpression `json:"expression"`
+	}{plain: (*plain)(c)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	c.Filter, c.Expression = v.Expression, nil
+	if 0 < len(v.Expression.Q) {
+		q := v.Expression.Q
+		c.Expression = &q
+	}
+	return nil
+}
+
+// MarshalJSON encodes headers and qs as objects Orion expects.
+func (hc SubscriptionHttpCustom) MarshalJSON() ([]byte, error) {
+	type plain SubscriptionHttpCustom
+	return json.Marshal(struct {
+		plain
+		Headers map[string]string `json:"headers,omitempty"`
+		Qs      map[string]string `json:"qs,omitempty"`
+	}{plain: plain(hc), Headers: mergeMaps(hc.Headers), Qs: mergeMaps(hc.Qs)})
+}
+
+// UnmarshalJSON decodes headers and qs objects into a list that has a map.
+func (hc *SubscriptionHttpCustom) UnmarshalJSON(b []byte) error {
+	type plain SubscriptionHttpCustom
+	v := struct {
+		*plain
+		Headers map[string]string `json:"headers"`
+		Qs      map[string]string `json:"qs"`
+	}{plain: (*plain)(hc)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	hc.Headers, hc.Qs = splitMap(v.Headers), splitMap(v.Qs)
+	return nil
+}
+
+// mergeMaps merges given list of maps into a map. Later keys win. nil when no keys.
+func mergeMaps(l *[]map[string]string) map[string]string {
+	if l == nil {
+		return nil
+	}
+	var merged map[string]string
+	for _, m := range *l {
+		for k, v := range m {
+			if merged == nil {
+				merged = make(map[string]string)
+			}
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// splitMap returns a list that has given map. nil when the map is nil.
+func splitMap(m map[string]string) *[]map[string]string {
+	if m == nil {
+		return nil
+	}
+	return &[]map[string]string{m}
+}
+
+// Validate checks whether this notification is acceptable or not.
+func (n *SubscriptionNotification) Validate() error {
+	endpoints := 0
+	for _, set := range []bool{n.Http != nil, n.HttpCustom != nil, n.Mqtt != nil, n.MqttCustom != nil} {
+		if set {
+			endpoints++
+		}
+	}
+	if endpoints != 1 {
+		return fmt.Errorf("%w: one of http, httpCustom, mqtt and mqttCustom is required", InvalidSubscriptionError)
+	}
+	if n.Attrs != nil && n.ExceptAttrs != nil {
+		return fmt.Errorf("%w: attrs and exceptAttrs are exclusive", InvalidSubscriptionError)
+	}
+	if n.AttrsFormat != nil {
+		switch *n.AttrsFormat {
+		case AttrsFormatNormalized, AttrsFormatKeyValues, AttrsFormatValues, AttrsFormatLegacy:
+		default:
+			return fmt.Errorf("%w: unknown attrsFormat %s", InvalidSubscriptionError, *n.AttrsFormat)
+		}
+	}
+	if hc := n.HttpCustom; hc != nil {
+		if err := validateCustomPayload(hc.Payload, hc.Json, hc.Ngsi); err != nil {
+			return err
+		}
+	}
+	if m := n.Mqtt; m != nil {
+		if err := validateMqtt(m.Url, m.Topic, m.Qos); err != nil {
+			return err
+		}
+	}
+	if mc := n.MqttCustom; mc != nil {
+		if err := validateMqtt(mc.Url, mc.Topic, mc.Qos); err != nil {
+			return err
+		}
+		if err := validateCustomPayload(mc.Payload, mc.Json, mc.Ngsi); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateCustomPayload checks that at most one of payload, json and ngsi is specified.
+func validateCustomPayload(payload *string, js interface{}, ngsi *map[string]interface{}) error {
+	payloads := 0
+	if payload != nil {
+		payloads++
+	}
+	if js != nil {
+		payloads++
+	}
+	if ngsi != nil {
+		payloads++
+	}
+	if 1 < payloads {
+		return InvalidSubscriptionPayloadError
+	}
+	return nil
+}
+
+// validateMqtt checks broker url, topic and QoS of mqtt notification.
+func validateMqtt(url, topic string, qos *int) error {
+	if !strings.HasPrefix(url, "mqtt://") {
+		return fmt.Errorf("%w: mqtt url has to begin with mqtt://", InvalidSubscriptionError)
+	}
+	if len(topic) <= 0 || strings.ContainsAny(topic, "+#") {
+		return fmt.Errorf("%w: invalid mqtt topic %q", InvalidSubscriptionError, topic)
+	}
+	if qos != nil && (*qos < 0 || 2 < *qos) {
+		return fmt.Errorf("%w: mqtt qos has to be 0, 1 or 2", InvalidSubscriptionError)
+	}
+	return nil
+}
+
+// Validate checks whether this subscription is acceptable or not.
+func (s *Subscription) Validate() error {
+	if len(s.Subject.Entities) <= 0 {
+		return fmt.Errorf("%w: subject requires entities", InvalidSubscriptionError)
+	}
+	if err := validateSubscriptionStatus(s.Status); err != nil {
+		return err
+	}
+	return s.Notification.Validate()
+}
+
+// Validate checks whether this update is acceptable or not.
+func (u *SubscriptionUpdate) Validate() error {
+	if u.Description == nil && u.Subject == nil && u.Notification == nil && u.Expires == nil && u.Throttling == nil && u.Status == nil {
+		return EmptySubscriptionUpdateError
+	}
+	if err := validateSubscriptionStatus(u.Status); err != nil {
+		return err
+	}
+	if u.Notification != nil {
+		return u.Notification.Validate()
+	}
+	return nil
+}
+
+// validateSubscriptionStatus checks given status is settable by clients.
+func validateSubscriptionStatus(status *string) error {
+	if status == nil || *status == SubscriptionStatusActive || *status == SubscriptionStatusInactive {
+		return nil
+	}
+	return InvalidSubscriptionStatusError
+}
+
 // CreateSubscription post request to create subscription and return subscription ID or error.
 func (a Accessor) CreateSubscription(service, servicePath string, subscription *Subscription) (string, error) {
+	return a.CreateSubscriptionCtx(context.Background(), service, servicePath, subscription)
+}
+
+// CreateSubscriptionCtx post request to create subscription and return subscription ID or error with given context.
+func (a Accessor) CreateSubscriptionCtx(ctx context.Context, service, servicePath string, subscription *Subscription) (string, error) {
+	if err := subscription.Validate(); err != nil {
+		return "", err
+	}
 	ap := AccessParameter{
+		Ctx:         ctx,
 		EpID:        EntryPointIDs.Subscriptions,
 		Method:      gohttp.HttpMethods.POST,
 		Service:     service,
@@ -72,81 +344,145 @@ func (a Accessor) CreateSubscription(service, servicePath string, subscription *
 
 // GetSubscriptionList gets current subscription list
 func (a Accessor) GetSubscriptionList(service, servicePath string, subscriptions *[]Subscription) error {
+	return a.GetSubscriptionListCtx(context.Background(), service, servicePath, subscriptions)
+}
+
+// GetSubscriptionListCtx gets current subscription list with given context.
+func (a Accessor) GetSubscriptionListCtx(ctx context.Context, service, servicePath string, subscriptions *[]Subscription) error {
+	return a.GetSubscriptionListQCtx(ctx, service, servicePath, nil, subscriptions)
+}
+
+// GetSubscriptionListQ gets a page of subscription list. limit and offset of q are used for pagination. q can be nil.
+func (a Accessor) GetSubscriptionListQ(service, servicePath string, q *Query, subscriptions *[]Subscription) error {
+	return a.GetSubscriptionListQCtx(context.Background(), service, servicePath, q, subscriptions)
+}
+
+// GetSubscriptionListQCtx is GetSubscriptionListQ with given context.
+func (a Accessor) GetSubscriptionListQCtx(ctx context.Context, service, servicePath string, q *Query, subscriptions *[]Subscription) error {
 	return a.access(&AccessParameter{
+		Ctx:          ctx,
 		EpID:         EntryPointIDs.Subscriptions,
 		Method:       gohttp.HttpMethods.GET,
 		Service:      service,
 		ServicePath:  servicePath,
 		Path:         "",
+		Query:        q,
 		ReceivedBody: subscriptions,
 	})
 }
 
+// GetAllSubscriptions gets all subscriptions page by page.
+func (a Accessor) GetAllSubscriptions(service, servicePath string) ([]Subscription, error) {
+	return a.GetAllSubscriptionsCtx(context.Background(), service, servicePath)
+}
+
+// GetAllSubscriptionsCtx gets all subscriptions page by page with given context.
+func (a Accessor) GetAllSubscriptionsCtx(ctx context.Context, service, servicePath string) ([]Subscription, error) {
+	var all []Subscription
+	for offset := 0; ; offset += DefaultPageSize {
+		q := NewQuery().SetLimit(DefaultPageSize).SetOffset(offset)
+		q.SetOptions([]Option{QueryOptions.Count})
+		var page []Subscription
+		ap := AccessParameter{
+			Ctx:          ctx,
+			EpID:         EntryPointIDs.Subscriptions,
+			Method:       gohttp.HttpMethods.GET,
+			Service:      service,
+			ServicePath:  servicePath,
+			Query:        q,
+			ReceivedBody: &page,
+		}
+		if err := a.access(&ap); err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < DefaultPageSize {
+			return all, nil
+		}
+		if total, err := strconv.Atoi(ap.ReceivedHeader.Get(TotalCountHeader)); err == nil && total <= len(all) {
+			return all, nil
+		}
+	}
+}
+
 // GetSubscription gets subscription that has specified ID.
 func (a *Accessor) GetSubscription(service, servicePath, id string, subscription *Subscription) error {
-	return a.access(&AccessParameter{
-		EpID:         EntryPointIDs.Subscriptions,
-		Method:       gohttp.HttpMethods.GET,
-		Service:      service,
-		ServicePath:  servicePath,
-		Path:         fmt.Sprintf("/%s", id),
-		ReceivedBody: subscription,
-	})
+	return a.GetSubscriptionCtx(context.Background(), service, servicePath, id, subscription)
 }
 
-// GetSubscriptionAttribute gets specified attribute of specified subscription.
-func (a *Accessor) GetSubscriptionAttribute(service, servicePath, id, attrName string, q *Query, attr interface{}) error {
+// GetSubscriptionCtx gets subscription that has specified ID with given context.
+func (a *Accessor) GetSubscriptionCtx(ctx context.Context, service, servicePath, id string, subscription *Subscription) error {
 	return a.access(&AccessParameter{
+		Ctx:          ctx,
 		EpID:         EntryPointIDs.Subscriptions,
 		Method:       gohttp.HttpMethods.GET,
 		Service:      service,
 		ServicePath:  servicePath,
-		Path:         fmt.Sprintf("/%s/attrs/%s", id, attrName),
-		Query:        q,
-		BodyToSend:   nil,
-		ReceivedBody: attr,
+		Path:         fmt.Sprintf("/%s", id),
+		ReceivedBody: subscription,
 	})
 }
 
 /// Update
 
-// UpdateSubscription updates subscription
-func (a *Accessor) UpdateSubscription(service, servicePath, id, typeName string, param interface{}) error {
+// UpdateSubscription updates fields of subscription that are set in given update.
+func (a *Accessor) UpdateSubscription(service, servicePath, id string, update *SubscriptionUpdate) error {
+	return a.UpdateSubscriptionCtx(context.Background(), service, servicePath, id, update)
+}
+
+// UpdateSubscriptionCtx updates fields of subscription with given context.
+func (a *Accessor) UpdateSubscriptionCtx(ctx context.Context, service, servicePath, id string, update *SubscriptionUpdate) error {
+	if err := update.Validate(); err != nil {
+		return err
+	}
 	return a.access(&AccessParameter{
+		Ctx:          ctx,
 		EpID:         EntryPointIDs.Subscriptions,
 		Method:       gohttp.HttpMethods.PATCH,
 		Service:      service,
 		ServicePath:  servicePath,
-		Path:         fmt.Sprintf("/%s/attrs", id),
-		Query:        NewQuery().SetQuery("type", typeName),
-		BodyToSend:   param,
+		Path:         fmt.Sprintf("/%s", id),
+		BodyToSend:   update,
 		ReceivedBody: nil,
 	})
 }
 
-// UpdateSubscriptionAttribute updates or append subscription attribute.
-func (a *Accessor) UpdateSubscriptionAttribute(service, servicePath, id, attrName string, q *Query, attr interface{}) error {
-	return a.access(&AccessParameter{
-		EpID:         EntryPointIDs.Subscriptions,
-		Method:       gohttp.HttpMethods.POST,
-		Service:      service,
-		ServicePath:  servicePath,
-		Path:         fmt.Sprintf("/%s/attrs", id),
-		Query:        q,
-		BodyToSend:   attr,
-		ReceivedBody: nil,
-	})
+// PauseSubscription makes subscription inactive. Orion stops sending notifications until it is resumed.
+func (a *Accessor) PauseSubscription(service, servicePath, id string) error {
+	return a.PauseSubscriptionCtx(context.Background(), service, servicePath, id)
+}
+
+// PauseSubscriptionCtx is PauseSubscription with given context.
+func (a *Accessor) PauseSubscriptionCtx(ctx context.Context, service, servicePath, id string) error {
+	status := SubscriptionStatusInactive
+	return a.UpdateSubscriptionCtx(ctx, service, servicePath, id, &SubscriptionUpdate{Status: &status})
+}
+
+// ResumeSubscription makes subscription active.
+func (a *Accessor) ResumeSubscription(service, servicePath, id string) error {
+	return a.ResumeSubscriptionCtx(context.Background(), service, servicePath, id)
+}
+
+// ResumeSubscriptionCtx is ResumeSubscription with given context.
+func (a *Accessor) ResumeSubscriptionCtx(ctx context.Context, service, servicePath, id string) error {
+	status := SubscriptionStatusActive
+	return a.UpdateSubscriptionCtx(ctx, service, servicePath, id, &SubscriptionUpdate{Status: &status})
 }
 
 /// Delete
-func (a *Accessor) DeleteSubscription(service, servicePath, id, typeName string) error {
+func (a *Accessor) DeleteSubscription(service, servicePath, id string) error {
+	return a.DeleteSubscriptionCtx(context.Background(), service, servicePath, id)
+}
+
+// DeleteSubscriptionCtx is DeleteSubscription with given context.
+func (a *Accessor) DeleteSubscriptionCtx(ctx context.Context, service, servicePath, id string) error {
 	return a.access(&AccessParameter{
+		Ctx:          ctx,
 		EpID:         EntryPointIDs.Subscriptions,
 		Method:       gohttp.HttpMethods.DELETE,
 		Service:      service,
 		ServicePath:  servicePath,
 		Path:         fmt.Sprintf("/%s", id),
-		Query:        NewQuery().SetQuery("type", typeName),
 		BodyToSend:   nil,
 		ReceivedBody: nil,
 	})
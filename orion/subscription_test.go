@@ -0,0 +1,203 @@
+package orion_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
+	"github.com/stretchr/testify/assert"
+)
+
+func genSubscription() *orion.Subscription {
+	format := orion.AttrsFormatKeyValues
+	onlyChanged := true
+	return &orion.Subscription{
+		Subject: orion.SubscriptionSubject{
+			Entities: *orion.GenEntities([]string{"Room"}, false, false),
+			Condition: &orion.SubscriptionSubjectCondition{
+				Attrs:  &[]string{"temperature"},
+				Filter: orion.SubscriptionExpression{Q: "temperature>40"},
+			},
+		},
+		Notification: orion.SubscriptionNotification{
+			Http:             &orion.Http{Url: "http://localhost:1028/notify"},
+			AttrsFormat:      &format,
+			OnlyChangedAttrs: &onlyChanged,
+		},
+	}
+}
+
+func TestAccessor_CreateSubscription(t *testing.T) {
+	var rec recordedRequest
+	ts := oriontest.NewStubServer(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		rec = recordedRequest{Method: r.Method, Path: r.URL.Path, Body: string(b)}
+		w.Header().Set("Location", "/v2/subscriptions/5e2b1c")
+		w.WriteHeader(http.StatusCreated)
+	})
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	id, err := a.CreateSubscription("", "", genSubscription())
+	assert.NoError(t, err)
+	assert.EqualValues(t, "5e2b1c", id)
+	assert.EqualValues(t, http.MethodPost, rec.Method)
+	assert.EqualValues(t, "/v2/subscriptions", rec.Path)
+	assert.JSONEq(t, `{"subject":{"entities":[{"type":"Room"}],"condition":{"attrs":["temperature"],"expression":{"q":"temperature>40"}}},`+
+		`"notification":{"http":{"url":"http://localhost:1028/notify"},"attrsFormat":"keyValues","onlyChangedAttrs":true}}`, rec.Body)
+}
+
+func TestSubscription_Validate(t *testing.T) {
+	s := genSubscription()
+	assert.NoError(t, s.Validate())
+
+	s.Notification.HttpCustom = &orion.SubscriptionHttpCustom{Url: "http://localhost:1028/notify"}
+	assert.True(t, errors.Is(s.Validate(), orion.InvalidSubscriptionError))
+	s.Notification.Http = nil
+	assert.NoError(t, s.Validate())
+
+	payload := "x"
+	s.Notification.HttpCustom.Payload = &payload
+	s.Notification.HttpCustom.Json = map[string]interface{}{"temperature": "${temperature}"}
+	assert.EqualError(t, s.Validate(), orion.InvalidSubscriptionPayloadError.Error())
+	s.Notification.HttpCustom.Payload = nil
+	assert.NoError(t, s.Validate())
+
+	format := "xml"
+	s.Notification.AttrsFormat = &format
+	assert.True(t, errors.Is(s.Validate(), orion.InvalidSubscriptionError))
+
+	s = genSubscription()
+	s.Notification.Http = nil
+	qos := 1
+	s.Notification.Mqtt = &orion.SubscriptionMqtt{Url: "mqtt://localhost:1883", Topic: "/orion/rooms", Qos: &qos}
+	assert.NoError(t, s.Validate())
+	qos = 3
+	assert.True(t, errors.Is(s.Validate(), orion.InvalidSubscriptionError))
+	qos = 0
+	s.Notification.Mqtt.Topic = "/orion/#"
+	assert.True(t, errors.Is(s.Validate(), orion.InvalidSubscriptionError))
+	s.Notification.Mqtt = nil
+	s.Notification.MqttCustom = &orion.SubscriptionMqttCustom{Url: "http://localhost:1883", Topic: "/orion/rooms"}
+	assert.True(t, errors.Is(s.Validate(), orion.InvalidSubscriptionError))
+	s.Notification.MqttCustom.Url = "mqtt://localhost:1883"
+	s.Notification.MqttCustom.Payload = &payload
+	assert.NoError(t, s.Validate())
+	s.Notification.MqttCustom.Ngsi = &map[string]interface{}{"id": "x"}
+	assert.EqualError(t, s.Validate(), orion.InvalidSubscriptionPayloadError.Error())
+
+	s = genSubscription()
+	status := orion.SubscriptionStatusExpired
+	s.Status = &status
+	assert.EqualError(t, s.Validate(), orion.InvalidSubscriptionStatusError.Error())
+}
+
+func TestSubscription_JSON(t *testing.T) {
+	q := "temperature>40"
+	s := genSubscription()
+	s.Subject.Condition.Expression = &q
+	s.Subject.Condition.Filter = orion.SubscriptionExpression{Georel: "near;maxDistance:1000", Geometry: "point", Coords: "40.4,-3.5"}
+	s.Notification.Http = nil
+	s.Notification.HttpCustom = &orion.SubscriptionHttpCustom{
+		Url:     "http://localhost:1028/notify",
+		Headers: &[]map[string]string{{"X-Room": "${id}"}, {"X-Floor": "1"}},
+		Qs:      &[]map[string]string{{"type": "${type}"}},
+	}
+	b, err := json.Marshal(s)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"subject":{"entities":[{"type":"Room"}],"condition":{"attrs":["temperature"],`+
+		`"expression":{"q":"temperature>40","georel":"near;maxDistance:1000","geometry":"point","coords":"40.4,-3.5"}}},`+
+		`"notification":{"httpCustom":{"url":"http://localhost:1028/notify","headers":{"X-Room":"${id}","X-Floor":"1"},"qs":{"type":"${type}"}},`+
+		`"attrsFormat":"keyValues","onlyChangedAttrs":true}}`, string(b))
+
+	var got orion.Subscription
+	assert.NoError(t, json.Unmarshal(b, &got))
+	assert.EqualValues(t, q, *got.Subject.Condition.Expression)
+	assert.EqualValues(t, "point", got.Subject.Condition.Filter.Geometry)
+	assert.EqualValues(t, &[]map[string]string{{"X-Room": "${id}", "X-Floor": "1"}}, got.Notification.HttpCustom.Headers)
+	assert.EqualValues(t, &[]map[string]string{{"type": "${type}"}}, got.Notification.HttpCustom.Qs)
+
+	// empty expression and headers are omitted.
+	b, err = json.Marshal(orion.SubscriptionHttpCustom{Url: "http://x"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"url":"http://x"}`, string(b))
+	b, err = json.Marshal(orion.SubscriptionSubjectCondition{Attrs: &[]string{"a"}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"attrs":["a"]}`, string(b))
+}
+
+func TestAccessor_UpdateSubscription(t *testing.T) {
+	var rec recordedRequest
+	ts := newRecordServer(&rec, "", "")
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	throttling := int64(5)
+	err := a.UpdateSubscription("", "", "5e2b1c", &orion.SubscriptionUpdate{Throttling: &throttling})
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.MethodPatch, rec.Method)
+	assert.EqualValues(t, "/v2/subscriptions/5e2b1c", rec.Path)
+	assert.EqualValues(t, "", rec.Query)
+	assert.JSONEq(t, `{"throttling":5}`, rec.Body)
+
+	assert.NoError(t, a.PauseSubscription("", "", "5e2b1c"))
+	assert.JSONEq(t, `{"status":"inactive"}`, rec.Body)
+	assert.NoError(t, a.ResumeSubscription("", "", "5e2b1c"))
+	assert.JSONEq(t, `{"status":"active"}`, rec.Body)
+
+	rec = recordedRequest{}
+	assert.EqualError(t, a.UpdateSubscription("", "", "5e2b1c", &orion.SubscriptionUpdate{}), orion.EmptySubscriptionUpdateError.Error())
+	assert.EqualValues(t, "", rec.Method)
+
+	assert.NoError(t, a.DeleteSubscription("", "", "5e2b1c"))
+	assert.EqualValues(t, http.MethodDelete, rec.Method)
+	assert.EqualValues(t, "/v2/subscriptions/5e2b1c", rec.Path)
+	assert.EqualValues(t, "", rec.Query)
+}
+
+func TestAccessor_GetSubscriptionList(t *testing.T) {
+	const total = 230
+	var queries []string
+	ts := oriontest.NewStubServer(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if limit <= 0 {
+			limit = 20
+		}
+		w.Header().Set(orion.TotalCountHeader, strconv.Itoa(total))
+		_, _ = fmt.Fprint(w, "[")
+		for i := offset; i < offset+limit && i < total; i++ {
+			if offset < i {
+				_, _ = fmt.Fprint(w, ",")
+			}
+			_, _ = fmt.Fprintf(w, `{"id":"sub%d","subject":{"entities":[{"type":"Room"}]},"notification":{"http":{"url":"http://x"}},"status":"active"}`, i)
+		}
+		_, _ = fmt.Fprint(w, "]")
+	})
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	var list []orion.Subscription
+	err := a.GetSubscriptionListQ("", "", orion.NewQuery().SetLimit(10).SetOffset(20), &list)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 10, len(list))
+	assert.EqualValues(t, "sub20", *list[0].Id)
+	assert.EqualValues(t, "limit=10&offset=20", queries[0])
+	assert.NoError(t, a.GetSubscriptionList("", "", &list))
+	assert.EqualValues(t, 20, len(list))
+	assert.EqualValues(t, "", queries[1])
+
+	queries = nil
+	all, err := a.GetAllSubscriptions("", "")
+	assert.NoError(t, err)
+	assert.EqualValues(t, total, len(all))
+	assert.EqualValues(t, "sub229", *all[total-1].Id)
+	assert.EqualValues(t, 3, len(queries))
+}
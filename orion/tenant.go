@@ -0,0 +1,459 @@
+// tenant scoped accessor
+package orion
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/go-fiware-api/ngsi"
+)
+
+var (
+	ServicePathScopeError = fmt.Errorf("service path scope is available only for entity queries")
+)
+
+// Tenant is an accessor bound to a fiware-service and fiware-servicepath.
+// The service path can be a query scope, comma-separated paths or hierarchical one such as `/a/#`.
+// Such a tenant can only query entities and types; other operations return ServicePathScopeError.
+type Tenant struct {
+	a           *Accessor
+	service     string
+	servicePath string
+}
+
+// Tenant returns new Tenant instance that accesses given service and service path. Both of them are validated here
+// and required as the same as iotagent.Tenant; use Accessor to access the default service.
+func (a *Accessor) Tenant(service, servicePath string) (*Tenant, error) {
+	if !common.IsValidService(service) {
+		return nil, common.InvalidServiceName
+	}
+	if !common.IsValidServicePathScope(servicePath) {
+		return nil, common.InvalidServicePath
+	}
+	return &Tenant{a: a, service: service, servicePath: servicePath}, nil
+}
+
+// Service returns fiware-service of this tenant.
+func (t *Tenant) Service() string {
+	return t.service
+}
+
+// ServicePath returns fiware-servicepath of this tenant.
+func (t *Tenant) ServicePath() string {
+	return t.servicePath
+}
+
+// checkWritable returns ServicePathScopeError when the service path of this tenant is a query scope.
+func (t *Tenant) checkWritable() error {
+	if common.IsServicePathScope(t.servicePath) {
+		return ServicePathScopeError
+	}
+	return nil
+}
+
+// CreateEntity is Accessor.CreateEntity for this tenant.
+func (t *Tenant) CreateEntity(q *Query, entity interface{}) error {
+	return t.CreateEntityCtx(context.Background(), q, entity)
+}
+
+// CreateEntityCtx is CreateEntity with given context.
+func (t *Tenant) CreateEntityCtx(ctx context.Context, q *Query, entity interface{}) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.CreateEntityCtx(ctx, t.service, t.servicePath, q, entity)
+}
+
+// GetEntityList is Accessor.GetEntityList for this tenant.
+func (t *Tenant) GetEntityList(q *Query, entities interface{}) error {
+	return t.GetEntityListCtx(context.Background(), q, entities)
+}
+
+// GetEntityListCtx is GetEntityList with given context.
+func (t *Tenant) GetEntityListCtx(ctx context.Context, q *Query, entities interface{}) error {
+	return t.a.GetEntityListCtx(ctx, t.service, t.servicePath, q, entities)
+}
+
+// GetEntity is Accessor.GetEntity for this tenant.
+func (t *Tenant) GetEntity(id string, q *Query, entity interface{}) error {
+	return t.GetEntityCtx(context.Background(), id, q, entity)
+}
+
+// GetEntityCtx is GetEntity with given context.
+func (t *Tenant) GetEntityCtx(ctx context.Context, id string, q *Query, entity interface{}) error {
+	return t.a.GetEntityCtx(ctx, t.service, t.servicePath, id, q, entity)
+}
+
+// GetEntityAttribute is Accessor.GetEntityAttribute for this tenant.
+func (t *Tenant) GetEntityAttribute(id, attrName string, q *Query, attr interface{}) error {
+	return t.GetEntityAttributeCtx(context.Background(), id, attrName, q, attr)
+}
+
+// GetEntityAttributeCtx is GetEntityAttribute with given context.
+func (t *Tenant) GetEntityAttributeCtx(ctx context.Context, id, attrName string, q *Query, attr interface{}) error {
+	return t.a.GetEntityAttributeCtx(ctx, t.service, t.servicePath, id, attrName, q, attr)
+}
+
+// UpdateEntity is Accessor.UpdateEntity for this tenant.
+func (t *Tenant) UpdateEntity(id, typeName string, param interface{}) error {
+	return t.UpdateEntityCtx(context.Background(), id, typeName, param)
+}
+
+// UpdateEntityCtx is UpdateEntity with given context.
+func (t *Tenant) UpdateEntityCtx(ctx context.Context, id, typeName string, param interface{}) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.UpdateEntityCtx(ctx, t.service, t.servicePath, id, typeName, param)
+}
+
+// UpdateEntityAttribute is Accessor.UpdateEntityAttribute for this tenant.
+func (t *Tenant) UpdateEntityAttribute(id, attrName string, q *Query, attr interface{}) error {
+	return t.UpdateEntityAttributeCtx(context.Background(), id, attrName, q, attr)
+}
+
+// UpdateEntityAttributeCtx is UpdateEntityAttribute with given context.
+func (t *Tenant) UpdateEntityAttributeCtx(ctx context.Context, id, attrName string, q *Query, attr interface{}) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.UpdateEntityAttributeCtx(ctx, t.service, t.servicePath, id, attrName, q, attr)
+}
+
+// DeleteEntity is Accessor.DeleteEntity for this tenant.
+func (t *Tenant) DeleteEntity(id, typeName string) error {
+	return t.DeleteEntityCtx(context.Background(), id, typeName)
+}
+
+// DeleteEntityCtx is DeleteEntity with given context.
+func (t *Tenant) DeleteEntityCtx(ctx context.Context, id, typeName string) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.DeleteEntityCtx(ctx, t.service, t.servicePath, id, typeName)
+}
+
+// GetNgsiEntityList is Accessor.GetNgsiEntityList for this tenant.
+func (t *Tenant) GetNgsiEntityList(q *Query) ([]ngsi.Entity, error) {
+	return t.GetNgsiEntityListCtx(context.Background(), q)
+}
+
+// GetNgsiEntityListCtx is GetNgsiEntityList with given context.
+func (t *Tenant) GetNgsiEntityListCtx(ctx context.Context, q *Query) ([]ngsi.Entity, error) {
+	return t.a.GetNgsiEntityListCtx(ctx, t.service, t.servicePath, q)
+}
+
+// GetNgsiEntity is Accessor.GetNgsiEntity for this tenant.
+func (t *Tenant) GetNgsiEntity(id string, q *Query) (*ngsi.Entity, error) {
+	return t.GetNgsiEntityCtx(context.Background(), id, q)
+}
+
+// GetNgsiEntityCtx is GetNgsiEntity with given context.
+func (t *Tenant) GetNgsiEntityCtx(ctx context.Context, id string, q *Query) (*ngsi.Entity, error) {
+	return t.a.GetNgsiEntityCtx(ctx, t.service, t.servicePath, id, q)
+}
+
+// GetEntityAttributeValue is Accessor.GetEntityAttributeValue for this tenant.
+func (t *Tenant) GetEntityAttributeValue(id, attrName, typeName string, value interface{}) error {
+	return t.GetEntityAttributeValueCtx(context.Background(), id, attrName, typeName, value)
+}
+
+// GetEntityAttributeValueCtx is GetEntityAttributeValue with given context.
+func (t *Tenant) GetEntityAttributeValueCtx(ctx context.Context, id, attrName, typeName string, value interface{}) error {
+	return t.a.GetEntityAttributeValueCtx(ctx, t.service, t.servicePath, id, attrName, typeName, value)
+}
+
+// ReplaceEntityAttributes is Accessor.ReplaceEntityAttributes for this tenant.
+func (t *Tenant) ReplaceEntityAttributes(id, typeName string, attrs interface{}) error {
+	return t.ReplaceEntityAttributesCtx(context.Background(), id, typeName, attrs)
+}
+
+// ReplaceEntityAttributesCtx is ReplaceEntityAttributes with given context.
+func (t *Tenant) ReplaceEntityAttributesCtx(ctx context.Context, id, typeName string, attrs interface{}) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.ReplaceEntityAttributesCtx(ctx, t.service, t.servicePath, id, typeName, attrs)
+}
+
+// UpdateEntityAttributeData is Accessor.UpdateEntityAttributeData for this tenant.
+func (t *Tenant) UpdateEntityAttributeData(id, attrName, typeName string, attr interface{}) error {
+	return t.UpdateEntityAttributeDataCtx(context.Background(), id, attrName, typeName, attr)
+}
+
+// UpdateEntityAttributeDataCtx is UpdateEntityAttributeData with given context.
+func (t *Tenant) UpdateEntityAttributeDataCtx(ctx context.Context, id, attrName, typeName string, attr interface{}) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.UpdateEntityAttributeDataCtx(ctx, t.service, t.servicePath, id, attrName, typeName, attr)
+}
+
+// UpdateEntityAttributeValue is Accessor.UpdateEntityAttributeValue for this tenant.
+func (t *Tenant) UpdateEntityAttributeValue(id, attrName, typeName string, value interface{}) error {
+	return t.UpdateEntityAttributeValueCtx(context.Background(), id, attrName, typeName, value)
+}
+
+// UpdateEntityAttributeValueCtx is UpdateEntityAttributeValue with given context.
+func (t *Tenant) UpdateEntityAttributeValueCtx(ctx context.Context, id, attrName, typeName string, value interface{}) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.UpdateEntityAttributeValueCtx(ctx, t.service, t.servicePath, id, attrName, typeName, value)
+}
+
+// DeleteEntityAttribute is Accessor.DeleteEntityAttribute for this tenant.
+func (t *Tenant) DeleteEntityAttribute(id, attrName, typeName string) error {
+	return t.DeleteEntityAttributeCtx(context.Background(), id, attrName, typeName)
+}
+
+// DeleteEntityAttributeCtx is DeleteEntityAttribute with given context.
+func (t *Tenant) DeleteEntityAttributeCtx(ctx context.Context, id, attrName, typeName string) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.DeleteEntityAttributeCtx(ctx, t.service, t.servicePath, id, attrName, typeName)
+}
+
+// GetEntityTypes is Accessor.GetEntityTypes for this tenant.
+func (t *Tenant) GetEntityTypes(q *Query) ([]EntityType, error) {
+	return t.GetEntityTypesCtx(context.Background(), q)
+}
+
+// GetEntityTypesCtx is GetEntityTypes with given context.
+func (t *Tenant) GetEntityTypesCtx(ctx context.Context, q *Query) ([]EntityType, error) {
+	return t.a.GetEntityTypesCtx(ctx, t.service, t.servicePath, q)
+}
+
+// GetEntityTypeNames is Accessor.GetEntityTypeNames for this tenant.
+func (t *Tenant) GetEntityTypeNames(q *Query) ([]string, error) {
+	return t.GetEntityTypeNamesCtx(context.Background(), q)
+}
+
+// GetEntityTypeNamesCtx is GetEntityTypeNames with given context.
+func (t *Tenant) GetEntityTypeNamesCtx(ctx context.Context, q *Query) ([]string, error) {
+	return t.a.GetEntityTypeNamesCtx(ctx, t.service, t.servicePath, q)
+}
+
+// GetEntityType is Accessor.GetEntityType for this tenant.
+func (t *Tenant) GetEntityType(typeName string) (*EntityType, error) {
+	return t.GetEntityTypeCtx(context.Background(), typeName)
+}
+
+// GetEntityTypeCtx is GetEntityType with given context.
+func (t *Tenant) GetEntityTypeCtx(ctx context.Context, typeName string) (*EntityType, error) {
+	return t.a.GetEntityTypeCtx(ctx, t.service, t.servicePath, typeName)
+}
+
+// IterateEntities is Accessor.IterateEntities for this tenant.
+func (t *Tenant) IterateEntities(q *Query) *EntityIterator {
+	return t.IterateEntitiesCtx(context.Background(), q)
+}
+
+// IterateEntitiesCtx is IterateEntities with given context.
+func (t *Tenant) IterateEntitiesCtx(ctx context.Context, q *Query) *EntityIterator {
+	return t.a.IterateEntitiesCtx(ctx, t.service, t.servicePath, q)
+}
+
+// BatchUpdate is Accessor.BatchUpdate for this tenant.
+func (t *Tenant) BatchUpdate(actionType ActionType, entities interface{}) error {
+	return t.BatchUpdateCtx(context.Background(), actionType, entities)
+}
+
+// BatchUpdateCtx is BatchUpdate with given context.
+func (t *Tenant) BatchUpdateCtx(ctx context.Context, actionType ActionType, entities interface{}) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.BatchUpdateCtx(ctx, t.service, t.servicePath, actionType, entities)
+}
+
+// BatchQuery is Accessor.BatchQuery for this tenant.
+func (t *Tenant) BatchQuery(body *BatchQuery, q *Query, entities interface{}) error {
+	return t.BatchQueryCtx(context.Background(), body, q, entities)
+}
+
+// BatchQueryCtx is BatchQuery with given context.
+func (t *Tenant) BatchQueryCtx(ctx context.Context, body *BatchQuery, q *Query, entities interface{}) error {
+	return t.a.BatchQueryCtx(ctx, t.service, t.servicePath, body, q, entities)
+}
+
+// CreateSubscription is Accessor.CreateSubscription for this tenant.
+func (t *Tenant) CreateSubscription(subscription *Subscription) (string, error) {
+	return t.CreateSubscriptionCtx(context.Background(), subscription)
+}
+
+// CreateSubscriptionCtx is CreateSubscription with given context.
+func (t *Tenant) CreateSubscriptionCtx(ctx context.Context, subscription *Subscription) (string, error) {
+	if err := t.checkWritable(); err != nil {
+		return "", err
+	}
+	return t.a.CreateSubscriptionCtx(ctx, t.service, t.servicePath, subscription)
+}
+
+// GetSubscriptionList is Accessor.GetSubscriptionList for this tenant.
+func (t *Tenant) GetSubscriptionList(subscriptions *[]Subscription) error {
+	return t.GetSubscriptionListCtx(context.Background(), subscriptions)
+}
+
+// GetSubscriptionListCtx is GetSubscriptionList with given context.
+func (t *Tenant) GetSubscriptionListCtx(ctx context.Context, subscriptions *[]Subscription) error {
+	return t.GetSubscriptionListQCtx(ctx, nil, subscriptions)
+}
+
+// GetSubscriptionListQ is Accessor.GetSubscriptionListQ for this tenant.
+func (t *Tenant) GetSubscriptionListQ(q *Query, subscriptions *[]Subscription) error {
+	return t.GetSubscriptionListQCtx(context.Background(), q, subscriptions)
+}
+
+// GetSubscriptionListQCtx is GetSubscriptionListQ with given context.
+func (t *Tenant) GetSubscriptionListQCtx(ctx context.Context, q *Query, subscriptions *[]Subscription) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.GetSubscriptionListQCtx(ctx, t.service, t.servicePath, q, subscriptions)
+}
+
+// GetAllSubscriptions is Accessor.GetAllSubscriptions for this tenant.
+func (t *Tenant) GetAllSubscriptions() ([]Subscription, error) {
+	return t.GetAllSubscriptionsCtx(context.Background())
+}
+
+// GetAllSubscriptionsCtx is GetAllSubscriptions with given context.
+func (t *Tenant) GetAllSubscriptionsCtx(ctx context.Context) ([]Subscription, error) {
+	if err := t.checkWritable(); err != nil {
+		return nil, err
+	}
+	return t.a.GetAllSubscriptionsCtx(ctx, t.service, t.servicePath)
+}
+
+// GetSubscription is Accessor.GetSubscription for this tenant.
+func (t *Tenant) GetSubscription(id string, subscription *Subscription) error {
+	return t.GetSubscriptionCtx(context.Background(), id, subscription)
+}
+
+// GetSubscriptionCtx is GetSubscription with given context.
+func (t *Tenant) GetSubscriptionCtx(ctx context.Context, id string, subscription *Subscription) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.GetSubscriptionCtx(ctx, t.service, t.servicePath, id, subscription)
+}
+
+// UpdateSubscription is Accessor.UpdateSubscription for this tenant.
+func (t *Tenant) UpdateSubscription(id string, update *SubscriptionUpdate) error {
+	return t.UpdateSubscriptionCtx(context.Background(), id, update)
+}
+
+// UpdateSubscriptionCtx is UpdateSubscription with given context.
+func (t *Tenant) UpdateSubscriptionCtx(ctx context.Context, id string, update *SubscriptionUpdate) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.UpdateSubscriptionCtx(ctx, t.service, t.servicePath, id, update)
+}
+
+// PauseSubscription is Accessor.PauseSubscription for this tenant.
+func (t *Tenant) PauseSubscription(id string) error {
+	return t.PauseSubscriptionCtx(context.Background(), id)
+}
+
+// PauseSubscriptionCtx is PauseSubscription with given context.
+func (t *Tenant) PauseSubscriptionCtx(ctx context.Context, id string) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.PauseSubscriptionCtx(ctx, t.service, t.servicePath, id)
+}
+
+// ResumeSubscription is Accessor.ResumeSubscription for this tenant.
+func (t *Tenant) ResumeSubscription(id string) error {
+	return t.ResumeSubscriptionCtx(context.Background(), id)
+}
+
+// ResumeSubscriptionCtx is ResumeSubscription with given context.
+func (t *Tenant) ResumeSubscriptionCtx(ctx context.Context, id string) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.ResumeSubscriptionCtx(ctx, t.service, t.servicePath, id)
+}
+
+// DeleteSubscription is Accessor.DeleteSubscription for this tenant.
+func (t *Tenant) DeleteSubscription(id string) error {
+	return t.DeleteSubscriptionCtx(context.Background(), id)
+}
+
+// DeleteSubscriptionCtx is DeleteSubscription with given context.
+func (t *Tenant) DeleteSubscriptionCtx(ctx context.Context, id string) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.DeleteSubscriptionCtx(ctx, t.service, t.servicePath, id)
+}
+
+// CreateRegistration is Accessor.CreateRegistration for this tenant.
+func (t *Tenant) CreateRegistration(registration *Registration) (string, error) {
+	return t.CreateRegistrationCtx(context.Background(), registration)
+}
+
+// CreateRegistrationCtx is CreateRegistration with given context.
+func (t *Tenant) CreateRegistrationCtx(ctx context.Context, registration *Registration) (string, error) {
+	if err := t.checkWritable(); err != nil {
+		return "", err
+	}
+	return t.a.CreateRegistrationCtx(ctx, t.service, t.servicePath, registration)
+}
+
+// GetRegistrationList is Accessor.GetRegistrationList for this tenant.
+func (t *Tenant) GetRegistrationList(q *Query, registrations *[]Registration) error {
+	return t.GetRegistrationListCtx(context.Background(), q, registrations)
+}
+
+// GetRegistrationListCtx is GetRegistrationList with given context.
+func (t *Tenant) GetRegistrationListCtx(ctx context.Context, q *Query, registrations *[]Registration) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.GetRegistrationListCtx(ctx, t.service, t.servicePath, q, registrations)
+}
+
+// GetRegistration is Accessor.GetRegistration for this tenant.
+func (t *Tenant) GetRegistration(id string, registration *Registration) error {
+	return t.GetRegistrationCtx(context.Background(), id, registration)
+}
+
+// GetRegistrationCtx is GetRegistration with given context.
+func (t *Tenant) GetRegistrationCtx(ctx context.Context, id string, registration *Registration) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.GetRegistrationCtx(ctx, t.service, t.servicePath, id, registration)
+}
+
+// UpdateRegistration is Accessor.UpdateRegistration for this tenant.
+func (t *Tenant) UpdateRegistration(id string, update *RegistrationUpdate) error {
+	return t.UpdateRegistrationCtx(context.Background(), id, update)
+}
+
+// UpdateRegistrationCtx is UpdateRegistration with given context.
+func (t *Tenant) UpdateRegistrationCtx(ctx context.Context, id string, update *RegistrationUpdate) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.UpdateRegistrationCtx(ctx, t.service, t.servicePath, id, update)
+}
+
+// DeleteRegistration is Accessor.DeleteRegistration for this tenant.
+func (t *Tenant) DeleteRegistration(id string) error {
+	return t.DeleteRegistrationCtx(context.Background(), id)
+}
+
+// DeleteRegistrationCtx is DeleteRegistration with given context.
+func (t *Tenant) DeleteRegistrationCtx(ctx context.Context, id string) error {
+	if err := t.checkWritable(); err != nil {
+		return err
+	}
+	return t.a.DeleteRegistrationCtx(ctx, t.service, t.servicePath, id)
+}
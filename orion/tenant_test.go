@@ -0,0 +1,56 @@
+package orion_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessor_Tenant(t *testing.T) {
+	var service, servicePath string
+	ts := newRecordServer(&recordedRequest{}, orion.ContentTypeJSON, `[]`)
+	ts.Config.Handler = func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v2" {
+				service, servicePath = r.Header.Get(common.ServiceHeader), r.Header.Get(common.ServicePathHeader)
+			}
+			h.ServeHTTP(w, r)
+		})
+	}(ts.Config.Handler)
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
+
+	_, err := a.Tenant("Open IoT", "/")
+	assert.EqualError(t, err, common.InvalidServiceName.Error())
+	_, err = a.Tenant("openiot", "floor1")
+	assert.EqualError(t, err, common.InvalidServicePath.Error())
+	_, err = a.Tenant("", "/")
+	assert.EqualError(t, err, common.InvalidServiceName.Error())
+	_, err = a.Tenant("openiot", "")
+	assert.EqualError(t, err, common.InvalidServicePath.Error())
+
+	tn, err := a.Tenant("openiot", "/floor1")
+	assert.NoError(t, err)
+	var list []interface{}
+	assert.NoError(t, tn.GetEntityList(nil, &list))
+	assert.EqualValues(t, "openiot", service)
+	assert.EqualValues(t, "/floor1", servicePath)
+	assert.NoError(t, tn.DeleteEntity("Room1", "Room"))
+
+	// plain accessor rejects scopes except for entity queries.
+	assert.EqualError(t, a.DeleteEntity("openiot", "/floor1/#", "Room1", "Room"), common.InvalidServicePath.Error())
+	assert.NoError(t, a.GetEntityList("openiot", "/floor1/#", nil, &list))
+
+	// query scope can read entities only.
+	scope, err := a.Tenant("openiot", "/floor1/#,/floor2")
+	assert.NoError(t, err)
+	assert.NoError(t, scope.GetEntityList(nil, &list))
+	assert.EqualValues(t, "/floor1/#,/floor2", servicePath)
+	assert.EqualError(t, scope.DeleteEntity("Room1", "Room"), orion.ServicePathScopeError.Error())
+	assert.EqualError(t, scope.BatchUpdate(orion.ActionTypes.Append, []interface{}{}), orion.ServicePathScopeError.Error())
+	_, err = scope.CreateSubscription(&orion.Subscription{})
+	assert.EqualError(t, err, orion.ServicePathScopeError.Error())
+}
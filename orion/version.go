@@ -1,10 +1,11 @@
 package orion
 
 import (
+	"context"
 	"fmt"
-	"net/http"
 	"time"
 
+	"github.com/marrbor/go-fiware-api/common"
 	"github.com/marrbor/gohttp"
 )
 
@@ -26,16 +27,21 @@ type Version struct {
 
 // GetVersion gets version information from the server.
 func (a *Accessor) GetVersion() (*Version, error) {
-	req, err := gohttp.GenRequest(gohttp.HttpMethods.GET, fmt.Sprintf("%s/version", a.BaseUrl), nil)
+	return a.GetVersionCtx(context.Background())
+}
+
+// GetVersionCtx gets version information from the server with given context.
+func (a *Accessor) GetVersionCtx(ctx context.Context) (*Version, error) {
+	req, err := common.GenRequest(ctx, gohttp.HttpMethods.GET, fmt.Sprintf("%s/version", a.BaseUrl), nil)
 	if err != nil {
 		return nil, err
 	}
-	res, err := a.HttpClient.Do(req)
+	res, err := a.do(req)
 	if err != nil {
 		return nil, err
 	}
-	if http.StatusBadRequest <= res.StatusCode {
-		return nil, fmt.Errorf(res.Status)
+	if err := checkResponse(res); err != nil {
+		return nil, err
 	}
 	var va VersionAPI
 	if err := gohttp.ResponseJSONToParams(res, &va); err != nil {
@@ -1,17 +1,19 @@
 package orion_test
 
 import (
-	"fmt"
 	"testing"
 
 	"github.com/marrbor/go-fiware-api/orion"
+	"github.com/marrbor/go-fiware-api/orion/oriontest"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestAccessor_GetVersion(t *testing.T) {
-	url := fmt.Sprintf("http://%s:%d", "localhost", 1026)
-	a := orion.NewAccessor(url)
+	ts := oriontest.NewServer()
+	defer ts.Close()
+	a := orion.NewAccessor(ts.URL)
 	v, err := a.GetVersion()
 	assert.NoError(t, err)
+	assert.EqualValues(t, oriontest.Version, v.Version)
 	t.Logf("got version:%+v", v)
 }
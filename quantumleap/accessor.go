@@ -1,14 +1,26 @@
 package quantumleap
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/gohttp"
 )
 
 type (
 	// Accessor is base of Context Producer, Context Provider and Context Consumer.
 	Accessor struct {
-		HttpClient *http.Client
-		BaseUrl    string
+		HttpClient  *http.Client
+		BaseUrl     string
+		Credentials common.Credentials     // attached to every request when specified.
+		Retry       *common.RetryPolicy    // retries transient failures when specified.
+		Breaker     *common.CircuitBreaker // can be shared among accessors.
+		Middlewares []common.Middleware    // see every outgoing call.
+		Logger      common.Logger          // no-op when not specified.
 	}
 )
 
@@ -19,3 +31,47 @@ func NewAccessor(baseUrl string) *Accessor {
 		BaseUrl:    baseUrl,
 	}
 }
+
+// do sends given request and returns its response. Returns context error when the request was canceled or expired.
+// The request is replayed once with refreshed credentials when it is rejected with 401, and retried on transient failures.
+func (a Accessor) do(req *http.Request) (*http.Response, error) {
+	return common.Sender{Client: a.HttpClient, Credentials: a.Credentials, Retry: a.Retry, Breaker: a.Breaker, Middlewares: a.Middlewares, Logger: a.Logger}.Do(req)
+}
+
+// genURL returns URL of given path elements. Elements are escaped.
+func (a Accessor) genURL(q *Query, elements ...string) (*url.URL, error) {
+	for i, e := range elements {
+		elements[i] = url.PathEscape(e)
+	}
+	u, err := url.Parse(fmt.Sprintf("%s/v2/%s", a.BaseUrl, strings.Join(elements, "/")))
+	if err != nil {
+		return nil, err
+	}
+	if q != nil {
+		if err := q.check(); err != nil {
+			return nil, err
+		}
+		q.SetToURL(u)
+	}
+	return u, nil
+}
+
+// send sends a request with tenant headers to given URL. Returns APIError on error responses, which wraps
+// NotFoundError on 404. The body has been closed in that case.
+func (a Accessor) send(ctx context.Context, method gohttp.HTTPMethod, service, servicePath string, u *url.URL, body interface{}) (*http.Response, error) {
+	req, err := common.GenRequest(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if err := common.AddServiceHeader(req, service, servicePath); err != nil {
+		return nil, err
+	}
+	res, err := a.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if http.StatusBadRequest <= res.StatusCode {
+		return nil, newAPIError(res)
+	}
+	return res, nil
+}
@@ -0,0 +1,43 @@
+package quantumleap
+
+import (
+	"context"
+
+	"github.com/marrbor/gohttp"
+)
+
+// delete deletes records at given path.
+func (a Accessor) delete(ctx context.Context, service, servicePath string, q *Query, elements ...string) error {
+	u, err := a.genURL(q, elements...)
+	if err != nil {
+		return err
+	}
+	res, err := a.send(ctx, gohttp.HttpMethods.DELETE, service, servicePath, u, nil)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// DeleteEntity deletes records of the entity that has specified ID. Records are limited by fromDate and toDate of
+// given query, e.g. NewQuery().SetToDate(time.Now().AddDate(0, -1, 0)) removes records older than a month.
+// Use SetType to disambiguate the entity. Returns NotFoundError when no records are deleted.
+func (a Accessor) DeleteEntity(service, servicePath, id string, q *Query) error {
+	return a.DeleteEntityCtx(context.Background(), service, servicePath, id, q)
+}
+
+// DeleteEntityCtx is DeleteEntity with given context.
+func (a Accessor) DeleteEntityCtx(ctx context.Context, service, servicePath, id string, q *Query) error {
+	return a.delete(ctx, service, servicePath, q, "entities", id)
+}
+
+// DeleteType deletes records of all entities that have specified type. Records are limited by fromDate and toDate
+// of given query. Returns NotFoundError when no records are deleted.
+func (a Accessor) DeleteType(service, servicePath, typeName string, q *Query) error {
+	return a.DeleteTypeCtx(context.Background(), service, servicePath, typeName, q)
+}
+
+// DeleteTypeCtx is DeleteType with given context.
+func (a Accessor) DeleteTypeCtx(ctx context.Context, service, servicePath, typeName string, q *Query) error {
+	return a.delete(ctx, service, servicePath, q, "types", typeName)
+}
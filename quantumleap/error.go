@@ -0,0 +1,25 @@
+// QuantumLeap error response
+package quantumleap
+
+import (
+	"net/http"
+
+	"github.com/marrbor/go-fiware-api/common"
+)
+
+// APIError holds error response returned from QuantumLeap. It wraps NotFoundError on 404.
+type APIError = common.APIError
+
+// newAPIError generates APIError instance from given response. Body of the response is consumed and closed.
+func newAPIError(res *http.Response) *APIError {
+	e := common.NewAPIError(res)
+	if e.StatusCode == http.StatusNotFound {
+		e.Err = NotFoundError
+	}
+	return e
+}
+
+// AsAPIError returns APIError held by given error or nil when it does not hold APIError.
+func AsAPIError(err error) *APIError {
+	return common.AsAPIError(err)
+}
@@ -0,0 +1,56 @@
+package quantumleap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/gohttp"
+)
+
+const (
+	// status of the health.
+	HealthPass = "pass"
+	HealthWarn = "warn"
+	HealthFail = "fail"
+)
+
+type (
+	// Health is a response of /health. QuantumLeap responds 503 when the status is fail.
+	Health struct {
+		Status string                 `json:"status"`
+		Checks map[string]interface{} `json:"checks,omitempty"` // status of backends.
+		Output string                 `json:"output,omitempty"`
+	}
+)
+
+// IsPassed returns whether QuantumLeap is ready to serve.
+func (h *Health) IsPassed() bool {
+	return h.Status == HealthPass
+}
+
+// GetHealth gets health of QuantumLeap and its backends. Returns the health with no error even when it is fail.
+func (a Accessor) GetHealth() (*Health, error) {
+	return a.GetHealthCtx(context.Background())
+}
+
+// GetHealthCtx is GetHealth with given context.
+func (a Accessor) GetHealthCtx(ctx context.Context) (*Health, error) {
+	req, err := common.GenRequest(ctx, gohttp.HttpMethods.GET, fmt.Sprintf("%s/health", a.BaseUrl), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := a.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if http.StatusBadRequest <= res.StatusCode && res.StatusCode != http.StatusServiceUnavailable {
+		return nil, newAPIError(res)
+	}
+	var h Health
+	if err := gohttp.ResponseJSONToParams(res, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
@@ -0,0 +1,39 @@
+package quantumleap
+
+import (
+	"context"
+
+	"github.com/marrbor/go-fiware-api/ngsi"
+	"github.com/marrbor/gohttp"
+)
+
+type (
+	// Notification is an NGSIv2 notification payload. QuantumLeap accepts normalized entities only.
+	Notification struct {
+		SubscriptionID string         `json:"subscriptionId"`
+		Data           []*ngsi.Entity `json:"data"`
+	}
+)
+
+// NewNotification returns new Notification instance that holds given entities.
+func NewNotification(entities ...*ngsi.Entity) *Notification {
+	return &Notification{Data: entities}
+}
+
+// Notify sends given notification to QuantumLeap directly. Entities are stored as the same as notified by Orion.
+func (a Accessor) Notify(service, servicePath string, n *Notification) error {
+	return a.NotifyCtx(context.Background(), service, servicePath, n)
+}
+
+// NotifyCtx is Notify with given context.
+func (a Accessor) NotifyCtx(ctx context.Context, service, servicePath string, n *Notification) error {
+	u, err := a.genURL(nil, "notify")
+	if err != nil {
+		return err
+	}
+	res, err := a.send(ctx, gohttp.HttpMethods.POST, service, servicePath, u, n)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
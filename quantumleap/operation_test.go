@@ -0,0 +1,135 @@
+package quantumleap_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/go-fiware-api/ngsi"
+	"github.com/marrbor/go-fiware-api/quantumleap"
+	"github.com/stretchr/testify/assert"
+)
+
+type received struct {
+	method, path, body string
+	query              url.Values
+	header             http.Header
+}
+
+func newRecorder(status int, body string, got *received) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		*got = received{method: r.Method, path: r.URL.Path, body: string(b), query: r.URL.Query(), header: r.Header}
+		w.WriteHeader(status)
+		_, _ = fmt.Fprint(w, body)
+	}))
+}
+
+func TestAccessor_Notify(t *testing.T) {
+	var got received
+	ts := newRecorder(http.StatusOK, `"Notification successfully processed"`, &got)
+	defer ts.Close()
+	a := quantumleap.NewAccessor(ts.URL)
+
+	e := ngsi.NewEntity("Room1", "Room").Set("temperature", ngsi.NewAttribute(ngsi.Number, 20))
+	assert.NoError(t, a.Notify("openiot", "/a", quantumleap.NewNotification(e)))
+	assert.EqualValues(t, http.MethodPost, got.method)
+	assert.EqualValues(t, "/v2/notify", got.path)
+	assert.EqualValues(t, "openiot", got.header.Get(common.ServiceHeader))
+	assert.EqualValues(t, "/a", got.header.Get(common.ServicePathHeader))
+	assert.JSONEq(t, `{"subscriptionId":"","data":[{"id":"Room1","type":"Room","temperature":{"type":"Number","value":20}}]}`, got.body)
+
+	// error payload is surfaced.
+	es := newRecorder(http.StatusBadRequest, `{"error":"Bad Request","description":"'data' is a required property"}`, &got)
+	defer es.Close()
+	err := quantumleap.NewAccessor(es.URL).Notify("", "", quantumleap.NewNotification(e))
+	assert.False(t, quantumleap.IsNotFound(err))
+	apiErr := quantumleap.AsAPIError(err)
+	assert.NotNil(t, apiErr)
+	assert.EqualValues(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.EqualValues(t, "'data' is a required property", apiErr.Description)
+	assert.EqualError(t, err, fmt.Sprintf("400 Bad Request: Bad Request ('data' is a required property) [POST %s/v2/notify]", es.URL))
+}
+
+func TestAccessor_Subscribe(t *testing.T) {
+	var got received
+	ts := newRecorder(http.StatusCreated, "", &got)
+	defer ts.Close()
+	a := quantumleap.NewAccessor(ts.URL)
+
+	s := &quantumleap.Subscribe{
+		OrionURL:           "http://orion:1026/v2",
+		QuantumLeapURL:     "http://quantumleap:8668/v2",
+		EntityType:         "Room",
+		IDPattern:          "Room.*",
+		ObservedAttributes: []string{"temperature"},
+		NotifiedAttributes: []string{"temperature", "pressure"},
+		Throttling:         5,
+		TimeIndexAttribute: "observedAt",
+	}
+	assert.NoError(t, a.Subscribe("openiot", "/a", s))
+	assert.EqualValues(t, http.MethodPost, got.method)
+	assert.EqualValues(t, "/v2/subscribe", got.path)
+	assert.EqualValues(t, url.Values{
+		"orionUrl":           {"http://orion:1026/v2"},
+		"quantumleapUrl":     {"http://quantumleap:8668/v2"},
+		"entityType":         {"Room"},
+		"idPattern":          {"Room.*"},
+		"observedAttributes": {"temperature"},
+		"notifiedAttributes": {"temperature,pressure"},
+		"throttling":         {"5"},
+		"timeIndexAttribute": {"observedAt"},
+	}, got.query)
+	assert.EqualValues(t, "openiot", got.header.Get(common.ServiceHeader))
+
+	s.EntityID = "Room1"
+	assert.EqualError(t, a.Subscribe("", "", s), quantumleap.IncompatibleSubjectError.Error())
+	assert.EqualError(t, a.Subscribe("", "", &quantumleap.Subscribe{OrionURL: "http://orion:1026/v2"}), quantumleap.MissingURLError.Error())
+}
+
+func TestAccessor_Delete(t *testing.T) {
+	var got received
+	ts := newRecorder(http.StatusNoContent, "", &got)
+	defer ts.Close()
+	a := quantumleap.NewAccessor(ts.URL)
+
+	to := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, a.DeleteEntity("openiot", "/a", "urn:ngsi-ld:Room:1", quantumleap.NewQuery().SetType("Room").SetToDate(to)))
+	assert.EqualValues(t, http.MethodDelete, got.method)
+	assert.EqualValues(t, "/v2/entities/urn:ngsi-ld:Room:1", got.path)
+	assert.EqualValues(t, "Room", got.query.Get("type"))
+	assert.EqualValues(t, "2020-01-01T00:00:00.000Z", got.query.Get("toDate"))
+	assert.EqualValues(t, "/a", got.header.Get(common.ServicePathHeader))
+
+	assert.NoError(t, a.DeleteType("openiot", "/a", "Room", quantumleap.NewQuery().SetFromDate(to)))
+	assert.EqualValues(t, "/v2/types/Room", got.path)
+	assert.EqualValues(t, "2020-01-01T00:00:00.000Z", got.query.Get("fromDate"))
+
+	ns := newRecorder(http.StatusNotFound, `{"error":"Not Found","description":"No records were found for such query."}`, &got)
+	defer ns.Close()
+	err := quantumleap.NewAccessor(ns.URL).DeleteType("", "", "Room", nil)
+	assert.True(t, quantumleap.IsNotFound(err))
+}
+
+func TestAccessor_GetHealth(t *testing.T) {
+	var got received
+	ts := newRecorder(http.StatusOK, `{"status":"pass"}`, &got)
+	defer ts.Close()
+	h, err := quantumleap.NewAccessor(ts.URL).GetHealth()
+	assert.NoError(t, err)
+	assert.True(t, h.IsPassed())
+	assert.EqualValues(t, "/health", got.path)
+
+	fs := newRecorder(http.StatusServiceUnavailable, `{"status":"fail","checks":{"crateDB":{"status":"fail"}}}`, &got)
+	defer fs.Close()
+	h, err = quantumleap.NewAccessor(fs.URL).GetHealth()
+	assert.NoError(t, err)
+	assert.False(t, h.IsPassed())
+	assert.EqualValues(t, quantumleap.HealthFail, h.Status)
+	assert.Contains(t, h.Checks, "crateDB")
+}
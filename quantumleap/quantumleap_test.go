@@ -2,25 +2,63 @@
 package quantumleap_test
 
 import (
-	"os"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/marrbor/go-fiware-api/common"
 	"github.com/marrbor/go-fiware-api/quantumleap"
 	"github.com/stretchr/testify/assert"
-)
-
-const (
-	EnvQuantumLeapUrl     = "QL_URL"
-	DefaultQuantumLeapUrl = "http://localhost/quantumleap"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAccessor_GetVersion(t *testing.T) {
-	url := DefaultQuantumLeapUrl
-	if 0 < len(os.Getenv(EnvQuantumLeapUrl)) {
-		url = os.Getenv(EnvQuantumLeapUrl)
-	}
-	a := quantumleap.NewAccessor(url)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.EqualValues(t, "/v2/version", r.URL.Path)
+		_, _ = fmt.Fprint(w, `{"version":"0.7.5"}`)
+	}))
+	defer ts.Close()
+
+	a := quantumleap.NewAccessor(ts.URL)
+	v, err := a.GetVersion()
+	require.NoError(t, err)
+	assert.EqualValues(t, "0.7.5", v.Version)
+}
+
+func TestAccessor_Credentials(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(common.AuthorizationHeader) != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"version":"0.7.5"}`)
+	}))
+	defer ts.Close()
+
+	a := quantumleap.NewAccessor(ts.URL)
+	_, err := a.GetVersion()
+	assert.Error(t, err)
+
+	a.Credentials = common.NewBearerToken("secret")
 	v, err := a.GetVersion()
 	assert.NoError(t, err)
 	assert.EqualValues(t, "0.7.5", v.Version)
 }
+
+func TestAccessor_GetVersionError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(common.CorrelatorHeader, "version-1")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = fmt.Fprint(w, `{"error":"Internal Server Error","description":"crate is not reachable"}`)
+	}))
+	defer ts.Close()
+
+	_, err := quantumleap.NewAccessor(ts.URL).GetVersion()
+	e := quantumleap.AsAPIError(err)
+	require.NotNil(t, e)
+	assert.EqualValues(t, http.StatusInternalServerError, e.StatusCode)
+	assert.EqualValues(t, "crate is not reachable", e.Description)
+	assert.EqualValues(t, "version-1", common.CorrelatorOf(err))
+	assert.False(t, quantumleap.IsNotFound(err))
+}
@@ -0,0 +1,217 @@
+package quantumleap
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marrbor/go-fiware-api/ngsi"
+)
+
+var (
+	InvalidAggregationError = fmt.Errorf("aggrPeriod and aggrScope require aggrMethod")
+	InvalidNumberError      = fmt.Errorf("lastN, limit and offset have to be positive")
+)
+
+// Aggregation method, period and scope
+type (
+	AggrMethod struct{ value string }
+	AggrPeriod struct{ value string }
+	AggrScope  struct{ value string }
+)
+
+// String returns aggregation method strings.
+func (m AggrMethod) String() string {
+	return m.value
+}
+
+// String returns aggregation period strings.
+func (p AggrPeriod) String() string {
+	return p.value
+}
+
+// String returns aggregation scope strings.
+func (s AggrScope) String() string {
+	return s.value
+}
+
+var (
+	// AggrMethods holds possible aggrMethod value.
+	AggrMethods = struct {
+		Count AggrMethod
+		Sum   AggrMethod
+		Avg   AggrMethod
+		Min   AggrMethod
+		Max   AggrMethod
+	}{
+		Count: AggrMethod{"count"},
+		Sum:   AggrMethod{"sum"},
+		Avg:   AggrMethod{"avg"},
+		Min:   AggrMethod{"min"},
+		Max:   AggrMethod{"max"},
+	}
+
+	// AggrPeriods holds possible aggrPeriod value.
+	AggrPeriods = struct {
+		Year   AggrPeriod
+		Month  AggrPeriod
+		Day    AggrPeriod
+		Hour   AggrPeriod
+		Minute AggrPeriod
+		Second AggrPeriod
+	}{
+		Year:   AggrPeriod{"year"},
+		Month:  AggrPeriod{"month"},
+		Day:    AggrPeriod{"day"},
+		Hour:   AggrPeriod{"hour"},
+		Minute: AggrPeriod{"minute"},
+		Second: AggrPeriod{"second"},
+	}
+
+	// AggrScopes holds possible aggrScope value. Global is valid for /types and /attrs only.
+	AggrScopes = struct {
+		Entity AggrScope
+		Global AggrScope
+	}{
+		Entity: AggrScope{"entity"},
+		Global: AggrScope{"global"},
+	}
+)
+
+// Query is a structure for handling query for QuantumLeap.
+type Query struct {
+	queries map[string]string
+}
+
+// SetToURL sets query attribute for given url instance.
+func (q *Query) SetToURL(u *url.URL) {
+	uq := u.Query()
+	for k, v := range q.queries {
+		uq.Set(k, v)
+	}
+	u.RawQuery = uq.Encode()
+}
+
+// SetToRequest sets query attribute for given http request.
+func (q *Query) SetToRequest(r *http.Request) {
+	q.SetToURL(r.URL)
+}
+
+// IsExists returns whether the given key has been already existing or not.
+func (q *Query) IsExists(k string) bool {
+	_, ok := q.queries[k]
+	return ok
+}
+
+// GetQuery returns value of given key. Returns empty string when the key does not exist.
+func (q *Query) GetQuery(k string) string {
+	return q.queries[k]
+}
+
+// SetQuery sets given query strings (key & value) into this instance.
+// If the same key has been already exists, replace it.
+func (q *Query) SetQuery(k, v string) *Query {
+	q.queries[k] = v
+	return q
+}
+
+// RemoveQuery removes given key from this instance.
+func (q *Query) RemoveQuery(k string) *Query {
+	delete(q.queries, k)
+	return q
+}
+
+// SetType sets type of the entity. Use it to disambiguate entities that have the same ID.
+// Example: Room.
+func (q *Query) SetType(t string) *Query {
+	return q.SetQuery("type", t)
+}
+
+// SetIDs sets comma-separated list of entity IDs to be retrieved. Use for /types and /attrs.
+// Example: Room1,Room2.
+func (q *Query) SetIDs(ids []string) *Query {
+	return q.SetQuery("id", strings.Join(ids, ","))
+}
+
+// SetAttrs sets comma-separated list of attribute names to be retrieved. Use for /entities and /types.
+// Example: temperature,pressure.
+func (q *Query) SetAttrs(attrs []string) *Query {
+	return q.SetQuery("attrs", strings.Join(attrs, ","))
+}
+
+// SetFromDate sets the starting date and time (inclusive) from which the records are retrieved.
+func (q *Query) SetFromDate(t time.Time) *Query {
+	return q.SetQuery("fromDate", t.UTC().Format(ngsi.DateTimeFormat))
+}
+
+// SetToDate sets the final date and time (inclusive) until which the records are retrieved.
+func (q *Query) SetToDate(t time.Time) *Query {
+	return q.SetQuery("toDate", t.UTC().Format(ngsi.DateTimeFormat))
+}
+
+// SetLastN sets the number of the most recent records to be retrieved.
+// Example: 10.
+func (q *Query) SetLastN(n int) *Query {
+	return q.SetQuery("lastN", strconv.Itoa(n))
+}
+
+// SetLimit sets limits the number of records to be retrieved.
+// Example: 20.
+func (q *Query) SetLimit(limit int) *Query {
+	return q.SetQuery("limit", strconv.Itoa(limit))
+}
+
+// SetOffset sets the offset from where records are retrieved.
+// Example: 20.
+func (q *Query) SetOffset(offset int) *Query {
+	return q.SetQuery("offset", strconv.Itoa(offset))
+}
+
+// SetAggrMethod sets the function used to aggregate the values.
+func (q *Query) SetAggrMethod(m AggrMethod) *Query {
+	return q.SetQuery("aggrMethod", m.value)
+}
+
+// SetAggrPeriod sets the time period by which the values are grouped. Requires aggrMethod.
+func (q *Query) SetAggrPeriod(p AggrPeriod) *Query {
+	return q.SetQuery("aggrPeriod", p.value)
+}
+
+// SetAggrScope sets whether the values are aggregated per entity or across the entities. Requires aggrMethod.
+func (q *Query) SetAggrScope(s AggrScope) *Query {
+	return q.SetQuery("aggrScope", s.value)
+}
+
+// check returns error when the query is rejected by QuantumLeap.
+func (q *Query) check() error {
+	if !q.IsExists("aggrMethod") && (q.IsExists("aggrPeriod") || q.IsExists("aggrScope")) {
+		return InvalidAggregationError
+	}
+	for _, k := range []string{"lastN", "limit", "offset"} {
+		if !q.IsExists(k) {
+			continue
+		}
+		n, err := strconv.Atoi(q.queries[k])
+		if err != nil || n < 0 || (n == 0 && k != "offset") {
+			return InvalidNumberError
+		}
+	}
+	return nil
+}
+
+// Clone returns a copy of this instance.
+func (q *Query) Clone() *Query {
+	c := NewQuery()
+	for k, v := range q.queries {
+		c.queries[k] = v
+	}
+	return c
+}
+
+// NewQuery returns new (empty) Query instance.
+func NewQuery() *Query {
+	return &Query{queries: make(map[string]string)}
+}
@@ -0,0 +1,141 @@
+package quantumleap
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	InvalidIndexError  = fmt.Errorf("invalid time index")
+	InvalidSeriesError = fmt.Errorf("number of values does not match the index")
+	NotNumberError     = fmt.Errorf("not a number")
+)
+
+// layouts of the time index. QuantumLeap omits the offset of aggregated indexes.
+var indexLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+}
+
+// Series is a time series of an entity. Values of every attribute have the same length as Index.
+type Series struct {
+	EntityID   string
+	EntityType string
+	Index      []time.Time
+	Attrs      []string                 // attribute names in the order QuantumLeap returned.
+	Values     map[string][]interface{} // values of each attribute.
+}
+
+// Get returns values of given attribute.
+func (s *Series) Get(attr string) ([]interface{}, bool) {
+	v, ok := s.Values[attr]
+	return v, ok
+}
+
+// Float64s returns values of given attribute as float64. Null values are NaN.
+func (s *Series) Float64s(attr string) ([]float64, error) {
+	vs := s.Values[attr]
+	fs := make([]float64, len(vs))
+	for i, v := range vs {
+		switch n := v.(type) {
+		case nil:
+			fs[i] = math.NaN()
+		case float64:
+			fs[i] = n
+		default:
+			return nil, fmt.Errorf("%s[%d]: %w", attr, i, NotNumberError)
+		}
+	}
+	return fs, nil
+}
+
+type (
+	// rawAttr is a values of an attribute in the response.
+	rawAttr struct {
+		AttrName string        `json:"attrName"`
+		Values   []interface{} `json:"values"`
+	}
+
+	// rawSeries covers every shape of the responses. It is a leaf when it has index, otherwise a container of
+	// entities, types or wrapped values.
+	rawSeries struct {
+		EntityID   string          `json:"entityId"`
+		EntityType string          `json:"entityType"`
+		AttrName   string          `json:"attrName"`
+		Index      []string        `json:"index"`
+		Attributes []rawAttr       `json:"attributes"`
+		Values     json.RawMessage `json:"values"`
+		Entities   []rawSeries     `json:"entities"`
+		Types      []rawSeries     `json:"types"`
+	}
+)
+
+// parseIndex parses a time index. Indexes without offset are UTC.
+func parseIndex(s string) (time.Time, error) {
+	for _, l := range indexLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%s: %w", s, InvalidIndexError)
+}
+
+// series flattens given response into series. Empty ID, type and attribute name are inherited from the parent.
+func (r *rawSeries) series(parent rawSeries) ([]Series, error) {
+	if len(r.EntityID) <= 0 {
+		r.EntityID = parent.EntityID
+	}
+	if len(r.EntityType) <= 0 {
+		r.EntityType = parent.EntityType
+	}
+	if len(r.AttrName) <= 0 {
+		r.AttrName = parent.AttrName
+	}
+
+	if r.Index == nil {
+		children := append(append([]rawSeries{}, r.Types...), r.Entities...)
+		if len(children) <= 0 && 0 < len(r.Values) {
+			if err := json.Unmarshal(r.Values, &children); err != nil {
+				return nil, err
+			}
+		}
+		list := make([]Series, 0, len(children))
+		for i := range children {
+			ss, err := children[i].series(*r)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, ss...)
+		}
+		return list, nil
+	}
+
+	s := Series{EntityID: r.EntityID, EntityType: r.EntityType, Index: make([]time.Time, len(r.Index)), Values: make(map[string][]interface{})}
+	for i, idx := range r.Index {
+		t, err := parseIndex(idx)
+		if err != nil {
+			return nil, err
+		}
+		s.Index[i] = t
+	}
+	if r.Attributes == nil && 0 < len(r.AttrName) {
+		var vs []interface{}
+		if 0 < len(r.Values) {
+			if err := json.Unmarshal(r.Values, &vs); err != nil {
+				return nil, err
+			}
+		}
+		r.Attributes = []rawAttr{{AttrName: r.AttrName, Values: vs}}
+	}
+	for _, at := range r.Attributes {
+		if len(at.Values) != len(s.Index) {
+			return nil, fmt.Errorf("%s of %s: %d values for %d indexes: %w", at.AttrName, r.EntityID, len(at.Values), len(s.Index), InvalidSeriesError)
+		}
+		s.Attrs = append(s.Attrs, at.AttrName)
+		s.Values[at.AttrName] = at.Values
+	}
+	return []Series{s}, nil
+}
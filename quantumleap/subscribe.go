@@ -0,0 +1,85 @@
+package quantumleap
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/marrbor/gohttp"
+)
+
+var (
+	MissingURLError          = fmt.Errorf("both orionUrl and quantumleapUrl are required")
+	IncompatibleSubjectError = fmt.Errorf("entityId and idPattern are incompatible")
+)
+
+type (
+	// Subscribe holds parameters of the subscription QuantumLeap creates on Orion so that Orion notifies QuantumLeap.
+	Subscribe struct {
+		OrionURL           string   // base URL of Orion seen from QuantumLeap. ex) http://orion:1026/v2
+		QuantumLeapURL     string   // base URL of QuantumLeap seen from Orion. ex) http://quantumleap:8668/v2
+		EntityType         string   // type of entities to be subscribed.
+		EntityID           string   // ID of the entity to be subscribed. Incompatible with IDPattern.
+		IDPattern          string   // regular expression of IDs to be subscribed.
+		Attributes         []string // attributes to be observed and notified. Overridden by following two fields.
+		ObservedAttributes []string // attributes whose change triggers a notification.
+		NotifiedAttributes []string // attributes included in the notification.
+		Throttling         int      // minimum seconds between notifications. Not set when 0.
+		TimeIndexAttribute string   // attribute or metadata used as time index. ex) observedAt
+	}
+)
+
+// query returns query parameters of POST /v2/subscribe.
+func (s *Subscribe) query() (*Query, error) {
+	if len(s.OrionURL) <= 0 || len(s.QuantumLeapURL) <= 0 {
+		return nil, MissingURLError
+	}
+	if 0 < len(s.EntityID) && 0 < len(s.IDPattern) {
+		return nil, IncompatibleSubjectError
+	}
+	if s.Throttling < 0 {
+		return nil, InvalidNumberError
+	}
+	q := NewQuery().SetQuery("orionUrl", s.OrionURL).SetQuery("quantumleapUrl", s.QuantumLeapURL)
+	for k, v := range map[string]string{
+		"entityType":         s.EntityType,
+		"entityId":           s.EntityID,
+		"idPattern":          s.IDPattern,
+		"attributes":         strings.Join(s.Attributes, ","),
+		"observedAttributes": strings.Join(s.ObservedAttributes, ","),
+		"notifiedAttributes": strings.Join(s.NotifiedAttributes, ","),
+		"timeIndexAttribute": s.TimeIndexAttribute,
+	} {
+		if 0 < len(v) {
+			q.SetQuery(k, v)
+		}
+	}
+	if 0 < s.Throttling {
+		q.SetQuery("throttling", strconv.Itoa(s.Throttling))
+	}
+	return q, nil
+}
+
+// Subscribe makes QuantumLeap create the subscription on Orion in given tenant. Use instead of creating the
+// subscription by orion.CreateSubscription to get the notification format QuantumLeap expects.
+func (a Accessor) Subscribe(service, servicePath string, s *Subscribe) error {
+	return a.SubscribeCtx(context.Background(), service, servicePath, s)
+}
+
+// SubscribeCtx is Subscribe with given context.
+func (a Accessor) SubscribeCtx(ctx context.Context, service, servicePath string, s *Subscribe) error {
+	q, err := s.query()
+	if err != nil {
+		return err
+	}
+	u, err := a.genURL(q, "subscribe")
+	if err != nil {
+		return err
+	}
+	res, err := a.send(ctx, gohttp.HttpMethods.POST, service, servicePath, u, nil)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
@@ -0,0 +1,158 @@
+package quantumleap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/marrbor/gohttp"
+)
+
+var (
+	// NotFoundError is returned when QuantumLeap has no records for the query.
+	NotFoundError = fmt.Errorf("no records were found")
+)
+
+// IsNotFound returns whether given error means that no records were found.
+func IsNotFound(err error) bool {
+	return errors.Is(err, NotFoundError)
+}
+
+// getSeries gets time series from given path and flattens them. parent holds ID, type and attribute name that the
+// response may omit.
+func (a Accessor) getSeries(ctx context.Context, service, servicePath string, q *Query, parent rawSeries, elements ...string) ([]Series, error) {
+	u, err := a.genURL(q, elements...)
+	if err != nil {
+		return nil, err
+	}
+	res, err := a.send(ctx, gohttp.HttpMethods.GET, service, servicePath, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	var r rawSeries
+	if err := gohttp.ResponseJSONToParams(res, &r); err != nil {
+		return nil, err
+	}
+	return r.series(parent)
+}
+
+// getOne gets time series of an entity.
+func (a Accessor) getOne(ctx context.Context, service, servicePath string, q *Query, parent rawSeries, elements ...string) (*Series, error) {
+	list, err := a.getSeries(ctx, service, servicePath, q, parent, elements...)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) <= 0 {
+		return nil, NotFoundError
+	}
+	return &list[0], nil
+}
+
+// entityParent returns ID and type of the entity that value responses omit.
+func entityParent(id, attr string, q *Query) rawSeries {
+	p := rawSeries{EntityID: id, AttrName: attr}
+	if q != nil {
+		p.EntityType = q.GetQuery("type")
+	}
+	return p
+}
+
+// GetEntitySeries gets time series of all or specified attributes of the entity that has specified ID.
+func (a Accessor) GetEntitySeries(service, servicePath, id string, q *Query) (*Series, error) {
+	return a.GetEntitySeriesCtx(context.Background(), service, servicePath, id, q)
+}
+
+// GetEntitySeriesCtx is GetEntitySeries with given context.
+func (a Accessor) GetEntitySeriesCtx(ctx context.Context, service, servicePath, id string, q *Query) (*Series, error) {
+	return a.getOne(ctx, service, servicePath, q, entityParent(id, "", q), "entities", id)
+}
+
+// GetEntityValues gets time series of the entity that has specified ID via /value endpoint.
+func (a Accessor) GetEntityValues(service, servicePath, id string, q *Query) (*Series, error) {
+	return a.GetEntityValuesCtx(context.Background(), service, servicePath, id, q)
+}
+
+// GetEntityValuesCtx is GetEntityValues with given context.
+func (a Accessor) GetEntityValuesCtx(ctx context.Context, service, servicePath, id string, q *Query) (*Series, error) {
+	return a.getOne(ctx, service, servicePath, q, entityParent(id, "", q), "entities", id, "value")
+}
+
+// GetEntityAttrSeries gets time series of specified attribute of the entity that has specified ID.
+func (a Accessor) GetEntityAttrSeries(service, servicePath, id, attr string, q *Query) (*Series, error) {
+	return a.GetEntityAttrSeriesCtx(context.Background(), service, servicePath, id, attr, q)
+}
+
+// GetEntityAttrSeriesCtx is GetEntityAttrSeries with given context.
+func (a Accessor) GetEntityAttrSeriesCtx(ctx context.Context, service, servicePath, id, attr string, q *Query) (*Series, error) {
+	return a.getOne(ctx, service, servicePath, q, entityParent(id, attr, q), "entities", id, "attrs", attr)
+}
+
+// GetEntityAttrValues gets time series of specified attribute of the entity via /value endpoint.
+func (a Accessor) GetEntityAttrValues(service, servicePath, id, attr string, q *Query) (*Series, error) {
+	return a.GetEntityAttrValuesCtx(context.Background(), service, servicePath, id, attr, q)
+}
+
+// GetEntityAttrValuesCtx is GetEntityAttrValues with given context.
+func (a Accessor) GetEntityAttrValuesCtx(ctx context.Context, service, servicePath, id, attr string, q *Query) (*Series, error) {
+	return a.getOne(ctx, service, servicePath, q, entityParent(id, attr, q), "entities", id, "attrs", attr, "value")
+}
+
+// GetTypeSeries gets time series of entities that have specified type.
+func (a Accessor) GetTypeSeries(service, servicePath, typeName string, q *Query) ([]Series, error) {
+	return a.GetTypeSeriesCtx(context.Background(), service, servicePath, typeName, q)
+}
+
+// GetTypeSeriesCtx is GetTypeSeries with given context.
+func (a Accessor) GetTypeSeriesCtx(ctx context.Context, service, servicePath, typeName string, q *Query) ([]Series, error) {
+	return a.getSeries(ctx, service, servicePath, q, rawSeries{EntityType: typeName}, "types", typeName)
+}
+
+// GetTypeValues gets time series of entities that have specified type via /value endpoint.
+func (a Accessor) GetTypeValues(service, servicePath, typeName string, q *Query) ([]Series, error) {
+	return a.GetTypeValuesCtx(context.Background(), service, servicePath, typeName, q)
+}
+
+// GetTypeValuesCtx is GetTypeValues with given context.
+func (a Accessor) GetTypeValuesCtx(ctx context.Context, service, servicePath, typeName string, q *Query) ([]Series, error) {
+	return a.getSeries(ctx, service, servicePath, q, rawSeries{EntityType: typeName}, "types", typeName, "value")
+}
+
+// GetTypeAttrSeries gets time series of specified attribute of entities that have specified type.
+func (a Accessor) GetTypeAttrSeries(service, servicePath, typeName, attr string, q *Query) ([]Series, error) {
+	return a.GetTypeAttrSeriesCtx(context.Background(), service, servicePath, typeName, attr, q)
+}
+
+// GetTypeAttrSeriesCtx is GetTypeAttrSeries with given context.
+func (a Accessor) GetTypeAttrSeriesCtx(ctx context.Context, service, servicePath, typeName, attr string, q *Query) ([]Series, error) {
+	return a.getSeries(ctx, service, servicePath, q, rawSeries{EntityType: typeName, AttrName: attr}, "types", typeName, "attrs", attr)
+}
+
+// GetTypeAttrValues gets time series of specified attribute of entities that have specified type via /value endpoint.
+func (a Accessor) GetTypeAttrValues(service, servicePath, typeName, attr string, q *Query) ([]Series, error) {
+	return a.GetTypeAttrValuesCtx(context.Background(), service, servicePath, typeName, attr, q)
+}
+
+// GetTypeAttrValuesCtx is GetTypeAttrValues with given context.
+func (a Accessor) GetTypeAttrValuesCtx(ctx context.Context, service, servicePath, typeName, attr string, q *Query) ([]Series, error) {
+	return a.getSeries(ctx, service, servicePath, q, rawSeries{EntityType: typeName, AttrName: attr}, "types", typeName, "attrs", attr, "value")
+}
+
+// GetAttrSeries gets time series of specified attribute of all entities.
+func (a Accessor) GetAttrSeries(service, servicePath, attr string, q *Query) ([]Series, error) {
+	return a.GetAttrSeriesCtx(context.Background(), service, servicePath, attr, q)
+}
+
+// GetAttrSeriesCtx is GetAttrSeries with given context.
+func (a Accessor) GetAttrSeriesCtx(ctx context.Context, service, servicePath, attr string, q *Query) ([]Series, error) {
+	return a.getSeries(ctx, service, servicePath, q, rawSeries{AttrName: attr}, "attrs", attr)
+}
+
+// GetAttrValues gets time series of specified attribute of all entities via /value endpoint.
+func (a Accessor) GetAttrValues(service, servicePath, attr string, q *Query) ([]Series, error) {
+	return a.GetAttrValuesCtx(context.Background(), service, servicePath, attr, q)
+}
+
+// GetAttrValuesCtx is GetAttrValues with given context.
+func (a Accessor) GetAttrValuesCtx(ctx context.Context, service, servicePath, attr string, q *Query) ([]Series, error) {
+	return a.getSeries(ctx, service, servicePath, q, rawSeries{AttrName: attr}, "attrs", attr, "value")
+}
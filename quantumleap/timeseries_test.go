@@ -0,0 +1,146 @@
+package quantumleap_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/marrbor/go-fiware-api/common"
+	"github.com/marrbor/go-fiware-api/quantumleap"
+	"github.com/stretchr/testify/assert"
+)
+
+// canned responses of QuantumLeap keyed by path.
+var responses = map[string]string{
+	"/v2/entities/Room1": `{"entityId":"Room1","entityType":"Room","index":["2020-01-01T00:00:00.000+00:00","2020-01-01T00:01:00.000+00:00"],
+		"attributes":[{"attrName":"temperature","values":[20.5,null]},{"attrName":"floor","values":["1F","1F"]}]}`,
+	"/v2/entities/Room2":                         `{"entityId":"Room2","index":["2020-01-01T00:00:00Z","2020-01-01T00:01:00Z"],"attributes":[{"attrName":"temperature","values":[1]}]}`,
+	"/v2/entities/Room1/value":                   `{"index":["2020-01-01T00:00:00.000"],"attributes":[{"attrName":"temperature","values":[21]}]}`,
+	"/v2/entities/Room1/attrs/temperature":       `{"entityId":"Room1","entityType":"Room","attrName":"temperature","index":["2020-01-01T00:00:00Z"],"values":[22]}`,
+	"/v2/entities/Room1/attrs/temperature/value": `{"index":["2020-01-01T00:00:00.000"],"values":[23]}`,
+	"/v2/types/Room": `{"entityType":"Room","entities":[{"entityId":"Room1","index":["2020-01-01T00:00:00.000"],"attributes":[{"attrName":"temperature","values":[1]}]},
+		{"entityId":"Room2","index":["2020-01-01T00:00:00.000"],"attributes":[{"attrName":"temperature","values":[2]}]}]}`,
+	"/v2/types/Room/attrs/temperature/value": `{"values":[{"entityId":"Room1","index":["2020-01-01T00:00:00.000"],"values":[3]}]}`,
+	"/v2/attrs/temperature": `{"attrName":"temperature","types":[{"entityType":"Room","entities":[{"entityId":"Room1","index":["2020-01-01T00:00:00.000"],"values":[4]}]},
+		{"entityType":"Car","entities":[{"entityId":"Car1","index":["2020-01-01T00:00:00.000"],"values":[5]}]}]}`,
+}
+
+func newServer(check func(r *http.Request)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if check != nil {
+			check(r)
+		}
+		b, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"error":"Not Found","description":"No records were found for such query."}`)
+			return
+		}
+		_, _ = fmt.Fprint(w, b)
+	}))
+}
+
+func TestAccessor_GetEntitySeries(t *testing.T) {
+	var query url.Values
+	var header http.Header
+	ts := newServer(func(r *http.Request) {
+		query, header = r.URL.Query(), r.Header
+	})
+	defer ts.Close()
+	a := quantumleap.NewAccessor(ts.URL)
+
+	from := time.Date(2020, 1, 1, 9, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+	q := quantumleap.NewQuery().SetFromDate(from).SetToDate(from.Add(time.Hour)).SetLastN(10).
+		SetAggrMethod(quantumleap.AggrMethods.Avg).SetAggrPeriod(quantumleap.AggrPeriods.Minute).SetAttrs([]string{"temperature", "floor"})
+	s, err := a.GetEntitySeries("openiot", "/a", "Room1", q)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "2020-01-01T00:00:00.000Z", query.Get("fromDate"))
+	assert.EqualValues(t, "2020-01-01T01:00:00.000Z", query.Get("toDate"))
+	assert.EqualValues(t, "10", query.Get("lastN"))
+	assert.EqualValues(t, "avg", query.Get("aggrMethod"))
+	assert.EqualValues(t, "minute", query.Get("aggrPeriod"))
+	assert.EqualValues(t, "temperature,floor", query.Get("attrs"))
+	assert.EqualValues(t, "openiot", header.Get(common.ServiceHeader))
+	assert.EqualValues(t, "/a", header.Get(common.ServicePathHeader))
+
+	assert.EqualValues(t, "Room1", s.EntityID)
+	assert.EqualValues(t, "Room", s.EntityType)
+	assert.EqualValues(t, []string{"temperature", "floor"}, s.Attrs)
+	assert.True(t, s.Index[1].Equal(time.Date(2020, 1, 1, 0, 1, 0, 0, time.UTC)))
+	fs, err := s.Float64s("temperature")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 20.5, fs[0])
+	assert.True(t, math.IsNaN(fs[1]))
+	_, err = s.Float64s("floor")
+	assert.True(t, errors.Is(err, quantumleap.NotNumberError))
+
+	// value responses omit the ID and type.
+	s, err = a.GetEntityValues("", "", "Room1", quantumleap.NewQuery().SetType("Room"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, "Room1", s.EntityID)
+	assert.EqualValues(t, "Room", s.EntityType)
+	assert.True(t, s.Index[0].Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.EqualValues(t, []interface{}{21.0}, s.Values["temperature"])
+
+	s, err = a.GetEntityAttrSeries("", "", "Room1", "temperature", nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []interface{}{22.0}, s.Values["temperature"])
+	s, err = a.GetEntityAttrValues("", "", "Room1", "temperature", nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "Room1", s.EntityID)
+	assert.EqualValues(t, []interface{}{23.0}, s.Values["temperature"])
+}
+
+func TestAccessor_GetTypeSeries(t *testing.T) {
+	ts := newServer(nil)
+	defer ts.Close()
+	a := quantumleap.NewAccessor(ts.URL)
+
+	list, err := a.GetTypeSeries("", "", "Room", nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(list))
+	assert.EqualValues(t, "Room2", list[1].EntityID)
+	assert.EqualValues(t, "Room", list[1].EntityType)
+	assert.EqualValues(t, []interface{}{2.0}, list[1].Values["temperature"])
+
+	list, err = a.GetTypeAttrValues("", "", "Room", "temperature", nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, len(list))
+	assert.EqualValues(t, "Room", list[0].EntityType)
+	assert.EqualValues(t, []interface{}{3.0}, list[0].Values["temperature"])
+
+	list, err = a.GetAttrSeries("", "", "temperature", nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, len(list))
+	assert.EqualValues(t, "Car1", list[1].EntityID)
+	assert.EqualValues(t, "Car", list[1].EntityType)
+	assert.EqualValues(t, []interface{}{5.0}, list[1].Values["temperature"])
+}
+
+func TestAccessor_GetSeriesErrors(t *testing.T) {
+	ts := newServer(nil)
+	defer ts.Close()
+	a := quantumleap.NewAccessor(ts.URL)
+
+	_, err := a.GetEntitySeries("", "", "Room9", nil)
+	assert.True(t, quantumleap.IsNotFound(err))
+	e := quantumleap.AsAPIError(err)
+	assert.NotNil(t, e)
+	assert.EqualValues(t, http.StatusNotFound, e.StatusCode)
+	assert.EqualValues(t, "Not Found", e.Code)
+	assert.EqualValues(t, "No records were found for such query.", e.Description)
+	assert.Contains(t, err.Error(), "No records were found for such query.")
+	_, err = a.GetEntitySeries("", "", "Room2", nil)
+	assert.True(t, errors.Is(err, quantumleap.InvalidSeriesError))
+	_, err = a.GetEntitySeries("", "", "Room1", quantumleap.NewQuery().SetAggrPeriod(quantumleap.AggrPeriods.Day))
+	assert.EqualError(t, err, quantumleap.InvalidAggregationError.Error())
+	_, err = a.GetEntitySeries("", "", "Room1", quantumleap.NewQuery().SetLastN(0))
+	assert.EqualError(t, err, quantumleap.InvalidNumberError.Error())
+	_, err = a.GetEntitySeries("Invalid Service", "", "Room1", nil)
+	assert.Error(t, err)
+}
@@ -2,9 +2,11 @@
 package quantumleap
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 
+	"github.com/marrbor/go-fiware-api/common"
 	"github.com/marrbor/gohttp"
 )
 
@@ -16,16 +18,21 @@ type (
 
 // GetVersion gets version information from the server.
 func (a Accessor) GetVersion() (*Version, error){
-	req, err := gohttp.GenRequest(gohttp.HttpMethods.GET, fmt.Sprintf("%s/v2/version", a.BaseUrl), nil)
+	return a.GetVersionCtx(context.Background())
+}
+
+// GetVersionCtx gets version information from the server with given context.
+func (a Accessor) GetVersionCtx(ctx context.Context) (*Version, error) {
+	req, err := common.GenRequest(ctx, gohttp.HttpMethods.GET, fmt.Sprintf("%s/v2/version", a.BaseUrl), nil)
 	if err != nil {
 		return nil, err
 	}
-	res, err := a.HttpClient.Do(req)
+	res, err := a.do(req)
 	if err != nil {
 		return nil, err
 	}
 	if http.StatusBadRequest <= res.StatusCode {
-		return nil, fmt.Errorf(res.Status)
+		return nil, newAPIError(res)
 	}
 	var v Version
 	if err := gohttp.ResponseJSONToParams(res, &v); err != nil {